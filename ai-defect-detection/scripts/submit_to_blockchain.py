@@ -13,16 +13,17 @@ Usage:
         --serial-number SN-2025-001 \
         --material-type "Carbon Fiber Composite" \
         --inspector "Dr. John Smith" \
-        --bbox 74,308,192,412 \
+        --bbox 250,120,310,180 \
         --confidence 0.95
 """
 
 import argparse
+import base64
 import hashlib
 import json
 import subprocess
 import sys
-from datetime import datetime
+from datetime import datetime, timezone
 from pathlib import Path
 
 
@@ -60,24 +61,32 @@ def upload_to_ipfs(file_path):
         sys.exit(1)
 
 
-def submit_to_blockchain(inspection_data, org="manufacturer"):
+def submit_to_blockchain(inspection_data, private_data, org="manufacturer"):
     """
     Submit inspection data to the blockchain.
 
     Args:
-        inspection_data: Dictionary containing inspection information
+        inspection_data: Dictionary containing the public inspection information
+        private_data: Dictionary of private fields (e.g. inspector), sent as transient data
         org: Organization name ('manufacturer' or 'mrolab')
     """
     # Convert inspection data to JSON
     inspection_json = json.dumps(inspection_data)
 
-    # Prepare peer command based on organization
+    # Transaction arguments are written to the block, so private fields travel in the
+    # transient map instead; the peer CLI expects its values base64-encoded
+    transient_json = json.dumps({
+        "private": base64.b64encode(json.dumps(private_data).encode()).decode()
+    })
+
+    # Prepare peer command based on organization. Results are submitted by a certified
+    # inspector (role=inspector, ndt.method=thermography), not the org admin.
     if org.lower() == "manufacturer":
         peer_env = {
             "CORE_PEER_TLS_ENABLED": "true",
             "CORE_PEER_LOCALMSPID": "ManufacturerMSP",
             "CORE_PEER_TLS_ROOTCERT_FILE": "${PWD}/organizations/peerOrganizations/manufacturer.thermotrace.com/peers/peer0.manufacturer.thermotrace.com/tls/ca.crt",
-            "CORE_PEER_MSPCONFIGPATH": "${PWD}/organizations/peerOrganizations/manufacturer.thermotrace.com/users/Admin@manufacturer.thermotrace.com/msp",
+            "CORE_PEER_MSPCONFIGPATH": "${PWD}/organizations/peerOrganizations/manufacturer.thermotrace.com/users/QE1@manufacturer.thermotrace.com/msp",
             "CORE_PEER_ADDRESS": "peer0.manufacturer.thermotrace.com:9051",
         }
     else:  # mrolab
@@ -85,7 +94,7 @@ def submit_to_blockchain(inspection_data, org="manufacturer"):
             "CORE_PEER_TLS_ENABLED": "true",
             "CORE_PEER_LOCALMSPID": "MROLabMSP",
             "CORE_PEER_TLS_ROOTCERT_FILE": "${PWD}/organizations/peerOrganizations/mrolab.thermotrace.com/peers/peer0.mrolab.thermotrace.com/tls/ca.crt",
-            "CORE_PEER_MSPCONFIGPATH": "${PWD}/organizations/peerOrganizations/mrolab.thermotrace.com/users/Admin@mrolab.thermotrace.com/msp",
+            "CORE_PEER_MSPCONFIGPATH": "${PWD}/organizations/peerOrganizations/mrolab.thermotrace.com/users/Inspector1@mrolab.thermotrace.com/msp",
             "CORE_PEER_ADDRESS": "peer0.mrolab.thermotrace.com:7051",
         }
 
@@ -100,6 +109,7 @@ def submit_to_blockchain(inspection_data, org="manufacturer"):
         -C inspection-channel \\
         -n aidefectinspection \\
         -c '{{"Args":["AddDefectInspection","{inspection_json}"]}}' \\
+        --transient '{transient_json}' \\
         --peerAddresses peer0.manufacturer.thermotrace.com:9051 \\
         --tlsRootCertFiles $PWD/organizations/peerOrganizations/manufacturer.thermotrace.com/peers/peer0.manufacturer.thermotrace.com/tls/ca.crt \\
         --peerAddresses peer0.mrolab.thermotrace.com:7051 \\
@@ -134,13 +144,16 @@ def main():
     # Optional arguments
     parser.add_argument("--material-type", default="Carbon Fiber Composite", help="Material type")
     parser.add_argument("--inspector", required=True, help="Inspector name (will be private)")
-    parser.add_argument("--bbox", help="Bounding box: x1,y1,x2,y2")
+    parser.add_argument("--bbox", help="Bounding box in full-frame pixels, inside the ROI: x1,y1,x2,y2")
     parser.add_argument("--confidence", type=float, default=0.0, help="Confidence score (0.0-1.0)")
     parser.add_argument("--defect-type", default="thermal defect", help="Type of defect detected")
     parser.add_argument("--roi", default="74,308,192,412", help="ROI coordinates: y1,y2,x1,x2")
     parser.add_argument("--iou", type=float, default=0.0, help="Intersection over Union")
     parser.add_argument("--organization", default="manufacturer", choices=["manufacturer", "mrolab"],
                         help="Organization submitting the inspection")
+    parser.add_argument("--submission-id", default="",
+                        help="Idempotency key (letters, digits, '.', '_', ':', '-'); derived by the chaincode "
+                             "from the serial number, file hashes and model if omitted")
 
     args = parser.parse_args()
 
@@ -200,14 +213,13 @@ def main():
         "partNumber": args.part_number,
         "serialNumber": args.serial_number,
         "materialType": args.material_type,
-        "inspectionDate": datetime.now().isoformat(),
+        "inspectionDate": datetime.now(timezone.utc).isoformat(timespec="seconds"),  # RFC3339
         "inspectionType": "Active Thermography",
-        "inspector": args.inspector,
         "organization": "",  # Will be set by chaincode
-        "rawVideoHash": f"sha256:{video_hash}",
+        "rawVideoHash": video_hash,  # 64 hex characters
         "rawVideoIPFS": video_cid,
         "rawVideoSize": video_size,
-        "processedImageHash": f"sha256:{image_hash}",
+        "processedImageHash": image_hash,
         "processedImageIPFS": image_cid,
         "roi_y1": roi_y1,
         "roi_y2": roi_y2,
@@ -218,7 +230,7 @@ def main():
         "sequenceLength": 2000,
         "modelName": "cnn_attention_grdino",
         "modelVersion": "v1.0",
-        "modelHash": "placeholder",  # TODO: Calculate model hash
+        "modelHash": "",  # TODO: Calculate model hash (SHA-256 hex, empty if unknown)
         "defectDetected": defect_detected,
         "defectType": args.defect_type if defect_detected else "",
         "confidenceScore": args.confidence,
@@ -234,24 +246,30 @@ def main():
         "gt_bbox_y1": 0.0,
         "gt_bbox_x2": 0.0,
         "gt_bbox_y2": 0.0,
+        "submissionId": args.submission_id,  # resubmitting identical content returns the existing record
         "txID": "",
         "blockchainTimestamp": "",
-        "submittedAt": ""
+        "submittedAt": datetime.now(timezone.utc).isoformat(timespec="seconds")  # RFC3339, checked against the tx timestamp
     }
 
+    # Private fields are rejected in the arguments and sent as transient data
+    private_data = {"inspector": args.inspector}
+
     print("Step 3: Preparing blockchain submission...")
     print(json.dumps(inspection_data, indent=2))
     print()
 
     # Step 5: Submit to blockchain
-    cmd = submit_to_blockchain(inspection_data, args.organization)
+    cmd = submit_to_blockchain(inspection_data, private_data, args.organization)
 
     # Save command to file for manual execution
     script_path = Path("/home/lp502261/thermotrace-production/submit_inspection.sh")
     with open(script_path, "w") as f:
         f.write("#!/bin/bash\n")
         f.write("# Auto-generated blockchain submission script\n")
-        f.write(f"# Generated: {datetime.now().isoformat()}\n\n")
+        f.write(f"# Generated: {datetime.now().isoformat()}\n")
+        f.write("# Safe to re-run: an identical resubmission returns the existing record, while different\n")
+        f.write("# content under the same submission ID fails with SUBMISSION_CONFLICT.\n\n")
         f.write(cmd)
 
     script_path.chmod(0o755)
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// chordColumns lists the 14 chord measurement columns in blade order
+var chordColumns = []string{"AR", "AP", "AN", "AM", "AL", "AK", "AJ", "AH", "AG", "AF", "AE", "AD", "AC", "AB"}
+
+// measurementPattern splits a source value into its number, decimal places and unit suffix
+var measurementPattern = regexp.MustCompile(`^[0-9]+(?:\.([0-9]+))?\s*([A-Za-z"]*)$`)
+
+// CSVRow is a single blade from a chord measurement CSV file
+type CSVRow struct {
+	Line         int // line number in the source file
+	PartNumber   string
+	SerialNumber string
+	Values       map[string]string // source values keyed by chord column, as recorded
+	Err          error             // set when the row cannot be submitted
+}
+
+// CSVFile is a parsed chord measurement CSV file in the P/N,S/N,AR..AB format
+type CSVFile struct {
+	Hash      string // SHA-256 of the file contents (hex)
+	Unit      string // "mm" or "in"
+	Precision int    // maximum decimal places of the source values
+	Rows      []*CSVRow
+}
+
+// ParseCSV parses a chord measurement CSV file. unit may be empty, in which case it is taken from
+// the value suffixes (e.g. "242.98 mm"); files without suffixes must specify the unit explicitly.
+func ParseCSV(data []byte, unit string) (*CSVFile, error) {
+	hash := sha256.Sum256(data)
+	file := &CSVFile{Hash: fmt.Sprintf("%x", hash)}
+
+	reader := csv.NewReader(strings.NewReader(string(data)))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(name), ":"))
+		columns[name] = i
+	}
+	for _, name := range append([]string{"P/N", "S/N"}, chordColumns...) {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing column %s", name)
+		}
+	}
+
+	suffixes := map[string]bool{}
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		field := func(name string) string {
+			if i := columns[name]; i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		row := &CSVRow{
+			Line:         line,
+			PartNumber:   field("P/N"),
+			SerialNumber: field("S/N"),
+			Values:       map[string]string{},
+		}
+		if row.PartNumber == "" && row.SerialNumber == "" {
+			continue
+		}
+		if row.PartNumber == "" || row.SerialNumber == "" {
+			row.Err = fmt.Errorf("P/N and S/N are required")
+		}
+
+		for _, name := range chordColumns {
+			value := field(name)
+			row.Values[name] = value
+
+			match := measurementPattern.FindStringSubmatch(value)
+			if match == nil {
+				if row.Err == nil {
+					if value == "" {
+						row.Err = fmt.Errorf("missing value for %s", name)
+					} else {
+						row.Err = fmt.Errorf("invalid value %q for %s", value, name)
+					}
+				}
+				continue
+			}
+			if len(match[1]) > file.Precision {
+				file.Precision = len(match[1])
+			}
+			suffixes[strings.ToLower(match[2])] = true
+		}
+
+		file.Rows = append(file.Rows, row)
+	}
+
+	file.Unit, err = resolveUnit(unit, suffixes)
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// resolveUnit checks the declared unit against the suffixes found in the file
+func resolveUnit(unit string, suffixes map[string]bool) (string, error) {
+	delete(suffixes, "")
+	if unit != "" && unit != "mm" && unit != "in" {
+		return "", fmt.Errorf("unit must be mm or in, got %q", unit)
+	}
+
+	for suffix := range suffixes {
+		if suffix == `"` || suffix == "inch" || suffix == "inches" {
+			suffix = "in"
+		}
+		if unit == "" {
+			unit = suffix
+		}
+		if suffix != unit {
+			return "", fmt.Errorf("values are in %s but the file is declared as %s", suffix, unit)
+		}
+	}
+
+	if unit == "" {
+		return "", fmt.Errorf("values have no unit suffix, specify the unit explicitly")
+	}
+	return unit, nil
+}
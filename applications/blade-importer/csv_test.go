@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func readSample(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile("../../sample-data/" + name)
+	if err != nil {
+		t.Fatalf("failed to read sample data: %v", err)
+	}
+	return data
+}
+
+func TestParseCSVMillimetres(t *testing.T) {
+	file, err := ParseCSV(readSample(t, "before_surfacing.csv"), "")
+	if err != nil {
+		t.Fatalf("ParseCSV failed: %v", err)
+	}
+
+	if file.Unit != "mm" || file.Precision != 2 {
+		t.Errorf("expected mm with 2 decimal places, got %s with %d", file.Unit, file.Precision)
+	}
+	if len(file.Hash) != 64 {
+		t.Errorf("expected SHA-256 hex hash, got %q", file.Hash)
+	}
+
+	row := file.Rows[1]
+	if row.Line != 3 || row.PartNumber != "6A7614" || row.SerialNumber != "RGA46870" {
+		t.Errorf("unexpected row: %+v", row)
+	}
+	if row.Values["AR"] != "243.04 mm" || row.Values["AB"] != "256.12 mm" || row.Err != nil {
+		t.Errorf("unexpected values: %v (%v)", row.Values, row.Err)
+	}
+}
+
+func TestParseCSVInchesRequiresUnit(t *testing.T) {
+	if _, err := ParseCSV(readSample(t, "manual.csv"), ""); err == nil {
+		t.Fatal("expected values without unit suffix to require an explicit unit")
+	}
+
+	file, err := ParseCSV(readSample(t, "manual.csv"), "in")
+	if err != nil {
+		t.Fatalf("ParseCSV failed: %v", err)
+	}
+	if file.Precision != 4 {
+		t.Errorf("expected 4 decimal places, got %d", file.Precision)
+	}
+
+	// The AB column of manual.csv is empty
+	for _, row := range file.Rows {
+		if row.Err == nil || !strings.Contains(row.Err.Error(), "missing value for AB") {
+			t.Errorf("line %d: expected missing AB, got %v", row.Line, row.Err)
+		}
+	}
+}
+
+func TestParseCSVRejectsConflictingUnit(t *testing.T) {
+	if _, err := ParseCSV(readSample(t, "after_surfacing.csv"), "in"); err == nil {
+		t.Fatal("expected mm values declared as inches to be rejected")
+	}
+}
+
+func TestParseCSVMissingColumn(t *testing.T) {
+	if _, err := ParseCSV([]byte("P/N:,S/N:,AR\n6A7614,RGA46870,242.98 mm\n"), ""); err == nil {
+		t.Fatal("expected missing chord columns to be rejected")
+	}
+}
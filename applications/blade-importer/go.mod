@@ -0,0 +1,20 @@
+module github.com/mahmoudhafez3/thermotrace/applications/blade-importer
+
+go 1.21
+
+require (
+	github.com/hyperledger/fabric-gateway v1.5.0
+	github.com/hyperledger/fabric-protos-go-apiv2 v0.3.3
+	google.golang.org/grpc v1.62.1
+)
+
+require (
+	github.com/golang/protobuf v1.5.4 // indirect
+	github.com/miekg/pkcs11 v1.1.1 // indirect
+	golang.org/x/crypto v0.21.0 // indirect
+	golang.org/x/net v0.22.0 // indirect
+	golang.org/x/sys v0.18.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240304212257-790db918fca8 // indirect
+	google.golang.org/protobuf v1.33.0 // indirect
+)
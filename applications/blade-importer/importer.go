@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Submitter submits a transaction to the blade-inspection chaincode with transient data, which reaches the
+// endorsing peers without being written to the block
+type Submitter interface {
+	SubmitTransaction(name string, transient map[string][]byte, args ...string) ([]byte, error)
+}
+
+// transientPrivateKey is the transient map entry of the private fields the chaincode stores in the
+// submitting organization's private collection
+const transientPrivateKey = "private"
+
+// PrivateFields are the private fields of an inspection, sent in the transient map. The salt is random
+// for every inspection, so that the hash of the inspector the chaincode keeps cannot be guessed; the
+// owner reads it back with GetInspectionPrivate to let an auditor verify the inspector.
+type PrivateFields struct {
+	Inspector     string `json:"inspector,omitempty"`
+	InspectorSalt string `json:"inspectorSalt"`
+}
+
+// newPrivateFields returns the private fields of an inspection with a new random salt
+func newPrivateFields(inspector string) (PrivateFields, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return PrivateFields{}, fmt.Errorf("failed to generate inspector salt: %v", err)
+	}
+	return PrivateFields{Inspector: inspector, InspectorSalt: hex.EncodeToString(salt)}, nil
+}
+
+// Measurements is the measurement payload expected by AddInspection
+type Measurements struct {
+	Unit            string            `json:"unit"`
+	SourcePrecision int               `json:"sourcePrecision"`
+	Source          map[string]string `json:"source"`
+}
+
+// Inspection is the AddInspection payload of the blade-inspection chaincode
+type Inspection struct {
+	PartNumber     string       `json:"partNumber"`
+	SerialNumber   string       `json:"serialNumber"`
+	OccasionLabel  string       `json:"occasionLabel"`
+	InspectionDate string       `json:"inspectionDate"`
+	SubmittedAt    string       `json:"submittedAt"`
+	Organization   string       `json:"organization"`
+	Measurements   Measurements `json:"measurements"`
+	CSVHash        string       `json:"csvHash"`
+}
+
+// InspectionMetadata holds the fields shared by every row of an imported file
+type InspectionMetadata struct {
+	OccasionLabel  string
+	InspectionDate string // ISO 8601 format
+	Inspector      string // private: sent in the transient map; empty defaults to the certificate's common name
+	Organization   string
+}
+
+// Row statuses in the import report
+const (
+	StatusSubmitted = "submitted"
+	StatusFailed    = "failed"
+	StatusDryRun    = "dry-run"
+	StatusExisting  = "existing" // part already registered (RegisterParts)
+)
+
+// RowResult is the outcome of importing a single CSV row
+type RowResult struct {
+	Line         int    `json:"line"`
+	PartNumber   string `json:"partNumber"`
+	SerialNumber string `json:"serialNumber"`
+	Status       string `json:"status"`
+	Attempts     int    `json:"attempts"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Report summarizes the import of a CSV file
+type Report struct {
+	CSVHash   string      `json:"csvHash"`
+	Submitted int         `json:"submitted"`
+	Failed    int         `json:"failed"`
+	DryRun    int         `json:"dryRun"`
+	Existing  int         `json:"existing,omitempty"`
+	Rows      []RowResult `json:"rows"`
+}
+
+// Importer submits the rows of a CSV file as AddInspection transactions, or as AddInspectionsBatch
+// transactions of up to BatchSize rows
+type Importer struct {
+	Submitter  Submitter
+	Workers    int           // concurrent submissions (at least 1)
+	BatchSize  int           // rows per AddInspectionsBatch transaction; 0 submits each row separately
+	Retries    int           // additional attempts after a retryable failure
+	RetryDelay time.Duration // delay before the first retry, doubled for each further retry
+	Retryable  func(error) bool
+	DryRun     bool // build and validate payloads without submitting
+}
+
+// submission is a single transaction covering one or more rows
+type submission struct {
+	rows        []int // indexes into the file rows
+	transaction string
+	payload     []byte
+	transient   map[string][]byte
+}
+
+// buildInspection creates the AddInspection payload for a row
+func buildInspection(file *CSVFile, row *CSVRow, meta InspectionMetadata, submittedAt string) Inspection {
+	source := map[string]string{}
+	for _, name := range chordColumns {
+		source[strings.ToLower(name)] = row.Values[name]
+	}
+
+	return Inspection{
+		PartNumber:     row.PartNumber,
+		SerialNumber:   row.SerialNumber,
+		OccasionLabel:  meta.OccasionLabel,
+		InspectionDate: meta.InspectionDate,
+		SubmittedAt:    submittedAt,
+		Organization:   meta.Organization,
+		Measurements: Measurements{
+			Unit:            file.Unit,
+			SourcePrecision: file.Precision,
+			Source:          source,
+		},
+		CSVHash: file.Hash,
+	}
+}
+
+// Import submits every row of file and returns a per-row report. Rows that failed to parse are
+// reported without being submitted.
+func (im *Importer) Import(ctx context.Context, file *CSVFile, meta InspectionMetadata) *Report {
+	report := &Report{
+		CSVHash: file.Hash,
+		Rows:    make([]RowResult, len(file.Rows)),
+	}
+
+	submittedAt := time.Now().UTC().Format(time.RFC3339)
+	var pending []int
+	var inspections []Inspection
+	for i, row := range file.Rows {
+		report.Rows[i] = RowResult{Line: row.Line, PartNumber: row.PartNumber, SerialNumber: row.SerialNumber}
+		if row.Err != nil {
+			report.Rows[i].Status = StatusFailed
+			report.Rows[i].Error = row.Err.Error()
+			continue
+		}
+		pending = append(pending, i)
+		inspections = append(inspections, buildInspection(file, row, meta, submittedAt))
+	}
+
+	submissions, err := im.plan(pending, inspections, meta)
+	if err != nil {
+		for _, i := range pending {
+			report.Rows[i].Status = StatusFailed
+			report.Rows[i].Error = err.Error()
+		}
+		pending = nil
+	}
+
+	if im.DryRun {
+		for _, i := range pending {
+			report.Rows[i].Status = StatusDryRun
+		}
+	} else {
+		im.submitAll(ctx, submissions, report)
+	}
+
+	report.count()
+	return report
+}
+
+// count totals the row statuses
+func (report *Report) count() {
+	for _, result := range report.Rows {
+		switch result.Status {
+		case StatusSubmitted:
+			report.Submitted++
+		case StatusFailed:
+			report.Failed++
+		case StatusDryRun:
+			report.DryRun++
+		case StatusExisting:
+			report.Existing++
+		}
+	}
+}
+
+// plan groups the pending rows into transactions. The private fields are sent as transient data, one
+// object per inspection of a batch.
+func (im *Importer) plan(pending []int, inspections []Inspection, meta InspectionMetadata) ([]submission, error) {
+	var submissions []submission
+
+	private := make([]PrivateFields, len(pending))
+	for j := range private {
+		var err error
+		private[j], err = newPrivateFields(meta.Inspector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if im.BatchSize <= 0 {
+		for j, i := range pending {
+			payload, err := json.Marshal(inspections[j])
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal inspection: %v", err)
+			}
+			transient, err := marshalTransient(private[j])
+			if err != nil {
+				return nil, err
+			}
+			submissions = append(submissions, submission{rows: []int{i}, transaction: "AddInspection", payload: payload, transient: transient})
+		}
+		return submissions, nil
+	}
+
+	for start := 0; start < len(pending); start += im.BatchSize {
+		end := start + im.BatchSize
+		if end > len(pending) {
+			end = len(pending)
+		}
+		payload, err := json.Marshal(inspections[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal inspections: %v", err)
+		}
+		transient, err := marshalTransient(private[start:end])
+		if err != nil {
+			return nil, err
+		}
+		submissions = append(submissions, submission{rows: pending[start:end], transaction: "AddInspectionsBatch", payload: payload,
+			transient: transient})
+	}
+	return submissions, nil
+}
+
+// marshalTransient returns the transient map carrying the private fields of a transaction
+func marshalTransient(private interface{}) (map[string][]byte, error) {
+	privateJSON, err := json.Marshal(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private fields: %v", err)
+	}
+	return map[string][]byte{transientPrivateKey: privateJSON}, nil
+}
+
+// submitAll submits the transactions concurrently and records the outcome of every row
+func (im *Importer) submitAll(ctx context.Context, submissions []submission, report *Report) {
+	workers := im.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	jobs := make(chan submission)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				attempts, err := im.submit(ctx, job)
+				// Each submission covers distinct rows, so no locking is needed
+				for _, i := range job.rows {
+					report.Rows[i].Attempts = attempts
+					report.Rows[i].Status = StatusSubmitted
+					if err != nil {
+						report.Rows[i].Status = StatusFailed
+						report.Rows[i].Error = err.Error()
+					}
+				}
+			}
+		}()
+	}
+	for _, job := range submissions {
+		jobs <- job
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// submit submits a single transaction, retrying retryable failures, and returns the number of attempts
+func (im *Importer) submit(ctx context.Context, job submission) (int, error) {
+	delay := im.RetryDelay
+	for attempts := 1; ; attempts++ {
+		_, err := im.Submitter.SubmitTransaction(job.transaction, job.transient, string(job.payload))
+		if err == nil {
+			return attempts, nil
+		}
+		if attempts > im.Retries || (im.Retryable != nil && !im.Retryable(err)) {
+			return attempts, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempts, fmt.Errorf("%v (cancelled: %v)", err, ctx.Err())
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeContract records submitted transactions and fails serial numbers on demand
+type fakeContract struct {
+	mu          sync.Mutex
+	submissions map[string][]Inspection
+	batches     [][]Inspection
+	failures    map[string][]error       // errors returned for successive submissions of a serial number
+	transient   map[string]PrivateFields // transient private data by serial number
+}
+
+func newFakeContract() *fakeContract {
+	return &fakeContract{submissions: map[string][]Inspection{}, failures: map[string][]error{}, transient: map[string]PrivateFields{}}
+}
+
+func (c *fakeContract) SubmitTransaction(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, errors.New("unexpected arguments")
+	}
+
+	var inspections []Inspection
+	var private []PrivateFields
+	switch name {
+	case "AddInspection":
+		var inspection Inspection
+		if err := json.Unmarshal([]byte(args[0]), &inspection); err != nil {
+			return nil, err
+		}
+		inspections = []Inspection{inspection}
+		private = make([]PrivateFields, 1)
+		if err := json.Unmarshal(transient[transientPrivateKey], &private[0]); err != nil {
+			return nil, err
+		}
+	case "AddInspectionsBatch":
+		if err := json.Unmarshal([]byte(args[0]), &inspections); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(transient[transientPrivateKey], &private); err != nil {
+			return nil, err
+		}
+		if len(private) != len(inspections) {
+			return nil, errors.New("unexpected transient data")
+		}
+	default:
+		return nil, errors.New("unexpected transaction")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if name == "AddInspectionsBatch" {
+		c.batches = append(c.batches, inspections)
+	}
+	// A failure of any item fails the whole transaction
+	for _, inspection := range inspections {
+		if failures := c.failures[inspection.SerialNumber]; len(failures) > 0 {
+			c.failures[inspection.SerialNumber] = failures[1:]
+			return nil, failures[0]
+		}
+	}
+	for i, inspection := range inspections {
+		c.submissions[inspection.SerialNumber] = append(c.submissions[inspection.SerialNumber], inspection)
+		c.transient[inspection.SerialNumber] = private[i]
+	}
+	return nil, nil
+}
+
+var errTransient = errors.New("transient")
+
+var testMetadata = InspectionMetadata{
+	OccasionLabel:  "before_surfacing",
+	InspectionDate: "2025-10-20T08:00:00Z",
+	Inspector:      "DataImport",
+	Organization:   "MROLabMSP",
+}
+
+func TestImportSubmitsEveryRow(t *testing.T) {
+	file, err := ParseCSV(readSample(t, "before_surfacing.csv"), "")
+	if err != nil {
+		t.Fatalf("ParseCSV failed: %v", err)
+	}
+
+	contract := newFakeContract()
+	importer := &Importer{Submitter: contract, Workers: 3}
+	report := importer.Import(context.Background(), file, testMetadata)
+
+	if report.Submitted != len(file.Rows) || report.Failed != 0 {
+		t.Fatalf("expected %d submitted, got %d submitted and %d failed", len(file.Rows), report.Submitted, report.Failed)
+	}
+	if len(contract.submissions) != len(file.Rows) {
+		t.Errorf("expected %d serial numbers, got %d", len(file.Rows), len(contract.submissions))
+	}
+
+	inspection := contract.submissions["RGA46870"][0]
+	if inspection.CSVHash != file.Hash || inspection.OccasionLabel != "before_surfacing" {
+		t.Errorf("unexpected payload: %+v", inspection)
+	}
+	if inspection.Measurements.Unit != "mm" || inspection.Measurements.SourcePrecision != 2 ||
+		inspection.Measurements.Source["ar"] != "243.04 mm" {
+		t.Errorf("unexpected measurements: %+v", inspection.Measurements)
+	}
+	// The inspector is private, so it is sent as transient data rather than in the payload, with a salt
+	// of its own for every inspection
+	private := contract.transient["RGA46870"]
+	if private.Inspector != "DataImport" || len(private.InspectorSalt) != 32 {
+		t.Errorf("unexpected transient data %+v", private)
+	}
+	if other := contract.transient["RGA85742"]; other.InspectorSalt == private.InspectorSalt {
+		t.Errorf("expected every inspection to have its own salt, got %q twice", other.InspectorSalt)
+	}
+}
+
+func TestImportRetriesTransientFailures(t *testing.T) {
+	file, err := ParseCSV(readSample(t, "after_surfacing.csv"), "")
+	if err != nil {
+		t.Fatalf("ParseCSV failed: %v", err)
+	}
+
+	contract := newFakeContract()
+	contract.failures["RGA46870"] = []error{errTransient, errTransient}
+	contract.failures["RGA85382"] = []error{errors.New("inspection already exists")}
+	contract.failures["RGA85742"] = []error{errTransient, errTransient, errTransient}
+
+	importer := &Importer{
+		Submitter: contract,
+		Workers:   2,
+		Retries:   2,
+		Retryable: func(err error) bool { return errors.Is(err, errTransient) },
+	}
+	report := importer.Import(context.Background(), file, testMetadata)
+
+	results := map[string]RowResult{}
+	for _, row := range report.Rows {
+		results[row.SerialNumber] = row
+	}
+	if r := results["RGA46870"]; r.Status != StatusSubmitted || r.Attempts != 3 {
+		t.Errorf("RGA46870: expected submitted after 3 attempts, got %+v", r)
+	}
+	if r := results["RGA85382"]; r.Status != StatusFailed || r.Attempts != 1 {
+		t.Errorf("RGA85382: expected permanent failure after 1 attempt, got %+v", r)
+	}
+	if r := results["RGA85742"]; r.Status != StatusFailed || r.Attempts != 3 || r.Error != "transient" {
+		t.Errorf("RGA85742: expected failure after exhausting retries, got %+v", r)
+	}
+	if report.Failed != 2 || report.Submitted != len(file.Rows)-2 {
+		t.Errorf("unexpected totals: %d submitted, %d failed", report.Submitted, report.Failed)
+	}
+}
+
+func TestImportDryRun(t *testing.T) {
+	file, err := ParseCSV(readSample(t, "manual.csv"), "in")
+	if err != nil {
+		t.Fatalf("ParseCSV failed: %v", err)
+	}
+	file.Rows[0].Values["AB"] = "10.0825"
+	file.Rows[0].Err = nil
+
+	contract := newFakeContract()
+	importer := &Importer{Submitter: contract, DryRun: true}
+	report := importer.Import(context.Background(), file, testMetadata)
+
+	if len(contract.submissions) != 0 {
+		t.Errorf("expected no submissions in dry-run mode, got %d", len(contract.submissions))
+	}
+	if report.DryRun != 1 || report.Failed != len(file.Rows)-1 {
+		t.Errorf("expected 1 dry-run row and %d failed rows, got %d and %d", len(file.Rows)-1, report.DryRun, report.Failed)
+	}
+	if report.Rows[1].Error != "missing value for AB" {
+		t.Errorf("unexpected error: %q", report.Rows[1].Error)
+	}
+}
+
+func TestImportBatches(t *testing.T) {
+	file, err := ParseCSV(readSample(t, "before_surfacing.csv"), "")
+	if err != nil {
+		t.Fatalf("ParseCSV failed: %v", err)
+	}
+
+	contract := newFakeContract()
+	contract.failures["RGA46870"] = []error{errTransient}
+	importer := &Importer{
+		Submitter: contract,
+		Workers:   2,
+		BatchSize: 8,
+		Retries:   1,
+		Retryable: func(err error) bool { return errors.Is(err, errTransient) },
+	}
+	report := importer.Import(context.Background(), file, testMetadata)
+
+	if report.Submitted != len(file.Rows) || report.Failed != 0 {
+		t.Fatalf("expected %d submitted, got %d submitted and %d failed", len(file.Rows), report.Submitted, report.Failed)
+	}
+	expectedBatches := (len(file.Rows)+7)/8 + 1 // one batch is retried
+	if len(contract.batches) != expectedBatches {
+		t.Errorf("expected %d batch transactions, got %d", expectedBatches, len(contract.batches))
+	}
+	for _, batch := range contract.batches {
+		if len(batch) > 8 {
+			t.Errorf("batch of %d exceeds the batch size", len(batch))
+		}
+	}
+
+	// Every row of the retried batch shares its attempt count
+	var retried int
+	for _, row := range report.Rows {
+		if row.Attempts == 2 {
+			retried++
+		}
+	}
+	if retried == 0 || retried > 8 {
+		t.Errorf("expected the rows of one batch to be retried, got %d rows", retried)
+	}
+}
@@ -0,0 +1,254 @@
+// Command blade-importer submits chord measurement CSV files (P/N,S/N,AR..AB) to the
+// blade-inspection chaincode through the Fabric Gateway. With -register-parts it registers the
+// file's parts in the part registry instead, which inspections require.
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"github.com/hyperledger/fabric-protos-go-apiv2/peer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+func main() {
+	csvPath := flag.String("csv", "", "chord measurement CSV file (required)")
+	occasion := flag.String("occasion", "", "occasion label, e.g. before_surfacing, manual, after_surfacing (required)")
+	unit := flag.String("unit", "", "unit of the CSV values (mm or in); taken from value suffixes when omitted")
+	inspectionDate := flag.String("date", "", "inspection date (ISO 8601, required); part of each inspection's identity, so reruns must pass the same date")
+	inspector := flag.String("inspector", "DataImport", "inspector name (sent as transient data, stored in the org's private collection)")
+	mspID := flag.String("msp-id", "MROLabMSP", "MSP ID of the submitting organization")
+	certPath := flag.String("cert", "", "client certificate (PEM)")
+	keyPath := flag.String("key", "", "client private key (PEM file or keystore directory)")
+	tlsCertPath := flag.String("tls-cert", "", "peer TLS CA certificate (PEM)")
+	peerEndpoint := flag.String("peer", "localhost:7051", "gateway peer endpoint")
+	peerHostAlias := flag.String("peer-host-alias", "peer0.mrolab.thermotrace.com", "gateway peer TLS host name")
+	channelName := flag.String("channel", "inspection-channel", "channel name")
+	chaincodeName := flag.String("chaincode", "bladeinspection", "chaincode name")
+	workers := flag.Int("workers", 4, "concurrent submissions")
+	batchSize := flag.Int("batch-size", 100, "rows per AddInspectionsBatch transaction (at most 100); 0 submits each row separately")
+	retries := flag.Int("retries", 3, "retries for transient failures")
+	retryDelay := flag.Duration("retry-delay", 2*time.Second, "delay before the first retry (doubled for each further retry)")
+	dryRun := flag.Bool("dry-run", false, "parse and validate without submitting")
+	jsonReport := flag.Bool("json", false, "print the report as JSON")
+	registerParts := flag.Bool("register-parts", false, "register the file's parts with RegisterPart instead of importing inspections")
+	partManufacturer := flag.String("part-manufacturer", "", "manufacturer of the registered parts (-register-parts)")
+	partMaterial := flag.String("part-material", "", "material of the registered parts (-register-parts)")
+	partManufactureDate := flag.String("part-manufacture-date", "", "manufacture date of the registered parts, YYYY-MM-DD (-register-parts)")
+	flag.Parse()
+
+	if *csvPath == "" || (!*registerParts && (*occasion == "" || *inspectionDate == "")) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(*csvPath)
+	if err != nil {
+		fatalf("failed to read CSV file: %v", err)
+	}
+	file, err := ParseCSV(data, *unit)
+	if err != nil {
+		fatalf("failed to parse %s: %v", *csvPath, err)
+	}
+	if *registerParts {
+		if *partManufacturer == "" || *partMaterial == "" || *partManufactureDate == "" {
+			fatalf("-register-parts requires -part-manufacturer, -part-material and -part-manufacture-date")
+		}
+	} else if _, err := time.Parse(time.RFC3339, *inspectionDate); err != nil {
+		fatalf("invalid inspection date: %v", err)
+	}
+
+	importer := &Importer{
+		Workers:    *workers,
+		BatchSize:  *batchSize,
+		Retries:    *retries,
+		RetryDelay: *retryDelay,
+		Retryable:  isRetryable,
+		DryRun:     *dryRun,
+	}
+
+	if !*dryRun {
+		clientConnection, err := newGrpcConnection(*tlsCertPath, *peerEndpoint, *peerHostAlias)
+		if err != nil {
+			fatalf("failed to connect to gateway peer: %v", err)
+		}
+		defer clientConnection.Close()
+
+		gw, err := newGateway(clientConnection, *mspID, *certPath, *keyPath)
+		if err != nil {
+			fatalf("failed to connect to gateway: %v", err)
+		}
+		defer gw.Close()
+
+		importer.Submitter = &contractSubmitter{gw.GetNetwork(*channelName).GetContract(*chaincodeName)}
+	}
+
+	var report *Report
+	if *registerParts {
+		report = importer.RegisterParts(context.Background(), file, PartDetails{
+			Manufacturer:    *partManufacturer,
+			Material:        *partMaterial,
+			ManufactureDate: *partManufactureDate,
+		})
+	} else {
+		report = importer.Import(context.Background(), file, InspectionMetadata{
+			OccasionLabel:  *occasion,
+			InspectionDate: *inspectionDate,
+			Inspector:      *inspector,
+			Organization:   *mspID,
+		})
+	}
+
+	if *jsonReport {
+		reportJSON, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(reportJSON))
+	} else {
+		printReport(*csvPath, file, report)
+	}
+
+	if report.Failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// printReport prints a per-row summary of the import
+func printReport(csvPath string, file *CSVFile, report *Report) {
+	fmt.Printf("File: %s (%s, %d decimal places)\n", csvPath, file.Unit, file.Precision)
+	fmt.Printf("CSV hash: %s\n", report.CSVHash)
+	for _, row := range report.Rows {
+		fmt.Printf("  line %3d  %s_%s  %-9s", row.Line, row.PartNumber, row.SerialNumber, row.Status)
+		if row.Attempts > 1 {
+			fmt.Printf("  (%d attempts)", row.Attempts)
+		}
+		if row.Error != "" {
+			fmt.Printf("  %s", row.Error)
+		}
+		fmt.Println()
+	}
+	fmt.Printf("Submitted: %d  Failed: %d  Dry run: %d", report.Submitted, report.Failed, report.DryRun)
+	if report.Existing > 0 {
+		fmt.Printf("  Already registered: %d", report.Existing)
+	}
+	fmt.Println()
+}
+
+// contractSubmitter submits transactions with transient data through the gateway
+type contractSubmitter struct {
+	contract *client.Contract
+}
+
+func (s *contractSubmitter) SubmitTransaction(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	return s.contract.Submit(name, client.WithArguments(args...), client.WithTransient(transient))
+}
+
+// isRetryable reports whether a gateway error is transient (peer unavailable, timeout or MVCC conflict).
+// Chaincode errors such as invalid measurements or submission conflicts are not retried; resubmitting
+// rows that were already recorded with identical content succeeds, so a failed import can be rerun.
+func isRetryable(err error) bool {
+	var commitErr *client.CommitError
+	if errors.As(err, &commitErr) {
+		return commitErr.Code == peer.TxValidationCode_MVCC_READ_CONFLICT ||
+			commitErr.Code == peer.TxValidationCode_PHANTOM_READ_CONFLICT
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+// newGrpcConnection creates a TLS connection to the gateway peer
+func newGrpcConnection(tlsCertPath, peerEndpoint, peerHostAlias string) (*grpc.ClientConn, error) {
+	tlsCertPEM, err := os.ReadFile(tlsCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS certificate: %v", err)
+	}
+	tlsCert, err := identity.CertificateFromPEM(tlsCertPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS certificate: %v", err)
+	}
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(tlsCert)
+	transportCredentials := credentials.NewClientTLSFromCert(certPool, peerHostAlias)
+
+	return grpc.Dial(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
+}
+
+// newGateway connects to the gateway with the client's X.509 identity
+func newGateway(clientConnection *grpc.ClientConn, mspID, certPath, keyPath string) (*client.Gateway, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %v", err)
+	}
+	cert, err := identity.CertificateFromPEM(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
+	}
+	id, err := identity.NewX509Identity(mspID, cert)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := readPrivateKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := identity.PrivateKeyFromPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithClientConnection(clientConnection),
+		client.WithEvaluateTimeout(5*time.Second),
+		client.WithEndorseTimeout(15*time.Second),
+		client.WithSubmitTimeout(5*time.Second),
+		client.WithCommitStatusTimeout(1*time.Minute),
+	)
+}
+
+// readPrivateKey reads a PEM private key from a file or the first file of an MSP keystore directory
+func readPrivateKey(keyPath string) ([]byte, error) {
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %v", err)
+	}
+	if info.IsDir() {
+		entries, err := os.ReadDir(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore: %v", err)
+		}
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("keystore %s is empty", keyPath)
+		}
+		keyPath = filepath.Join(keyPath, entries[0].Name())
+	}
+
+	return os.ReadFile(keyPath)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// PartDetails holds the registry fields shared by the parts of an imported file, which the chord
+// measurement CSV files do not record
+type PartDetails struct {
+	Manufacturer    string
+	Material        string
+	ManufactureDate string // YYYY-MM-DD
+}
+
+// Part is the RegisterPart payload of the blade-inspection chaincode
+type Part struct {
+	PartNumber      string `json:"partNumber"`
+	SerialNumber    string `json:"serialNumber"`
+	Manufacturer    string `json:"manufacturer"`
+	Material        string `json:"material"`
+	ManufactureDate string `json:"manufactureDate"`
+}
+
+// alreadyRegistered reports whether a RegisterPart error means the part is in the registry
+func alreadyRegistered(message string) bool {
+	return strings.Contains(message, "is already registered")
+}
+
+// RegisterParts submits a RegisterPart transaction for every distinct part of file and returns a report
+// with a row per part, at the line of its first occurrence. Parts that are already registered are
+// reported as existing rather than failed, so the registration can be rerun before every import.
+func (im *Importer) RegisterParts(ctx context.Context, file *CSVFile, details PartDetails) *Report {
+	report := &Report{CSVHash: file.Hash}
+
+	seen := map[string]bool{}
+	var submissions []submission
+	for _, row := range file.Rows {
+		if row.PartNumber == "" || row.SerialNumber == "" || seen[row.PartNumber+"\x00"+row.SerialNumber] {
+			continue
+		}
+		seen[row.PartNumber+"\x00"+row.SerialNumber] = true
+
+		result := RowResult{Line: row.Line, PartNumber: row.PartNumber, SerialNumber: row.SerialNumber}
+		payload, err := json.Marshal(Part{
+			PartNumber:      row.PartNumber,
+			SerialNumber:    row.SerialNumber,
+			Manufacturer:    details.Manufacturer,
+			Material:        details.Material,
+			ManufactureDate: details.ManufactureDate,
+		})
+		if err != nil {
+			result.Status = StatusFailed
+			result.Error = fmt.Sprintf("failed to marshal part: %v", err)
+		} else if im.DryRun {
+			result.Status = StatusDryRun
+		} else {
+			submissions = append(submissions, submission{rows: []int{len(report.Rows)}, transaction: "RegisterPart", payload: payload})
+		}
+		report.Rows = append(report.Rows, result)
+	}
+
+	if !im.DryRun {
+		im.submitAll(ctx, submissions, report)
+	}
+	for i, result := range report.Rows {
+		if result.Status == StatusFailed && result.Attempts > 0 && alreadyRegistered(result.Error) {
+			report.Rows[i].Status = StatusExisting
+			report.Rows[i].Error = ""
+		}
+	}
+
+	report.count()
+	return report
+}
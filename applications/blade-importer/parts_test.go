@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// fakeRegistry answers RegisterPart like the blade-inspection chaincode
+type fakeRegistry struct {
+	mu    sync.Mutex
+	parts map[string]Part
+}
+
+func (r *fakeRegistry) SubmitTransaction(name string, transient map[string][]byte, args ...string) ([]byte, error) {
+	if name != "RegisterPart" || len(args) != 1 {
+		return nil, errors.New("unexpected transaction")
+	}
+	var part Part
+	if err := json.Unmarshal([]byte(args[0]), &part); err != nil {
+		return nil, err
+	}
+	if part.SerialNumber == "RGA85742" {
+		return nil, errors.New("only manufacturer organizations can register parts")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.parts[part.SerialNumber]; ok {
+		return nil, fmt.Errorf("part %s %s is already registered", part.PartNumber, part.SerialNumber)
+	}
+	r.parts[part.SerialNumber] = part
+	return nil, nil
+}
+
+var testPartDetails = PartDetails{Manufacturer: "Sample OEM", Material: "Nickel superalloy", ManufactureDate: "2020-01-01"}
+
+func TestRegisterParts(t *testing.T) {
+	file, err := ParseCSV(readSample(t, "before_surfacing.csv"), "")
+	if err != nil {
+		t.Fatalf("ParseCSV failed: %v", err)
+	}
+	// A blade measured twice is registered once
+	file.Rows = append(file.Rows, &CSVRow{Line: 99, PartNumber: "6A7614", SerialNumber: "RGA46870"})
+
+	registry := &fakeRegistry{parts: map[string]Part{"RGA85382": {}}}
+	importer := &Importer{Submitter: registry, Workers: 2}
+	report := importer.RegisterParts(context.Background(), file, testPartDetails)
+
+	if len(report.Rows) != len(file.Rows)-1 {
+		t.Fatalf("expected a row per distinct part, got %d", len(report.Rows))
+	}
+	if report.Existing != 1 || report.Failed != 1 || report.Submitted != len(report.Rows)-2 {
+		t.Errorf("unexpected totals: %d submitted, %d existing, %d failed", report.Submitted, report.Existing, report.Failed)
+	}
+	if part := registry.parts["RGA46870"]; part.PartNumber != "6A7614" || part.Manufacturer != "Sample OEM" ||
+		part.ManufactureDate != "2020-01-01" {
+		t.Errorf("unexpected registered part %+v", part)
+	}
+	for _, row := range report.Rows {
+		if row.SerialNumber == "RGA85382" && (row.Status != StatusExisting || row.Error != "") {
+			t.Errorf("expected the registered part to be reported as existing, got %+v", row)
+		}
+	}
+
+	// Rerunning reports every part as existing, except the one that keeps failing
+	report = importer.RegisterParts(context.Background(), file, testPartDetails)
+	if report.Existing != len(report.Rows)-1 || report.Failed != 1 {
+		t.Errorf("unexpected totals on rerun: %d existing, %d failed", report.Existing, report.Failed)
+	}
+}
+
+func TestRegisterPartsDryRun(t *testing.T) {
+	file, err := ParseCSV(readSample(t, "after_surfacing.csv"), "")
+	if err != nil {
+		t.Fatalf("ParseCSV failed: %v", err)
+	}
+
+	registry := &fakeRegistry{parts: map[string]Part{}}
+	report := (&Importer{Submitter: registry, DryRun: true}).RegisterParts(context.Background(), file, testPartDetails)
+	if len(registry.parts) != 0 || report.DryRun != len(file.Rows) {
+		t.Errorf("expected %d dry-run rows and no registrations, got %d and %d", len(file.Rows), report.DryRun, len(registry.parts))
+	}
+}
@@ -0,0 +1,176 @@
+// Command event-listener prints the chaincode events of an inspection chaincode as JSON lines, one per
+// event, resuming after the last printed event when it is restarted with the same checkpoint file.
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	listener "github.com/mahmoudhafez3/thermotrace/applications/event-listener"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+	"github.com/hyperledger/fabric-gateway/pkg/identity"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// printedEvent is the JSON line printed for each event
+type printedEvent struct {
+	BlockNumber   uint64          `json:"blockNumber"`
+	TransactionID string          `json:"transactionId"`
+	ChaincodeName string          `json:"chaincodeName"`
+	EventName     string          `json:"eventName"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func main() {
+	mspID := flag.String("msp-id", "MROLabMSP", "MSP ID of the client organization")
+	certPath := flag.String("cert", "", "client certificate (PEM)")
+	keyPath := flag.String("key", "", "client private key (PEM file or keystore directory)")
+	tlsCertPath := flag.String("tls-cert", "", "peer TLS CA certificate (PEM)")
+	peerEndpoint := flag.String("peer", "localhost:7051", "gateway peer endpoint")
+	peerHostAlias := flag.String("peer-host-alias", "peer0.mrolab.thermotrace.com", "gateway peer TLS host name")
+	channelName := flag.String("channel", "inspection-channel", "channel name")
+	chaincodeName := flag.String("chaincode", "bladeinspection", "chaincode name (bladeinspection or aidefectinspection)")
+	checkpointPath := flag.String("checkpoint", "", "checkpoint file (default <chaincode>.checkpoint.json)")
+	startBlock := flag.Uint64("start-block", 0, "first block to read without a checkpoint; 0 starts at the latest block")
+	retryDelay := flag.Duration("retry-delay", 5*time.Second, "delay before reconnecting after the event stream fails")
+	flag.Parse()
+
+	if *checkpointPath == "" {
+		*checkpointPath = *chaincodeName + ".checkpoint.json"
+	}
+	checkpointer, err := client.NewFileCheckpointer(*checkpointPath)
+	if err != nil {
+		fatalf("failed to open checkpoint file: %v", err)
+	}
+	defer checkpointer.Close()
+
+	clientConnection, err := newGrpcConnection(*tlsCertPath, *peerEndpoint, *peerHostAlias)
+	if err != nil {
+		fatalf("failed to connect to gateway peer: %v", err)
+	}
+	defer clientConnection.Close()
+
+	gw, err := newGateway(clientConnection, *mspID, *certPath, *keyPath)
+	if err != nil {
+		fatalf("failed to connect to gateway: %v", err)
+	}
+	defer gw.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	encoder := json.NewEncoder(os.Stdout)
+	l := &listener.Listener{
+		Source:       &listener.GatewaySource{Network: gw.GetNetwork(*channelName)},
+		Chaincode:    *chaincodeName,
+		Checkpointer: checkpointer,
+		StartBlock:   *startBlock,
+		RetryDelay:   *retryDelay,
+		Logf: func(format string, args ...interface{}) {
+			fmt.Fprintf(os.Stderr, format+"\n", args...)
+		},
+		Handler: func(ctx context.Context, event *client.ChaincodeEvent) error {
+			return encoder.Encode(printedEvent{
+				BlockNumber:   event.BlockNumber,
+				TransactionID: event.TransactionID,
+				ChaincodeName: event.ChaincodeName,
+				EventName:     event.EventName,
+				Payload:       event.Payload,
+			})
+		},
+	}
+
+	err = l.Run(ctx)
+	if err != nil && ctx.Err() == nil {
+		fatalf("%v", err)
+	}
+}
+
+// newGrpcConnection creates a TLS connection to the gateway peer
+func newGrpcConnection(tlsCertPath, peerEndpoint, peerHostAlias string) (*grpc.ClientConn, error) {
+	tlsCertPEM, err := os.ReadFile(tlsCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read TLS certificate: %v", err)
+	}
+	tlsCert, err := identity.CertificateFromPEM(tlsCertPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse TLS certificate: %v", err)
+	}
+
+	certPool := x509.NewCertPool()
+	certPool.AddCert(tlsCert)
+	transportCredentials := credentials.NewClientTLSFromCert(certPool, peerHostAlias)
+
+	return grpc.Dial(peerEndpoint, grpc.WithTransportCredentials(transportCredentials))
+}
+
+// newGateway connects to the gateway with the client's X.509 identity
+func newGateway(clientConnection *grpc.ClientConn, mspID, certPath, keyPath string) (*client.Gateway, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %v", err)
+	}
+	cert, err := identity.CertificateFromPEM(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate: %v", err)
+	}
+	id, err := identity.NewX509Identity(mspID, cert)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPEM, err := readPrivateKey(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := identity.PrivateKeyFromPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private key: %v", err)
+	}
+	sign, err := identity.NewPrivateKeySign(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return client.Connect(
+		id,
+		client.WithSign(sign),
+		client.WithClientConnection(clientConnection),
+		client.WithEvaluateTimeout(5*time.Second),
+	)
+}
+
+// readPrivateKey reads a PEM private key from a file or the first file of an MSP keystore directory
+func readPrivateKey(keyPath string) ([]byte, error) {
+	info, err := os.Stat(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private key: %v", err)
+	}
+	if info.IsDir() {
+		entries, err := os.ReadDir(keyPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keystore: %v", err)
+		}
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("keystore %s is empty", keyPath)
+		}
+		keyPath = filepath.Join(keyPath, entries[0].Name())
+	}
+
+	return os.ReadFile(keyPath)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
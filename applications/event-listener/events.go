@@ -0,0 +1,84 @@
+package listener
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// Chaincode event names. A transaction emits one event named after its most significant outcome, so
+// DefectDetected and OutOfTolerance events also report added or amended inspections.
+const (
+	EventInspectionAdded   = "InspectionAdded"
+	EventInspectionAmended = "InspectionAmended"
+	EventDefectDetected    = "DefectDetected"
+	EventOutOfTolerance    = "OutOfTolerance"
+)
+
+// DefectInspectionEvent is the payload of the InspectionAdded and DefectDetected events of the
+// ai-defect-inspection chaincode
+type DefectInspectionEvent struct {
+	PartNumber      string  `json:"partNumber"`
+	SerialNumber    string  `json:"serialNumber"`
+	InspectionDate  string  `json:"inspectionDate"`
+	Organization    string  `json:"organization"`
+	ModelName       string  `json:"modelName"`
+	ModelVersion    string  `json:"modelVersion"`
+	DefectDetected  bool    `json:"defectDetected"`
+	DefectType      string  `json:"defectType"`
+	ConfidenceScore float64 `json:"confidenceScore"`
+	SubmissionID    string  `json:"submissionId"`
+	TxID            string  `json:"txID"`
+}
+
+// BladeInspectionEvent is the payload of the InspectionAdded, InspectionAmended and OutOfTolerance events
+// of the blade-inspection chaincode, listing the inspections written by the transaction
+type BladeInspectionEvent struct {
+	Organization string                `json:"organization"`
+	CSVHash      string                `json:"csvHash,omitempty"` // set for AddInspectionsBatch
+	TxID         string                `json:"txId"`
+	Inspections  []InspectionEventItem `json:"inspections"`
+}
+
+// InspectionEventItem is a blade inspection written by the transaction with its assessed conformance
+type InspectionEventItem struct {
+	Key                  string   `json:"key"`
+	PartNumber           string   `json:"partNumber"`
+	SerialNumber         string   `json:"serialNumber"`
+	OccasionLabel        string   `json:"occasionLabel"`
+	InspectionDate       string   `json:"inspectionDate"`
+	Disposition          string   `json:"disposition"` // serviceable, repair, scrap or unassessed
+	OutOfLimitPoints     []string `json:"outOfLimitPoints,omitempty"`
+	ToleranceSpecVersion int      `json:"toleranceSpecVersion,omitempty"`
+	SubmissionID         string   `json:"submissionId"`
+	Version              int      `json:"version,omitempty"` // above 1 for an amended version
+}
+
+// DecodeDefectInspection decodes an event of the ai-defect-inspection chaincode
+func DecodeDefectInspection(event *client.ChaincodeEvent) (*DefectInspectionEvent, error) {
+	if event.EventName != EventInspectionAdded && event.EventName != EventDefectDetected {
+		return nil, fmt.Errorf("unexpected event %s", event.EventName)
+	}
+
+	var payload DefectInspectionEvent
+	err := json.Unmarshal(event.Payload, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s event: %v", event.EventName, err)
+	}
+	return &payload, nil
+}
+
+// DecodeBladeInspection decodes an event of the blade-inspection chaincode
+func DecodeBladeInspection(event *client.ChaincodeEvent) (*BladeInspectionEvent, error) {
+	if event.EventName != EventInspectionAdded && event.EventName != EventInspectionAmended && event.EventName != EventOutOfTolerance {
+		return nil, fmt.Errorf("unexpected event %s", event.EventName)
+	}
+
+	var payload BladeInspectionEvent
+	err := json.Unmarshal(event.Payload, &payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal %s event: %v", event.EventName, err)
+	}
+	return &payload, nil
+}
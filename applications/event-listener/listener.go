@@ -0,0 +1,118 @@
+// Package listener subscribes to the chaincode events of the ThermoTrace inspection chaincodes through
+// the Fabric Gateway. A Listener checkpoints each event after its handler succeeds and resumes from the
+// checkpoint when it reconnects or restarts, so consumers neither miss nor reprocess events.
+package listener
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// EventSource opens a stream of chaincode events that resumes after a checkpoint: events of earlier blocks,
+// and of the checkpoint's block up to and including its transaction, are skipped
+type EventSource interface {
+	ChaincodeEvents(ctx context.Context, chaincodeName string, checkpoint client.Checkpoint) (<-chan *client.ChaincodeEvent, error)
+}
+
+// GatewaySource reads chaincode events from a channel of the Fabric Gateway
+type GatewaySource struct {
+	Network *client.Network
+}
+
+// ChaincodeEvents opens an event stream starting at the checkpoint (at the latest block if it is empty)
+func (s *GatewaySource) ChaincodeEvents(ctx context.Context, chaincodeName string,
+	checkpoint client.Checkpoint) (<-chan *client.ChaincodeEvent, error) {
+
+	return s.Network.ChaincodeEvents(ctx, chaincodeName, client.WithCheckpoint(checkpoint))
+}
+
+// Checkpointer persists the position of the last processed event (satisfied by *client.FileCheckpointer).
+// A consumer that stores its results in a database can implement it in the same database transaction,
+// which also covers a crash between handling an event and checkpointing it.
+type Checkpointer interface {
+	client.Checkpoint
+	CheckpointChaincodeEvent(event *client.ChaincodeEvent) error
+}
+
+// Handler processes a single event. An error stops the listener before the event is checkpointed, so the
+// event is delivered again when the listener is restarted.
+type Handler func(ctx context.Context, event *client.ChaincodeEvent) error
+
+// Listener delivers the chaincode events of one chaincode to a handler in ledger order
+type Listener struct {
+	Source       EventSource
+	Chaincode    string
+	Checkpointer Checkpointer
+	Handler      Handler
+	StartBlock   uint64        // first block to read when there is no checkpoint yet; 0 starts at the latest block
+	RetryDelay   time.Duration // delay before reconnecting after the event stream fails
+	Logf         func(format string, args ...interface{})
+}
+
+// position is a fixed checkpoint, used to start at StartBlock
+type position struct {
+	blockNumber uint64
+}
+
+func (p position) BlockNumber() uint64   { return p.blockNumber }
+func (p position) TransactionID() string { return "" }
+
+// Run delivers events until ctx is cancelled or the handler or checkpointer fails. When the event stream
+// fails, for example because the peer restarted, it reconnects after RetryDelay from the last checkpoint.
+func (l *Listener) Run(ctx context.Context) error {
+	for {
+		reconnect, err := l.listen(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if !reconnect {
+			return err
+		}
+		l.logf("event stream failed, reconnecting in %v: %v", l.RetryDelay, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(l.RetryDelay):
+		}
+	}
+}
+
+// listen reads a single event stream until it fails. It returns whether the failure is one of the stream,
+// after which the listener reconnects, rather than of the handler or checkpointer.
+func (l *Listener) listen(ctx context.Context) (bool, error) {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var checkpoint client.Checkpoint = l.Checkpointer
+	if l.Checkpointer.BlockNumber() == 0 && l.Checkpointer.TransactionID() == "" {
+		checkpoint = position{l.StartBlock}
+	}
+
+	events, err := l.Source.ChaincodeEvents(streamCtx, l.Chaincode, checkpoint)
+	if err != nil {
+		return true, fmt.Errorf("failed to open event stream: %v", err)
+	}
+
+	for event := range events {
+		err = l.Handler(ctx, event)
+		if err != nil {
+			return false, fmt.Errorf("failed to handle %s event of transaction %s: %v", event.EventName, event.TransactionID, err)
+		}
+		err = l.Checkpointer.CheckpointChaincodeEvent(event)
+		if err != nil {
+			return false, fmt.Errorf("failed to checkpoint transaction %s: %v", event.TransactionID, err)
+		}
+	}
+
+	return true, fmt.Errorf("event stream closed")
+}
+
+func (l *Listener) logf(format string, args ...interface{}) {
+	if l.Logf != nil {
+		l.Logf(format, args...)
+	}
+}
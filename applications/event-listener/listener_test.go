@@ -0,0 +1,179 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric-gateway/pkg/client"
+)
+
+// fakeSource replays a ledger of chaincode events from a checkpoint as the gateway does
+type fakeSource struct {
+	ledger    []*client.ChaincodeEvent
+	failAfter int      // events delivered before a stream fails; 0 keeps streams open
+	opened    []string // start position of every stream, as block/transaction ID
+}
+
+func (s *fakeSource) ChaincodeEvents(ctx context.Context, chaincodeName string,
+	checkpoint client.Checkpoint) (<-chan *client.ChaincodeEvent, error) {
+
+	// The start position is read when the stream is opened
+	blockNumber, transactionID := checkpoint.BlockNumber(), checkpoint.TransactionID()
+	s.opened = append(s.opened, fmt.Sprintf("%d/%s", blockNumber, transactionID))
+
+	events := make(chan *client.ChaincodeEvent)
+	go func() {
+		defer close(events)
+		skipping := transactionID != ""
+		sent := 0
+		for _, event := range s.ledger {
+			if event.BlockNumber < blockNumber {
+				continue
+			}
+			if skipping && event.BlockNumber == blockNumber {
+				skipping = event.TransactionID != transactionID
+				continue
+			}
+			if s.failAfter > 0 && sent == s.failAfter {
+				return
+			}
+			select {
+			case events <- event:
+				sent++
+			case <-ctx.Done():
+				return
+			}
+		}
+		// A live stream waits for new blocks
+		<-ctx.Done()
+	}()
+	return events, nil
+}
+
+func sampleLedger() []*client.ChaincodeEvent {
+	return []*client.ChaincodeEvent{
+		{BlockNumber: 2, TransactionID: "tx1", ChaincodeName: "bladeinspection", EventName: EventInspectionAdded},
+		{BlockNumber: 3, TransactionID: "tx2", ChaincodeName: "bladeinspection", EventName: EventOutOfTolerance},
+		{BlockNumber: 3, TransactionID: "tx3", ChaincodeName: "bladeinspection", EventName: EventInspectionAdded},
+		{BlockNumber: 5, TransactionID: "tx4", ChaincodeName: "bladeinspection", EventName: EventInspectionAdded},
+		{BlockNumber: 6, TransactionID: "tx5", ChaincodeName: "bladeinspection", EventName: EventOutOfTolerance},
+	}
+}
+
+// collect returns a handler that records transaction IDs and cancels the context after count events
+func collect(cancel context.CancelFunc, count int, handled *[]string) Handler {
+	return func(ctx context.Context, event *client.ChaincodeEvent) error {
+		*handled = append(*handled, event.TransactionID)
+		if len(*handled) == count {
+			cancel()
+		}
+		return nil
+	}
+}
+
+func newCheckpointer(t *testing.T, path string) *client.FileCheckpointer {
+	t.Helper()
+	checkpointer, err := client.NewFileCheckpointer(path)
+	if err != nil {
+		t.Fatalf("failed to create checkpointer: %v", err)
+	}
+	t.Cleanup(func() { checkpointer.Close() })
+	return checkpointer
+}
+
+func TestListenerReconnectsFromCheckpoint(t *testing.T) {
+	source := &fakeSource{ledger: sampleLedger(), failAfter: 2}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var handled []string
+	listener := &Listener{
+		Source:       source,
+		Chaincode:    "bladeinspection",
+		Checkpointer: newCheckpointer(t, filepath.Join(t.TempDir(), "checkpoint.json")),
+		Handler:      collect(cancel, 5, &handled),
+		StartBlock:   1,
+		RetryDelay:   time.Millisecond,
+	}
+	err := listener.Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the listener to run until cancelled, got %v", err)
+	}
+
+	if expected := []string{"tx1", "tx2", "tx3", "tx4", "tx5"}; !reflect.DeepEqual(handled, expected) {
+		t.Errorf("expected every event once, got %v", handled)
+	}
+	if expected := []string{"1/", "3/tx2", "5/tx4"}; !reflect.DeepEqual(source.opened, expected) {
+		t.Errorf("expected streams to resume from the checkpoint, got %v", source.opened)
+	}
+}
+
+func TestListenerResumesAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	source := &fakeSource{ledger: sampleLedger()}
+
+	// The handler fails on tx3, which stops the listener without checkpointing it
+	var handled []string
+	checkpointer := newCheckpointer(t, path)
+	listener := &Listener{
+		Source:       source,
+		Chaincode:    "bladeinspection",
+		Checkpointer: checkpointer,
+		Handler: func(ctx context.Context, event *client.ChaincodeEvent) error {
+			if event.TransactionID == "tx3" {
+				return errors.New("database unavailable")
+			}
+			handled = append(handled, event.TransactionID)
+			return nil
+		},
+		StartBlock: 1,
+	}
+	err := listener.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "database unavailable") {
+		t.Fatalf("expected the handler error, got %v", err)
+	}
+	checkpointer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	listener.Checkpointer = newCheckpointer(t, path)
+	listener.Handler = collect(cancel, 5, &handled)
+	err = listener.Run(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected the listener to run until cancelled, got %v", err)
+	}
+
+	if expected := []string{"tx1", "tx2", "tx3", "tx4", "tx5"}; !reflect.DeepEqual(handled, expected) {
+		t.Errorf("expected the restart to continue after tx2, got %v", handled)
+	}
+}
+
+func TestDecodeEvents(t *testing.T) {
+	blade, err := DecodeBladeInspection(&client.ChaincodeEvent{EventName: EventOutOfTolerance, Payload: []byte(
+		`{"organization":"MROLabMSP","txId":"tx1","inspections":[{"serialNumber":"RGA85382","disposition":"scrap","outOfLimitPoints":["AC"]}]}`)})
+	if err != nil || len(blade.Inspections) != 1 || blade.Inspections[0].OutOfLimitPoints[0] != "AC" {
+		t.Errorf("unexpected blade event %+v (%v)", blade, err)
+	}
+
+	amended, err := DecodeBladeInspection(&client.ChaincodeEvent{EventName: EventInspectionAmended, Payload: []byte(
+		`{"organization":"MROLabMSP","txId":"tx2","inspections":[{"serialNumber":"RGA85382","version":2}]}`)})
+	if err != nil || amended.Inspections[0].Version != 2 {
+		t.Errorf("unexpected amendment event %+v (%v)", amended, err)
+	}
+
+	defect, err := DecodeDefectInspection(&client.ChaincodeEvent{EventName: EventDefectDetected, Payload: []byte(
+		`{"serialNumber":"BLADE-001","defectDetected":true,"defectType":"thermal defect","confidenceScore":0.92}`)})
+	if err != nil || !defect.DefectDetected || defect.ConfidenceScore != 0.92 {
+		t.Errorf("unexpected defect event %+v (%v)", defect, err)
+	}
+
+	if _, err := DecodeBladeInspection(&client.ChaincodeEvent{EventName: EventDefectDetected}); err == nil {
+		t.Error("expected an AI event to be rejected as a blade event")
+	}
+}
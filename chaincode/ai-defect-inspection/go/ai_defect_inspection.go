@@ -4,14 +4,17 @@ import (
 	"crypto/sha256"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
 )
 
 // SmartContract provides functions for managing AI defect inspections
 type SmartContract struct {
 	contractapi.Contract
+	common.RegistryContract[registryDefaults]
 }
 
 // AIDefectInspection represents a composite material defect inspection using AI
@@ -23,8 +26,8 @@ type AIDefectInspection struct {
 
 	// Inspection Metadata
 	InspectionDate string `json:"inspectionDate"`
-	InspectionType string `json:"inspectionType"` // e.g., "Active Thermography"
-	Inspector      string `json:"inspector"`      // Private field
+	InspectionType string `json:"inspectionType"`             // e.g., "Active Thermography"
+	Inspector      string `json:"inspector" ledger:"private"` // kept in the submitting org's private collection
 	Organization   string `json:"organization"`
 
 	// Video/Image Data (External Storage References)
@@ -73,58 +76,53 @@ type AIDefectInspection struct {
 	GT_BBox_X2     float64 `json:"gt_bbox_x2"`
 	GT_BBox_Y2     float64 `json:"gt_bbox_y2"`
 
+	// Idempotency (derived from the content when the client does not supply one)
+	SubmissionID string `json:"submissionId"`
+
+	// Approval workflow (draft, submitted, reviewed, released or rejected; see workflow.go)
+	Status        string                `json:"status,omitempty"` // draft if requested, submitted by default
+	StatusHistory []common.StatusChange `json:"statusHistory,omitempty"`
+
 	// Blockchain Metadata
 	TxID                string `json:"txID"`
 	BlockchainTimestamp string `json:"blockchainTimestamp"`
 	SubmittedAt         string `json:"submittedAt"`
 }
 
-// AIDefectInspectionPublic contains public data (shared across orgs)
-type AIDefectInspectionPublic struct {
-	PartNumber         string  `json:"partNumber"`
-	SerialNumber       string  `json:"serialNumber"`
-	MaterialType       string  `json:"materialType"`
-	InspectionDate     string  `json:"inspectionDate"`
-	InspectionType     string  `json:"inspectionType"`
-	Organization       string  `json:"organization"`
-	RawVideoHash       string  `json:"rawVideoHash"`
-	RawVideoIPFS       string  `json:"rawVideoIPFS"`
-	RawVideoSize       int64   `json:"rawVideoSize"`
-	ProcessedImageHash string  `json:"processedImageHash"`
-	ProcessedImageIPFS string  `json:"processedImageIPFS"`
-	ROI_Y1             int     `json:"roi_y1"`
-	ROI_Y2             int     `json:"roi_y2"`
-	ROI_X1             int     `json:"roi_x1"`
-	ROI_X2             int     `json:"roi_x2"`
-	PulseTime          int     `json:"pulseTime"`
-	PCAComponents      int     `json:"pcaComponents"`
-	SequenceLength     int     `json:"sequenceLength"`
-	ModelName          string  `json:"modelName"`
-	ModelVersion       string  `json:"modelVersion"`
-	ModelHash          string  `json:"modelHash"`
-	DefectDetected     bool    `json:"defectDetected"`
-	DefectType         string  `json:"defectType"`
-	ConfidenceScore    float64 `json:"confidenceScore"`
-	BBox_X1            float64 `json:"bbox_x1"`
-	BBox_Y1            float64 `json:"bbox_y1"`
-	BBox_X2            float64 `json:"bbox_x2"`
-	BBox_Y2            float64 `json:"bbox_y2"`
-	IoU                float64 `json:"iou"`
-	CenterDistance     float64 `json:"centerDistance"`
-	NormCenterDistance float64 `json:"normCenterDistance"`
-	HasGroundTruth     bool    `json:"hasGroundTruth"`
-	GT_BBox_X1         float64 `json:"gt_bbox_x1"`
-	GT_BBox_Y1         float64 `json:"gt_bbox_y1"`
-	GT_BBox_X2         float64 `json:"gt_bbox_x2"`
-	GT_BBox_Y2         float64 `json:"gt_bbox_y2"`
-	TxID               string  `json:"txID"`
-	BlockchainTimestamp string `json:"blockchainTimestamp"`
-	SubmittedAt        string  `json:"submittedAt"`
+// defectInspectionObjectType prefixes the composite keys of inspection events: defectInspection~SerialNumber~TxID.
+// The simple key SerialNumber holds a copy of the latest inspection of the serial number.
+const defectInspectionObjectType = "defectInspection"
+
+// maxSubmissionSkew is how far a client-supplied submission time may be ahead of the transaction timestamp
+const maxSubmissionSkew = 5 * time.Minute
+
+// getTxTime returns the transaction timestamp in UTC, which is identical on every endorsing peer
+func getTxTime(ctx contractapi.TransactionContextInterface) (time.Time, error) {
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	return time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).UTC(), nil
 }
 
-// AIDefectInspectionPrivate contains private data (inspector name only)
-type AIDefectInspectionPrivate struct {
-	Inspector string `json:"inspector"`
+// validateSubmittedAt checks the client-supplied submission time (RFC3339, not later than the
+// transaction timestamp plus maxSubmissionSkew) and normalizes it to UTC. An empty value defaults
+// to the transaction timestamp.
+func validateSubmittedAt(submittedAt string, txTime time.Time) (string, error) {
+	if submittedAt == "" {
+		return txTime.Format(time.RFC3339), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, submittedAt)
+	if err != nil {
+		return "", fmt.Errorf("submittedAt must be an RFC3339 timestamp: %v", err)
+	}
+	if t.After(txTime.Add(maxSubmissionSkew)) {
+		return "", fmt.Errorf("submittedAt %s is more than %v after the transaction timestamp %s",
+			submittedAt, maxSubmissionSkew, txTime.Format(time.RFC3339))
+	}
+
+	return t.UTC().Format(time.RFC3339), nil
 }
 
 // InitLedger initializes the ledger with sample data
@@ -133,319 +131,341 @@ func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface)
 	return nil
 }
 
-// AddDefectInspection adds a new AI defect inspection to the ledger
+// getPrivateCollectionName returns the private collection the org registry assigns to the caller's organization
+func getPrivateCollectionName(ctx contractapi.TransactionContextInterface) (string, error) {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return "", fmt.Errorf("failed to get MSP ID: %v", err)
+	}
+	registry, err := common.LoadRegistry[registryDefaults](ctx)
+	if err != nil {
+		return "", err
+	}
+	return registry.PrivateCollection(mspID)
+}
+
+// inspectionKey returns the composite key of an inspection event
+func inspectionKey(ctx contractapi.TransactionContextInterface, serialNumber, txID string) (string, error) {
+	return common.CompositeKey(ctx.GetStub(), defectInspectionObjectType, serialNumber, txID)
+}
+
+// inspectionMethod is the certification (ndt.method attribute) required to submit AI defect inspections
+const inspectionMethod = "thermography"
+
+// AddDefectInspection adds a new AI defect inspection to the ledger and returns it. Each inspection is
+// kept under its own key, so re-inspecting a serial number adds to its history and becomes its latest
+// inspection. Submissions are idempotent: resubmitting the same content under the same submission ID
+// returns the existing inspection, while different content under a used ID fails with SUBMISSION_CONFLICT.
+// A new inspection emits a DefectDetected event if it detected a defect and InspectionAdded otherwise.
+// The inspector is sent in the transient map under "private" ({"inspector":"..."}); an inspector in the
+// arguments, which every channel member can read from the block, is rejected. The inspection is
+// submitted for review unless its status is "draft". The part must be registered in the bladeinspection
+// chaincode's part registry and not scrapped. Only inspectors certified in thermography may submit
+// inspections.
 func (s *SmartContract) AddDefectInspection(ctx contractapi.TransactionContextInterface,
-	inspectionJSON string) error {
+	inspectionJSON string) (*AIDefectInspection, error) {
 
-	// Parse the input JSON
-	var inspection AIDefectInspection
-	err := json.Unmarshal([]byte(inspectionJSON), &inspection)
+	err := common.CheckInspector(ctx.GetClientIdentity(), inspectionMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse and validate the input JSON
+	inspection, err := parseInspection(inspectionJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	// Private fields arrive in the transient map, which is not written to the block
+	transientJSON, err := common.TransientPrivate(ctx.GetStub())
+	if err != nil {
+		return nil, err
+	}
+	if transientJSON != nil {
+		err = common.UnmarshalTransientPrivate(transientJSON, inspection)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// A resubmission returns the inspection its submission ID already created
+	submission, existing, err := checkSubmission(ctx, inspection)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	// New inspections need a registered part that has not been scrapped
+	err = checkPartInspectable(ctx, inspection.PartNumber, inspection.SerialNumber)
 	if err != nil {
-		return fmt.Errorf("failed to parse inspection JSON: %v", err)
+		return nil, err
 	}
 
-	// Get transaction metadata
+	// Get transaction metadata. Every endorser must produce the same write set, so times are
+	// derived from the transaction timestamp rather than the peer's clock.
 	txID := ctx.GetStub().GetTxID()
-	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	txTime, err := getTxTime(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to get transaction timestamp: %v", err)
+		return nil, err
 	}
 
-	blockchainTimestamp := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).Format(time.RFC3339)
 	inspection.TxID = txID
-	inspection.BlockchainTimestamp = blockchainTimestamp
-	inspection.SubmittedAt = time.Now().Format(time.RFC3339)
+	inspection.BlockchainTimestamp = txTime.Format(time.RFC3339)
+	inspection.SubmittedAt, err = validateSubmittedAt(inspection.SubmittedAt, txTime)
+	if err != nil {
+		return nil, err
+	}
+
+	// Start the approval workflow as a draft or submitted for review
+	inspection.Status, err = common.InitialStatus(inspection.Status)
+	if err != nil {
+		return nil, err
+	}
+	change, actor, err := common.NewStatusChange(ctx, inspection.Status, "")
+	if err != nil {
+		return nil, err
+	}
+	inspection.StatusHistory = []common.StatusChange{change}
 
 	// Get the organization MSP ID
 	mspID, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
-		return fmt.Errorf("failed to get MSP ID: %v", err)
+		return nil, fmt.Errorf("failed to get MSP ID: %v", err)
 	}
 	inspection.Organization = mspID
 
 	// Determine which private collection to use based on org
-	var privateCollectionName string
-	if mspID == "ManufacturerMSP" {
-		privateCollectionName = "aiDefectPrivateManufacturerCollection"
-	} else if mspID == "MROLabMSP" {
-		privateCollectionName = "aiDefectPrivateMROLabCollection"
-	} else {
-		return fmt.Errorf("unknown MSP ID: %s", mspID)
-	}
-
-	// Split data into public and private
-	publicData := AIDefectInspectionPublic{
-		PartNumber:          inspection.PartNumber,
-		SerialNumber:        inspection.SerialNumber,
-		MaterialType:        inspection.MaterialType,
-		InspectionDate:      inspection.InspectionDate,
-		InspectionType:      inspection.InspectionType,
-		Organization:        inspection.Organization,
-		RawVideoHash:        inspection.RawVideoHash,
-		RawVideoIPFS:        inspection.RawVideoIPFS,
-		RawVideoSize:        inspection.RawVideoSize,
-		ProcessedImageHash:  inspection.ProcessedImageHash,
-		ProcessedImageIPFS:  inspection.ProcessedImageIPFS,
-		ROI_Y1:              inspection.ROI_Y1,
-		ROI_Y2:              inspection.ROI_Y2,
-		ROI_X1:              inspection.ROI_X1,
-		ROI_X2:              inspection.ROI_X2,
-		PulseTime:           inspection.PulseTime,
-		PCAComponents:       inspection.PCAComponents,
-		SequenceLength:      inspection.SequenceLength,
-		ModelName:           inspection.ModelName,
-		ModelVersion:        inspection.ModelVersion,
-		ModelHash:           inspection.ModelHash,
-		DefectDetected:      inspection.DefectDetected,
-		DefectType:          inspection.DefectType,
-		ConfidenceScore:     inspection.ConfidenceScore,
-		BBox_X1:             inspection.BBox_X1,
-		BBox_Y1:             inspection.BBox_Y1,
-		BBox_X2:             inspection.BBox_X2,
-		BBox_Y2:             inspection.BBox_Y2,
-		IoU:                 inspection.IoU,
-		CenterDistance:      inspection.CenterDistance,
-		NormCenterDistance:  inspection.NormCenterDistance,
-		HasGroundTruth:      inspection.HasGroundTruth,
-		GT_BBox_X1:          inspection.GT_BBox_X1,
-		GT_BBox_Y1:          inspection.GT_BBox_Y1,
-		GT_BBox_X2:          inspection.GT_BBox_X2,
-		GT_BBox_Y2:          inspection.GT_BBox_Y2,
-		TxID:                inspection.TxID,
-		BlockchainTimestamp: inspection.BlockchainTimestamp,
-		SubmittedAt:         inspection.SubmittedAt,
-	}
-
-	privateData := AIDefectInspectionPrivate{
-		Inspector: inspection.Inspector,
-	}
-
-	// Store public data in public collection
-	publicDataJSON, err := json.Marshal(publicData)
-	if err != nil {
-		return fmt.Errorf("failed to marshal public data: %v", err)
+	privateCollectionName, err := getPrivateCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := inspectionKey(ctx, inspection.SerialNumber, txID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The previous latest inspection, whose index entries are replaced
+	previous, err := readLatestInspection(ctx, inspection.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// Store public data under the event key and as the latest inspection of the serial number
+	publicDataJSON, err := common.MarshalPublic(inspection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public data: %v", err)
 	}
 
+	err = ctx.GetStub().PutState(key, publicDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to put public data: %v", err)
+	}
 	err = ctx.GetStub().PutState(inspection.SerialNumber, publicDataJSON)
 	if err != nil {
-		return fmt.Errorf("failed to put public data: %v", err)
+		return nil, fmt.Errorf("failed to update latest inspection: %v", err)
+	}
+	err = updateIndexes(ctx, previous, inspection)
+	if err != nil {
+		return nil, err
 	}
 
 	// Store private data in org-specific collection
-	privateDataJSON, err := json.Marshal(privateData)
+	privateDataJSON, err := common.MarshalPrivate(inspection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private data: %v", err)
+	}
+
+	err = ctx.GetStub().PutPrivateData(privateCollectionName, key, privateDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to put private data: %v", err)
+	}
+	err = common.PutStatusActor(ctx, privateCollectionName, inspection, actor)
 	if err != nil {
-		return fmt.Errorf("failed to marshal private data: %v", err)
+		return nil, err
 	}
 
-	err = ctx.GetStub().PutPrivateData(privateCollectionName, inspection.SerialNumber, privateDataJSON)
+	// Record the submission, so that resubmissions find this inspection
+	err = putSubmission(ctx, submission, key)
 	if err != nil {
-		return fmt.Errorf("failed to put private data: %v", err)
+		return nil, err
 	}
 
-	fmt.Printf("AI Defect Inspection added: %s by %s\n", inspection.SerialNumber, mspID)
-	return nil
+	// Notify subscribers; a resubmission returned above emits no event
+	err = setInspectionEvent(ctx, inspection)
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("AI Defect Inspection added: %s (%s) by %s\n", inspection.SerialNumber, txID, mspID)
+	return inspection, nil
 }
 
-// GetDefectInspection retrieves an AI defect inspection by serial number
-func (s *SmartContract) GetDefectInspection(ctx contractapi.TransactionContextInterface,
-	serialNumber string) (*AIDefectInspection, error) {
+// readPrivateData fills in the private fields of an inspection from a private collection. They stay
+// empty when the collection holds no record for the inspection.
+func readPrivateData(ctx contractapi.TransactionContextInterface, collection string, inspection *AIDefectInspection) {
+	if collection == "" {
+		return
+	}
+	key, err := inspectionKey(ctx, inspection.SerialNumber, inspection.TxID)
+	if err != nil {
+		return
+	}
 
-	// Get public data
+	privateDataJSON, err := ctx.GetStub().GetPrivateData(collection, key)
+	if err != nil || privateDataJSON == nil {
+		return
+	}
+	_ = common.UnmarshalPrivate(privateDataJSON, inspection)
+}
+
+// readLatestInspection returns the public data of the latest inspection of a serial number, or nil if there is none
+func readLatestInspection(ctx contractapi.TransactionContextInterface, serialNumber string) (*AIDefectInspection, error) {
 	publicDataJSON, err := ctx.GetStub().GetState(serialNumber)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read public data: %v", err)
 	}
 	if publicDataJSON == nil {
-		return nil, fmt.Errorf("inspection %s does not exist", serialNumber)
+		return nil, nil
 	}
 
-	var publicData AIDefectInspectionPublic
-	err = json.Unmarshal(publicDataJSON, &publicData)
+	var inspection AIDefectInspection
+	err = json.Unmarshal(publicDataJSON, &inspection)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal public data: %v", err)
 	}
+	return &inspection, nil
+}
+
+// GetDefectInspection retrieves the latest AI defect inspection of a serial number
+func (s *SmartContract) GetDefectInspection(ctx contractapi.TransactionContextInterface,
+	serialNumber string) (*AIDefectInspection, error) {
+
+	// Get public data
+	inspection, err := readLatestInspection(ctx, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if inspection == nil {
+		return nil, fmt.Errorf("inspection %s does not exist", serialNumber)
+	}
 
 	// Get MSP ID to determine which private collection to read
-	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	privateCollectionName, err := getPrivateCollectionName(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get MSP ID: %v", err)
+		return nil, err
 	}
 
-	var privateCollectionName string
-	if mspID == "ManufacturerMSP" {
-		privateCollectionName = "aiDefectPrivateManufacturerCollection"
-	} else if mspID == "MROLabMSP" {
-		privateCollectionName = "aiDefectPrivateMROLabCollection"
-	} else {
-		return nil, fmt.Errorf("unknown MSP ID: %s", mspID)
+	// Combine public and private data
+	readPrivateData(ctx, privateCollectionName, inspection)
+	return inspection, nil
+}
+
+// VerifyDefectInspectionPrivate checks a candidate private record of an AI inspection (JSON with the
+// inspector) against the hash of the submitting org's private data, so an auditor of any org can confirm
+// a claim without the data being disclosed. The inspection is identified by its serial number and TxID.
+// Evaluate it rather than submit it, so the candidate is never written to a block.
+func (s *SmartContract) VerifyDefectInspectionPrivate(ctx contractapi.TransactionContextInterface,
+	serialNumber string, txID string, candidateJSON string) (*common.PrivateDataVerification, error) {
+
+	key, err := inspectionKey(ctx, serialNumber, txID)
+	if err != nil {
+		return nil, err
+	}
+	publicDataJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public data: %v", err)
+	}
+	if publicDataJSON == nil {
+		return nil, fmt.Errorf("inspection %s (%s) does not exist", serialNumber, txID)
 	}
 
-	// Try to get private data
-	privateDataJSON, err := ctx.GetStub().GetPrivateData(privateCollectionName, serialNumber)
-	var inspector string
-	if err == nil && privateDataJSON != nil {
-		var privateData AIDefectInspectionPrivate
-		err = json.Unmarshal(privateDataJSON, &privateData)
-		if err == nil {
-			inspector = privateData.Inspector
-		}
+	var inspection AIDefectInspection
+	err = json.Unmarshal(publicDataJSON, &inspection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal public data: %v", err)
 	}
 
-	// Combine public and private data
-	inspection := &AIDefectInspection{
-		PartNumber:          publicData.PartNumber,
-		SerialNumber:        publicData.SerialNumber,
-		MaterialType:        publicData.MaterialType,
-		InspectionDate:      publicData.InspectionDate,
-		InspectionType:      publicData.InspectionType,
-		Inspector:           inspector,
-		Organization:        publicData.Organization,
-		RawVideoHash:        publicData.RawVideoHash,
-		RawVideoIPFS:        publicData.RawVideoIPFS,
-		RawVideoSize:        publicData.RawVideoSize,
-		ProcessedImageHash:  publicData.ProcessedImageHash,
-		ProcessedImageIPFS:  publicData.ProcessedImageIPFS,
-		ROI_Y1:              publicData.ROI_Y1,
-		ROI_Y2:              publicData.ROI_Y2,
-		ROI_X1:              publicData.ROI_X1,
-		ROI_X2:              publicData.ROI_X2,
-		PulseTime:           publicData.PulseTime,
-		PCAComponents:       publicData.PCAComponents,
-		SequenceLength:      publicData.SequenceLength,
-		ModelName:           publicData.ModelName,
-		ModelVersion:        publicData.ModelVersion,
-		ModelHash:           publicData.ModelHash,
-		DefectDetected:      publicData.DefectDetected,
-		DefectType:          publicData.DefectType,
-		ConfidenceScore:     publicData.ConfidenceScore,
-		BBox_X1:             publicData.BBox_X1,
-		BBox_Y1:             publicData.BBox_Y1,
-		BBox_X2:             publicData.BBox_X2,
-		BBox_Y2:             publicData.BBox_Y2,
-		IoU:                 publicData.IoU,
-		CenterDistance:      publicData.CenterDistance,
-		NormCenterDistance:  publicData.NormCenterDistance,
-		HasGroundTruth:      publicData.HasGroundTruth,
-		GT_BBox_X1:          publicData.GT_BBox_X1,
-		GT_BBox_Y1:          publicData.GT_BBox_Y1,
-		GT_BBox_X2:          publicData.GT_BBox_X2,
-		GT_BBox_Y2:          publicData.GT_BBox_Y2,
-		TxID:                publicData.TxID,
-		BlockchainTimestamp: publicData.BlockchainTimestamp,
-		SubmittedAt:         publicData.SubmittedAt,
+	// Re-encode the candidate the way AddDefectInspection encodes private data, so that equal records hash equally
+	var candidate AIDefectInspection
+	err = common.UnmarshalPrivate([]byte(candidateJSON), &candidate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal candidate: %v", err)
+	}
+	candidateBytes, err := common.MarshalPrivate(candidate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal candidate: %v", err)
 	}
 
-	return inspection, nil
+	// The submitting org may since have been deactivated, so the registry is read directly
+	registry, err := common.LoadRegistry[registryDefaults](ctx)
+	if err != nil {
+		return nil, err
+	}
+	owner, ok := registry.Organizations[inspection.Organization]
+	if !ok {
+		return nil, fmt.Errorf("organization %s is not registered", inspection.Organization)
+	}
+	return common.VerifyPrivateData(ctx.GetStub(), owner.PrivateCollection, key, candidateBytes)
 }
 
-// GetAllDefectInspections returns all AI defect inspections
-func (s *SmartContract) GetAllDefectInspections(ctx contractapi.TransactionContextInterface) ([]*AIDefectInspection, error) {
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+// GetDefectInspectionHistory returns every AI inspection of a serial number, oldest first, so that
+// detections can be compared across model versions
+func (s *SmartContract) GetDefectInspectionHistory(ctx contractapi.TransactionContextInterface,
+	serialNumber string) ([]*AIDefectInspection, error) {
+
+	privateCollectionName, err := getPrivateCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(defectInspectionObjectType, []string{serialNumber})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get state by range: %v", err)
+		return nil, fmt.Errorf("failed to read inspection history: %v", err)
 	}
 	defer resultsIterator.Close()
 
-	var inspections []*AIDefectInspection
+	inspections := []*AIDefectInspection{}
 	for resultsIterator.HasNext() {
 		queryResponse, err := resultsIterator.Next()
 		if err != nil {
 			return nil, fmt.Errorf("failed to iterate: %v", err)
 		}
 
-		var publicData AIDefectInspectionPublic
-		err = json.Unmarshal(queryResponse.Value, &publicData)
+		var inspection AIDefectInspection
+		err = json.Unmarshal(queryResponse.Value, &inspection)
 		if err != nil {
-			continue
-		}
-
-		// Get private data if available
-		mspID, _ := ctx.GetClientIdentity().GetMSPID()
-		var privateCollectionName string
-		if mspID == "ManufacturerMSP" {
-			privateCollectionName = "aiDefectPrivateManufacturerCollection"
-		} else if mspID == "MROLabMSP" {
-			privateCollectionName = "aiDefectPrivateMROLabCollection"
-		}
-
-		var inspector string
-		if privateCollectionName != "" {
-			privateDataJSON, err := ctx.GetStub().GetPrivateData(privateCollectionName, publicData.SerialNumber)
-			if err == nil && privateDataJSON != nil {
-				var privateData AIDefectInspectionPrivate
-				err = json.Unmarshal(privateDataJSON, &privateData)
-				if err == nil {
-					inspector = privateData.Inspector
-				}
-			}
-		}
-
-		inspection := &AIDefectInspection{
-			PartNumber:          publicData.PartNumber,
-			SerialNumber:        publicData.SerialNumber,
-			MaterialType:        publicData.MaterialType,
-			InspectionDate:      publicData.InspectionDate,
-			InspectionType:      publicData.InspectionType,
-			Inspector:           inspector,
-			Organization:        publicData.Organization,
-			RawVideoHash:        publicData.RawVideoHash,
-			RawVideoIPFS:        publicData.RawVideoIPFS,
-			RawVideoSize:        publicData.RawVideoSize,
-			ProcessedImageHash:  publicData.ProcessedImageHash,
-			ProcessedImageIPFS:  publicData.ProcessedImageIPFS,
-			ROI_Y1:              publicData.ROI_Y1,
-			ROI_Y2:              publicData.ROI_Y2,
-			ROI_X1:              publicData.ROI_X1,
-			ROI_X2:              publicData.ROI_X2,
-			PulseTime:           publicData.PulseTime,
-			PCAComponents:       publicData.PCAComponents,
-			SequenceLength:      publicData.SequenceLength,
-			ModelName:           publicData.ModelName,
-			ModelVersion:        publicData.ModelVersion,
-			ModelHash:           publicData.ModelHash,
-			DefectDetected:      publicData.DefectDetected,
-			DefectType:          publicData.DefectType,
-			ConfidenceScore:     publicData.ConfidenceScore,
-			BBox_X1:             publicData.BBox_X1,
-			BBox_Y1:             publicData.BBox_Y1,
-			BBox_X2:             publicData.BBox_X2,
-			BBox_Y2:             publicData.BBox_Y2,
-			IoU:                 publicData.IoU,
-			CenterDistance:      publicData.CenterDistance,
-			NormCenterDistance:  publicData.NormCenterDistance,
-			HasGroundTruth:      publicData.HasGroundTruth,
-			GT_BBox_X1:          publicData.GT_BBox_X1,
-			GT_BBox_Y1:          publicData.GT_BBox_Y1,
-			GT_BBox_X2:          publicData.GT_BBox_X2,
-			GT_BBox_Y2:          publicData.GT_BBox_Y2,
-			TxID:                publicData.TxID,
-			BlockchainTimestamp: publicData.BlockchainTimestamp,
-			SubmittedAt:         publicData.SubmittedAt,
+			return nil, fmt.Errorf("failed to unmarshal public data: %v", err)
 		}
-
-		inspections = append(inspections, inspection)
+		readPrivateData(ctx, privateCollectionName, &inspection)
+		inspections = append(inspections, &inspection)
 	}
 
+	// Keys are ordered by TxID, so order by the time each inspection was recorded
+	sort.SliceStable(inspections, func(i, j int) bool {
+		return inspections[i].BlockchainTimestamp < inspections[j].BlockchainTimestamp
+	})
+
 	return inspections, nil
 }
 
-// QueryDefectsByConfidence returns inspections above a confidence threshold
-func (s *SmartContract) QueryDefectsByConfidence(ctx contractapi.TransactionContextInterface,
-	minConfidence float64) ([]*AIDefectInspection, error) {
-
-	allInspections, err := s.GetAllDefectInspections(ctx)
-	if err != nil {
-		return nil, err
-	}
+// GetAllDefectInspections returns the latest AI defect inspection of every serial number, reading them
+// page by page with GetDefectInspectionsPage. Like it, it must be evaluated rather than submitted (use
+// GetDefectInspectionsPage for large fleets).
+func (s *SmartContract) GetAllDefectInspections(ctx contractapi.TransactionContextInterface) ([]*AIDefectInspection, error) {
+	var inspections []*AIDefectInspection
+	bookmark := ""
+	for {
+		page, err := s.GetDefectInspectionsPage(ctx, maxPageSize, bookmark)
+		if err != nil {
+			return nil, err
+		}
+		inspections = append(inspections, page.Records...)
 
-	var filtered []*AIDefectInspection
-	for _, inspection := range allInspections {
-		if inspection.DefectDetected && inspection.ConfidenceScore >= minConfidence {
-			filtered = append(filtered, inspection)
+		if page.Bookmark == "" {
+			return inspections, nil
 		}
+		bookmark = page.Bookmark
 	}
-
-	return filtered, nil
 }
 
 // VerifyVideoHash verifies the integrity of the raw video file
@@ -460,25 +480,6 @@ func (s *SmartContract) VerifyVideoHash(ctx contractapi.TransactionContextInterf
 	return inspection.RawVideoHash == providedHash, nil
 }
 
-// GetInspectionsByPart returns all inspections for a given part number
-func (s *SmartContract) GetInspectionsByPart(ctx contractapi.TransactionContextInterface,
-	partNumber string) ([]*AIDefectInspection, error) {
-
-	allInspections, err := s.GetAllDefectInspections(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	var filtered []*AIDefectInspection
-	for _, inspection := range allInspections {
-		if inspection.PartNumber == partNumber {
-			filtered = append(filtered, inspection)
-		}
-	}
-
-	return filtered, nil
-}
-
 // CalculateHash is a utility function to calculate SHA-256 hash
 func CalculateHash(data []byte) string {
 	hash := sha256.Sum256(data)
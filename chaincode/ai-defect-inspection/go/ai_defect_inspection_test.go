@@ -0,0 +1,470 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// txSeconds is the transaction timestamp of the mock proposals (2025-10-20T08:00:00Z)
+const txSeconds = 1760947200
+
+// mockStub extends shimtest.MockStub with peer range query semantics
+type mockStub struct {
+	*shimtest.MockStub
+	events    map[string]*peer.ChaincodeEvent // chaincode event by transaction ID
+	parts     *mockPartRegistry
+	submitted bool // the proposal is submitted rather than evaluated, so paginated queries fail
+}
+
+func newMockStub() *mockStub {
+	stub := &mockStub{MockStub: shimtest.NewMockStub("aidefectinspection", nil), events: map[string]*peer.ChaincodeEvent{},
+		parts: &mockPartRegistry{statuses: map[string]string{}}}
+	stub.MockPeerChaincode(partRegistryChaincode, shimtest.NewMockStub(partRegistryChaincode, stub.parts), "")
+	// Proposals carry the sample inspector in the transient map, as the clients send it
+	stub.TransientMap = map[string][]byte{common.TransientPrivateKey: []byte(`{"inspector":"Dr. Smith"}`)}
+	stub.setTransaction("tx1", txSeconds)
+	return stub
+}
+
+// GetStateByRange excludes composite keys when the start key is empty, as on a peer. An empty end
+// key is open-ended.
+func (stub *mockStub) GetStateByRange(startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if startKey == "" {
+		startKey = "\x01"
+	}
+	if endKey == "" {
+		endKey = string(utf8.MaxRune)
+	}
+	return shimtest.NewMockStateRangeQueryIterator(stub.MockStub, startKey, endKey), nil
+}
+
+// GetStateByRangeWithPagination returns up to pageSize keys from bookmark (or startKey) with the next
+// key as bookmark, as on a peer with LevelDB
+func (stub *mockStub) GetStateByRangeWithPagination(startKey, endKey string, pageSize int32,
+	bookmark string) (shim.StateQueryIteratorInterface, *peer.QueryResponseMetadata, error) {
+
+	if stub.submitted {
+		return nil, nil, fmt.Errorf("paginated queries are only allowed in read-only transactions")
+	}
+	if bookmark != "" {
+		startKey = bookmark
+	}
+	iter, err := stub.GetStateByRange(startKey, endKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	page := &mockIterator{}
+	metadata := &peer.QueryResponseMetadata{}
+	for iter.HasNext() {
+		kv, _ := iter.Next()
+		if metadata.FetchedRecordsCount == pageSize {
+			metadata.Bookmark = kv.Key
+			break
+		}
+		page.results = append(page.results, kv)
+		metadata.FetchedRecordsCount++
+	}
+	return page, metadata, nil
+}
+
+// GetPrivateDataHash returns the SHA-256 hash of a private data value, as every peer of the channel holds it
+func (stub *mockStub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	value, ok := stub.PvtState[collection][key]
+	if !ok {
+		return nil, nil
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+// SetEvent records the chaincode event of the current transaction. As on a peer, a later call replaces it.
+func (stub *mockStub) SetEvent(name string, payload []byte) error {
+	stub.events[stub.TxID] = &peer.ChaincodeEvent{TxId: stub.TxID, EventName: name, Payload: payload}
+	return nil
+}
+
+// setTransaction starts a new mock transaction with the given ID and timestamp
+func (stub *mockStub) setTransaction(txID string, seconds int64) {
+	stub.TxID = txID
+	stub.TxTimestamp = &timestamp.Timestamp{Seconds: seconds}
+}
+
+type mockIterator struct {
+	results []*queryresult.KV
+}
+
+func (it *mockIterator) HasNext() bool { return len(it.results) > 0 }
+func (it *mockIterator) Close() error  { return nil }
+
+func (it *mockIterator) Next() (*queryresult.KV, error) {
+	kv := it.results[0]
+	it.results = it.results[1:]
+	return kv, nil
+}
+
+// mockIdentity is a client identity with a fixed MSP ID and NodeOU
+type mockIdentity struct {
+	mspID string
+	ou    string
+	attrs map[string]string
+	name  string // common name, the MSP ID if empty
+}
+
+func (id *mockIdentity) GetID() (string, error) {
+	if id.name != "" {
+		return "x509::CN=" + id.name, nil
+	}
+	return "x509::CN=" + id.mspID, nil
+}
+
+func (id *mockIdentity) GetMSPID() (string, error)                 { return id.mspID, nil }
+func (id *mockIdentity) AssertAttributeValue(string, string) error { return nil }
+func (id *mockIdentity) GetAttributeValue(name string) (string, bool, error) {
+	value, found := id.attrs[name]
+	return value, found, nil
+}
+func (id *mockIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return &x509.Certificate{Subject: pkix.Name{CommonName: id.mspID, OrganizationalUnit: []string{id.ou}}}, nil
+}
+
+func newContext(stub *mockStub, mspID string) contractapi.TransactionContextInterface {
+	return newContextWithOU(stub, mspID, "client")
+}
+
+func newAdminContext(stub *mockStub, mspID string) contractapi.TransactionContextInterface {
+	return newContextWithOU(stub, mspID, "admin")
+}
+
+// certifiedInspector holds the certificate attributes of a level 2 thermography inspector
+var certifiedInspector = map[string]string{"role": "inspector", "ndt.level": "2", "ndt.method": "thermography"}
+
+func newContextWithOU(stub *mockStub, mspID, ou string) contractapi.TransactionContextInterface {
+	return newContextWithAttrs(stub, mspID, ou, certifiedInspector)
+}
+
+func newContextWithAttrs(stub *mockStub, mspID, ou string, attrs map[string]string) contractapi.TransactionContextInterface {
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(&mockIdentity{mspID: mspID, ou: ou, attrs: attrs})
+	return ctx
+}
+
+// newNamedContext returns the context of a client with its own identity ID
+func newNamedContext(stub *mockStub, mspID, name string, attrs map[string]string) contractapi.TransactionContextInterface {
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(&mockIdentity{mspID: mspID, ou: "client", attrs: attrs, name: name})
+	return ctx
+}
+
+func sampleDefectInspection(serialNumber, submittedAt string) string {
+	return sampleModelInspection(serialNumber, "v1.0", 0.92, submittedAt)
+}
+
+func sampleModelInspection(serialNumber, modelVersion string, confidence float64, submittedAt string) string {
+	inspection := AIDefectInspection{
+		PartNumber:         "6A7614",
+		SerialNumber:       serialNumber,
+		MaterialType:       "CFRP",
+		InspectionDate:     "2025-10-20T07:30:00Z",
+		InspectionType:     "Active Thermography",
+		RawVideoHash:       strings.Repeat("a", 64),
+		RawVideoIPFS:       "QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG",
+		RawVideoSize:       52428800,
+		ProcessedImageHash: strings.Repeat("b", 64),
+		ProcessedImageIPFS: "QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o",
+		ROI_Y1:             10,
+		ROI_Y2:             470,
+		ROI_X1:             20,
+		ROI_X2:             620,
+		PulseTime:          3,
+		PCAComponents:      10,
+		SequenceLength:     500,
+		ModelName:          "cnn_attention_grdino",
+		ModelVersion:       modelVersion,
+		ModelHash:          strings.Repeat("c", 64),
+		DefectDetected:     true,
+		DefectType:         "thermal defect",
+		ConfidenceScore:    confidence,
+		BBox_X1:            120,
+		BBox_Y1:            80,
+		BBox_X2:            180,
+		BBox_Y2:            140,
+		SubmittedAt:        submittedAt,
+	}
+	inspectionJSON, _ := json.Marshal(inspection)
+	return string(inspectionJSON)
+}
+
+// endorser is the local environment of an endorsing peer, which must not affect its write set
+type endorser struct {
+	location *time.Location // time zone of the peer
+	locale   string         // LANG and LC_ALL of the peer process
+}
+
+// endorse simulates an endorsing peer executing the proposal and returns its write set
+func endorse(t *testing.T, peer endorser, mspID, inspectionJSON string) *mockStub {
+	defer func(local *time.Location) { time.Local = local }(time.Local)
+	time.Local = peer.location
+	t.Setenv("LANG", peer.locale)
+	t.Setenv("LC_ALL", peer.locale)
+
+	stub := newMockStub()
+	_, err := new(SmartContract).AddDefectInspection(newContext(stub, mspID), inspectionJSON)
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+	return stub
+}
+
+// expectIdenticalWrites fails unless two write sets hold the same keys with byte-identical values
+func expectIdenticalWrites(t *testing.T, name string, writes1, writes2 map[string][]byte) {
+	t.Helper()
+	if len(writes1) != len(writes2) {
+		t.Errorf("%s: expected the same keys, got %d and %d", name, len(writes1), len(writes2))
+	}
+	for key, value := range writes1 {
+		if !bytes.Equal(value, writes2[key]) {
+			t.Errorf("%s: records of %q differ:\n%s\n%s", name, key, value, writes2[key])
+		}
+	}
+}
+
+func TestEndorsersProduceIdenticalWriteSets(t *testing.T) {
+	peer1 := endorser{location: time.FixedZone("UTC-7", -7*60*60), locale: "en_US.UTF-8"}
+	peer2 := endorser{location: time.FixedZone("UTC+9", 9*60*60), locale: "ja_JP.UTF-8"}
+
+	proposals := []string{
+		sampleDefectInspection("BLADE-001", ""),
+		sampleDefectInspection("BLADE-001", "2025-10-20T09:58:00+02:00"),
+	}
+	var writes1 []*mockStub
+	for _, proposal := range proposals {
+		writes1 = append(writes1, endorse(t, peer1, "MROLabMSP", proposal))
+	}
+	// The second peer endorses the same proposals later, as with a skewed or slower clock
+	time.Sleep(time.Second)
+
+	for i, proposal := range proposals {
+		stub1, stub2 := writes1[i], endorse(t, peer2, "MROLabMSP", proposal)
+
+		if len(stub1.State) != 8 {
+			t.Errorf("expected the inspection event, latest inspection, submission and 5 index entries, got %d keys", len(stub1.State))
+		}
+		name := fmt.Sprintf("proposal %d", i)
+		expectIdenticalWrites(t, name+" public", stub1.State, stub2.State)
+		if len(stub1.PvtState) != len(stub2.PvtState) {
+			t.Errorf("%s: expected the same private collections, got %d and %d", name, len(stub1.PvtState), len(stub2.PvtState))
+		}
+		for collection, writes := range stub1.PvtState {
+			expectIdenticalWrites(t, name+" "+collection, writes, stub2.PvtState[collection])
+		}
+	}
+}
+
+func TestAddDefectInspectionTimestamps(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+
+	_, err := contract.AddDefectInspection(ctx, sampleDefectInspection("BLADE-001", "2025-10-20T09:58:00+02:00"))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+	_, err = contract.AddDefectInspection(ctx, sampleDefectInspection("BLADE-002", ""))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+
+	inspection, err := contract.GetDefectInspection(ctx, "BLADE-001")
+	if err != nil {
+		t.Fatalf("GetDefectInspection failed: %v", err)
+	}
+	if inspection.BlockchainTimestamp != "2025-10-20T08:00:00Z" || inspection.TxID != "tx1" {
+		t.Errorf("unexpected transaction metadata: %s %s", inspection.TxID, inspection.BlockchainTimestamp)
+	}
+	if inspection.SubmittedAt != "2025-10-20T07:58:00Z" {
+		t.Errorf("expected submittedAt normalized to UTC, got %s", inspection.SubmittedAt)
+	}
+
+	inspection, err = contract.GetDefectInspection(ctx, "BLADE-002")
+	if err != nil {
+		t.Fatalf("GetDefectInspection failed: %v", err)
+	}
+	if inspection.SubmittedAt != "2025-10-20T08:00:00Z" {
+		t.Errorf("expected submittedAt to default to the transaction timestamp, got %s", inspection.SubmittedAt)
+	}
+}
+
+func TestAddDefectInspectionRejectsInvalidSubmittedAt(t *testing.T) {
+	tests := map[string]string{
+		"2025-10-20 08:00:00":  "must be an RFC3339 timestamp",
+		"2025-10-20T08:06:00Z": "is more than 5m0s after the transaction timestamp",
+	}
+
+	for submittedAt, expected := range tests {
+		stub := newMockStub()
+		_, err := new(SmartContract).AddDefectInspection(newContext(stub, "MROLabMSP"), sampleDefectInspection("BLADE-001", submittedAt))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("submittedAt %q: expected error containing %q, got %v", submittedAt, expected, err)
+		}
+		if len(stub.State) != 0 {
+			t.Errorf("submittedAt %q: expected nothing written", submittedAt)
+		}
+	}
+
+	// Within the skew window
+	stub := newMockStub()
+	_, err := new(SmartContract).AddDefectInspection(newContext(stub, "MROLabMSP"), sampleDefectInspection("BLADE-001", "2025-10-20T08:04:59Z"))
+	if err != nil {
+		t.Errorf("expected submittedAt within the skew window to be accepted, got %v", err)
+	}
+}
+
+func TestAddDefectInspectionRequiresCertifiedInspector(t *testing.T) {
+	stub := newMockStub()
+	for _, attrs := range []map[string]string{
+		nil,
+		{"role": "viewer", "ndt.level": "2", "ndt.method": "thermography"},
+		{"role": "inspector", "ndt.level": "2", "ndt.method": "dimensional"},
+	} {
+		_, err := new(SmartContract).AddDefectInspection(newContextWithAttrs(stub, "MROLabMSP", "client", attrs),
+			sampleDefectInspection("BLADE-001", ""))
+		if err == nil || !strings.HasPrefix(err.Error(), "only ") {
+			t.Errorf("%v: expected the caller to be rejected, got %v", attrs, err)
+		}
+	}
+	if len(stub.State) != 0 {
+		t.Errorf("expected nothing written")
+	}
+}
+
+func TestDefectInspectionHistory(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	mroLab := newContext(stub, "MROLabMSP")
+
+	// Transaction IDs do not sort in submission order
+	runs := []struct {
+		txID       string
+		version    string
+		confidence float64
+	}{
+		{"f1", "v1.0", 0.71},
+		{"a2", "v1.1", 0.84},
+		{"c3", "v2.0", 0.93},
+	}
+	for i, run := range runs {
+		stub.setTransaction(run.txID, txSeconds+int64(i)*3600)
+		_, err := contract.AddDefectInspection(mroLab, sampleModelInspection("BLADE-001", run.version, run.confidence, ""))
+		if err != nil {
+			t.Fatalf("AddDefectInspection failed: %v", err)
+		}
+	}
+	stub.setTransaction("b4", txSeconds+4*3600)
+	_, err := contract.AddDefectInspection(mroLab, sampleDefectInspection("BLADE-002", ""))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+
+	latest, err := contract.GetDefectInspection(mroLab, "BLADE-001")
+	if err != nil {
+		t.Fatalf("GetDefectInspection failed: %v", err)
+	}
+	if latest.TxID != "c3" || latest.ModelVersion != "v2.0" || latest.Inspector != "Dr. Smith" {
+		t.Errorf("expected the latest inspection, got %s %s %q", latest.TxID, latest.ModelVersion, latest.Inspector)
+	}
+	if strings.Contains(string(stub.State["BLADE-001"]), "Dr. Smith") {
+		t.Errorf("public state contains the inspector: %s", stub.State["BLADE-001"])
+	}
+
+	history, err := contract.GetDefectInspectionHistory(mroLab, "BLADE-001")
+	if err != nil {
+		t.Fatalf("GetDefectInspectionHistory failed: %v", err)
+	}
+	if len(history) != len(runs) {
+		t.Fatalf("expected %d inspections, got %d", len(runs), len(history))
+	}
+	for i, run := range runs {
+		if history[i].TxID != run.txID || history[i].ModelVersion != run.version || history[i].ConfidenceScore != run.confidence {
+			t.Errorf("history[%d]: expected %s %s, got %s %s", i, run.txID, run.version, history[i].TxID, history[i].ModelVersion)
+		}
+		if history[i].Inspector != "Dr. Smith" {
+			t.Errorf("history[%d]: expected inspector, got %q", i, history[i].Inspector)
+		}
+	}
+
+	// The other organization sees the history without the inspector
+	history, err = contract.GetDefectInspectionHistory(newContext(stub, "ManufacturerMSP"), "BLADE-001")
+	if err != nil {
+		t.Fatalf("GetDefectInspectionHistory failed: %v", err)
+	}
+	if len(history) != len(runs) || history[0].Inspector != "" {
+		t.Errorf("expected %d inspections without inspector, got %d", len(runs), len(history))
+	}
+
+	all, err := contract.GetAllDefectInspections(mroLab)
+	if err != nil {
+		t.Fatalf("GetAllDefectInspections failed: %v", err)
+	}
+	if len(all) != 2 {
+		t.Errorf("expected the latest inspection of 2 serial numbers, got %d", len(all))
+	}
+
+	history, err = contract.GetDefectInspectionHistory(mroLab, "BLADE-999")
+	if err != nil || len(history) != 0 {
+		t.Errorf("expected empty history, got %d inspections (%v)", len(history), err)
+	}
+}
+
+func TestVerifyDefectInspectionPrivate(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	_, err := contract.AddDefectInspection(newContext(stub, "ManufacturerMSP"), sampleDefectInspection("BLADE-001", ""))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+
+	auditorCtx := newContext(stub, "MROLabMSP")
+	result, err := contract.VerifyDefectInspectionPrivate(auditorCtx, "BLADE-001", "tx1", `{"inspector":"Dr. Smith"}`)
+	if err != nil {
+		t.Fatalf("VerifyDefectInspectionPrivate failed: %v", err)
+	}
+	if !result.Match || result.Collection != "aiDefectPrivateManufacturerCollection" {
+		t.Errorf("expected the claim to match, got %+v", result)
+	}
+
+	result, err = contract.VerifyDefectInspectionPrivate(auditorCtx, "BLADE-001", "tx1", `{"inspector":"Dr. Jones"}`)
+	if err != nil || result.Match {
+		t.Errorf("expected the claim not to match, got %+v (%v)", result, err)
+	}
+
+	_, err = contract.VerifyDefectInspectionPrivate(auditorCtx, "BLADE-001", "tx2", `{"inspector":"Dr. Smith"}`)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected a missing inspection error, got %v", err)
+	}
+}
+
+func TestChaincodeMetadata(t *testing.T) {
+	if _, err := contractapi.NewChaincode(&SmartContract{}); err != nil {
+		t.Fatalf("failed to create chaincode: %v", err)
+	}
+}
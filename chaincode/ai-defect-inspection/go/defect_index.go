@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// Composite-key indexes over the latest inspection of each serial number. Index entries have an
+// empty value; the inspection is read from its SerialNumber key. Queries iterate only the matching
+// index range, so they scale with the number of results rather than the size of the ledger.
+const (
+	partIndex       = "defectPart"       // defectPart~PartNumber~SerialNumber
+	materialIndex   = "defectMaterial"   // defectMaterial~MaterialType~SerialNumber
+	modelIndex      = "defectModel"      // defectModel~ModelName~ModelVersion~SerialNumber
+	defectTypeIndex = "defectType"       // defectType~DefectType~SerialNumber (detected defects only)
+	confidenceIndex = "defectConfidence" // defectConfidence~InvertedConfidence~SerialNumber (detected defects only)
+)
+
+// confidenceScale is the resolution of confidence scores in the confidence index
+const confidenceScale = 1000000
+
+// indexValue is stored under every index key, since a nil value would delete the key
+var indexValue = []byte{0x00}
+
+// confidenceIndexKey encodes a confidence score so that keys sort from the highest to the lowest score
+func confidenceIndexKey(confidence float64) string {
+	scaled := int64(math.Round(confidence * confidenceScale))
+	if scaled < 0 {
+		scaled = 0
+	}
+	if scaled > confidenceScale {
+		scaled = confidenceScale
+	}
+	return fmt.Sprintf("%07d", confidenceScale-scaled)
+}
+
+// indexKeys returns the index keys of an inspection
+func indexKeys(ctx contractapi.TransactionContextInterface, publicData *AIDefectInspection) ([]string, error) {
+	serial := publicData.SerialNumber
+	entries := [][]string{
+		{partIndex, publicData.PartNumber, serial},
+		{materialIndex, publicData.MaterialType, serial},
+		{modelIndex, publicData.ModelName, publicData.ModelVersion, serial},
+	}
+	if publicData.DefectDetected {
+		entries = append(entries,
+			[]string{defectTypeIndex, publicData.DefectType, serial},
+			[]string{confidenceIndex, confidenceIndexKey(publicData.ConfidenceScore), serial},
+		)
+	}
+
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		key, err := common.CompositeKey(ctx.GetStub(), entry[0], entry[1:]...)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// updateIndexes replaces the index entries of the previous latest inspection (nil if none) with those of latest
+func updateIndexes(ctx contractapi.TransactionContextInterface, previous, latest *AIDefectInspection) error {
+	newKeys, err := indexKeys(ctx, latest)
+	if err != nil {
+		return err
+	}
+	keep := map[string]bool{}
+	for _, key := range newKeys {
+		keep[key] = true
+	}
+
+	if previous != nil {
+		oldKeys, err := indexKeys(ctx, previous)
+		if err != nil {
+			return err
+		}
+		for _, key := range oldKeys {
+			if keep[key] {
+				continue
+			}
+			err = ctx.GetStub().DelState(key)
+			if err != nil {
+				return fmt.Errorf("failed to delete index entry: %v", err)
+			}
+		}
+	}
+
+	for _, key := range newKeys {
+		err = ctx.GetStub().PutState(key, indexValue)
+		if err != nil {
+			return fmt.Errorf("failed to write index entry: %v", err)
+		}
+	}
+	return nil
+}
+
+// queryIndex returns the latest inspections of the serial numbers in an index range, in index order.
+// Iteration stops early when include returns false for a key's attributes.
+func queryIndex(ctx contractapi.TransactionContextInterface, index string, attributes []string,
+	include func(attributes []string) bool) ([]*AIDefectInspection, error) {
+
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(index, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s index: %v", index, err)
+	}
+	defer resultsIterator.Close()
+
+	privateCollectionName, err := getPrivateCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	inspections := []*AIDefectInspection{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate: %v", err)
+		}
+
+		_, keyAttributes, err := ctx.GetStub().SplitCompositeKey(queryResponse.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split index key: %v", err)
+		}
+		if include != nil && !include(keyAttributes) {
+			break
+		}
+
+		serialNumber := keyAttributes[len(keyAttributes)-1]
+		publicDataJSON, err := ctx.GetStub().GetState(serialNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read inspection %s: %v", serialNumber, err)
+		}
+		if publicDataJSON == nil {
+			return nil, fmt.Errorf("index entry for missing inspection %s", serialNumber)
+		}
+
+		var inspection AIDefectInspection
+		err = json.Unmarshal(publicDataJSON, &inspection)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal public data: %v", err)
+		}
+		readPrivateData(ctx, privateCollectionName, &inspection)
+		inspections = append(inspections, &inspection)
+	}
+
+	return inspections, nil
+}
+
+// QueryDefectsByConfidence returns the latest inspections with a detected defect at or above a
+// confidence threshold, highest confidence first
+func (s *SmartContract) QueryDefectsByConfidence(ctx contractapi.TransactionContextInterface,
+	minConfidence float64) ([]*AIDefectInspection, error) {
+
+	maxKey := confidenceIndexKey(minConfidence)
+	return queryIndex(ctx, confidenceIndex, []string{}, func(attributes []string) bool {
+		return attributes[0] <= maxKey
+	})
+}
+
+// GetInspectionsByPart returns the latest inspection of every serial number of a part number
+func (s *SmartContract) GetInspectionsByPart(ctx contractapi.TransactionContextInterface,
+	partNumber string) ([]*AIDefectInspection, error) {
+
+	return queryIndex(ctx, partIndex, []string{partNumber}, nil)
+}
+
+// GetInspectionsByMaterial returns the latest inspection of every serial number of a material type
+func (s *SmartContract) GetInspectionsByMaterial(ctx contractapi.TransactionContextInterface,
+	materialType string) ([]*AIDefectInspection, error) {
+
+	return queryIndex(ctx, materialIndex, []string{materialType}, nil)
+}
+
+// GetInspectionsByModel returns the latest inspections produced by an AI model. An empty model
+// version matches every version of the model.
+func (s *SmartContract) GetInspectionsByModel(ctx contractapi.TransactionContextInterface,
+	modelName string, modelVersion string) ([]*AIDefectInspection, error) {
+
+	attributes := []string{modelName}
+	if modelVersion != "" {
+		attributes = append(attributes, modelVersion)
+	}
+	return queryIndex(ctx, modelIndex, attributes, nil)
+}
+
+// GetInspectionsByDefectType returns the latest inspections that detected a defect type
+func (s *SmartContract) GetInspectionsByDefectType(ctx contractapi.TransactionContextInterface,
+	defectType string) ([]*AIDefectInspection, error) {
+
+	return queryIndex(ctx, defectTypeIndex, []string{defectType}, nil)
+}
+
+// RebuildDefectIndexes writes the index entries of every latest inspection. It is needed once for
+// inspections recorded before the indexes existed and returns the number of inspections indexed. It
+// rewrites every index entry, so only admins of active organizations can run it.
+func (s *SmartContract) RebuildDefectIndexes(ctx contractapi.TransactionContextInterface) (int, error) {
+	registry, err := common.LoadRegistry[registryDefaults](ctx)
+	if err != nil {
+		return 0, err
+	}
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get client certificate: %v", err)
+	}
+	if _, err := registry.Lookup(clientMSPID); err != nil || !common.IsAdmin(cert) {
+		return 0, fmt.Errorf("only admins of active organizations can rebuild the defect indexes")
+	}
+
+	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get state by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	count := 0
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return 0, fmt.Errorf("failed to iterate: %v", err)
+		}
+
+		var publicData AIDefectInspection
+		err = json.Unmarshal(queryResponse.Value, &publicData)
+		if err != nil {
+			continue
+		}
+
+		err = updateIndexes(ctx, nil, &publicData)
+		if err != nil {
+			return 0, err
+		}
+		count++
+	}
+
+	return count, nil
+}
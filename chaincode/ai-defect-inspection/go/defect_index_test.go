@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// addIndexedInspection records an inspection in its own transaction
+func addIndexedInspection(t *testing.T, stub *mockStub, tx int, update func(*AIDefectInspection)) {
+	t.Helper()
+
+	var inspection AIDefectInspection
+	_ = json.Unmarshal([]byte(sampleDefectInspection("", "")), &inspection)
+	update(&inspection)
+	inspectionJSON, _ := json.Marshal(inspection)
+
+	stub.setTransaction(fmt.Sprintf("tx%d", tx), txSeconds+int64(tx)*60)
+	_, err := new(SmartContract).AddDefectInspection(newContext(stub, "MROLabMSP"), string(inspectionJSON))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+}
+
+func serials(inspections []*AIDefectInspection) []string {
+	result := []string{}
+	for _, inspection := range inspections {
+		result = append(result, inspection.SerialNumber)
+	}
+	return result
+}
+
+func expectSerials(t *testing.T, name string, inspections []*AIDefectInspection, err error, expected ...string) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s failed: %v", name, err)
+	}
+	got := serials(inspections)
+	if len(got) != len(expected) {
+		t.Errorf("%s: expected %v, got %v", name, expected, got)
+		return
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("%s: expected %v, got %v", name, expected, got)
+			return
+		}
+	}
+}
+
+func TestIndexedQueries(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+
+	addIndexedInspection(t, stub, 0, func(i *AIDefectInspection) {
+		i.SerialNumber, i.PartNumber, i.MaterialType, i.ConfidenceScore = "SN-1", "6A7614", "CFRP", 0.95
+	})
+	addIndexedInspection(t, stub, 1, func(i *AIDefectInspection) {
+		i.SerialNumber, i.PartNumber, i.MaterialType, i.ConfidenceScore = "SN-2", "6A7614", "GFRP", 0.75
+		i.DefectType = "delamination"
+	})
+	addIndexedInspection(t, stub, 2, func(i *AIDefectInspection) {
+		i.SerialNumber, i.PartNumber, i.MaterialType, i.ConfidenceScore = "SN-3", "7B1234", "CFRP", 0.88
+		i.ModelVersion = "v2.0"
+	})
+	addIndexedInspection(t, stub, 3, func(i *AIDefectInspection) {
+		i.SerialNumber, i.PartNumber, i.MaterialType = "SN-4", "7B1234", "CFRP"
+		i.DefectDetected, i.DefectType, i.ConfidenceScore = false, "", 0.99
+	})
+
+	inspections, err := contract.QueryDefectsByConfidence(ctx, 0.8)
+	expectSerials(t, "QueryDefectsByConfidence", inspections, err, "SN-1", "SN-3")
+	if err == nil && inspections[0].Inspector != "Dr. Smith" {
+		t.Errorf("expected inspector from the private collection, got %q", inspections[0].Inspector)
+	}
+	inspections, err = contract.QueryDefectsByConfidence(ctx, 0.75)
+	expectSerials(t, "QueryDefectsByConfidence", inspections, err, "SN-1", "SN-3", "SN-2")
+	inspections, err = contract.QueryDefectsByConfidence(ctx, 0.96)
+	expectSerials(t, "QueryDefectsByConfidence", inspections, err)
+
+	inspections, err = contract.GetInspectionsByPart(ctx, "7B1234")
+	expectSerials(t, "GetInspectionsByPart", inspections, err, "SN-3", "SN-4")
+	inspections, err = contract.GetInspectionsByMaterial(ctx, "CFRP")
+	expectSerials(t, "GetInspectionsByMaterial", inspections, err, "SN-1", "SN-3", "SN-4")
+	inspections, err = contract.GetInspectionsByModel(ctx, "cnn_attention_grdino", "v1.0")
+	expectSerials(t, "GetInspectionsByModel", inspections, err, "SN-1", "SN-2", "SN-4")
+	inspections, err = contract.GetInspectionsByModel(ctx, "cnn_attention_grdino", "")
+	expectSerials(t, "GetInspectionsByModel", inspections, err, "SN-1", "SN-2", "SN-4", "SN-3")
+	inspections, err = contract.GetInspectionsByDefectType(ctx, "thermal defect")
+	expectSerials(t, "GetInspectionsByDefectType", inspections, err, "SN-1", "SN-3")
+
+	// A re-inspection replaces the index entries of the previous result
+	addIndexedInspection(t, stub, 4, func(i *AIDefectInspection) {
+		i.SerialNumber, i.PartNumber, i.MaterialType = "SN-1", "6A7614", "CFRP"
+		i.DefectDetected, i.DefectType, i.ConfidenceScore, i.ModelVersion = false, "", 0.97, "v2.0"
+	})
+
+	inspections, err = contract.QueryDefectsByConfidence(ctx, 0.8)
+	expectSerials(t, "QueryDefectsByConfidence after re-inspection", inspections, err, "SN-3")
+	inspections, err = contract.GetInspectionsByModel(ctx, "cnn_attention_grdino", "v2.0")
+	expectSerials(t, "GetInspectionsByModel after re-inspection", inspections, err, "SN-1", "SN-3")
+	inspections, err = contract.GetInspectionsByPart(ctx, "6A7614")
+	expectSerials(t, "GetInspectionsByPart after re-inspection", inspections, err, "SN-1", "SN-2")
+	if err == nil && inspections[0].ModelVersion != "v2.0" {
+		t.Errorf("expected the latest inspection, got model %s", inspections[0].ModelVersion)
+	}
+}
+
+func TestRebuildDefectIndexes(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+
+	addIndexedInspection(t, stub, 0, func(i *AIDefectInspection) { i.SerialNumber = "SN-1" })
+	addIndexedInspection(t, stub, 1, func(i *AIDefectInspection) { i.SerialNumber = "SN-2" })
+
+	// Drop the index entries, as for inspections recorded before the indexes existed
+	for _, index := range []string{partIndex, materialIndex, modelIndex, defectTypeIndex, confidenceIndex} {
+		iter, _ := stub.GetStateByPartialCompositeKey(index, []string{})
+		for iter.HasNext() {
+			kv, _ := iter.Next()
+			_ = stub.DelState(kv.Key)
+		}
+	}
+	inspections, err := contract.GetInspectionsByPart(ctx, "6A7614")
+	expectSerials(t, "GetInspectionsByPart without indexes", inspections, err)
+
+	// Rebuilding rewrites every index entry, so clients and unregistered admins are rejected
+	for _, caller := range []contractapi.TransactionContextInterface{ctx, newAdminContext(stub, "AirlineMSP")} {
+		_, err = contract.RebuildDefectIndexes(caller)
+		if err == nil || !strings.Contains(err.Error(), "only admins of active organizations") {
+			t.Errorf("expected the rebuild to be rejected, got %v", err)
+		}
+	}
+
+	count, err := contract.RebuildDefectIndexes(newAdminContext(stub, "MROLabMSP"))
+	if err != nil || count != 2 {
+		t.Fatalf("expected 2 inspections indexed, got %d (%v)", count, err)
+	}
+	inspections, err = contract.GetInspectionsByPart(ctx, "6A7614")
+	expectSerials(t, "GetInspectionsByPart after rebuild", inspections, err, "SN-1", "SN-2")
+}
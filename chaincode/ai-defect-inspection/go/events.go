@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// DefectInspectionEvent is the payload of the InspectionAdded and DefectDetected events emitted by
+// AddDefectInspection. It holds public fields only; the inspector stays in the org's private collection.
+type DefectInspectionEvent struct {
+	PartNumber      string  `json:"partNumber"`
+	SerialNumber    string  `json:"serialNumber"`
+	InspectionDate  string  `json:"inspectionDate"`
+	Organization    string  `json:"organization"`
+	ModelName       string  `json:"modelName"`
+	ModelVersion    string  `json:"modelVersion"`
+	DefectDetected  bool    `json:"defectDetected"`
+	DefectType      string  `json:"defectType"`
+	ConfidenceScore float64 `json:"confidenceScore"`
+	SubmissionID    string  `json:"submissionId"`
+	TxID            string  `json:"txID"`
+}
+
+// setInspectionEvent emits DefectDetected for an inspection that detected a defect and InspectionAdded
+// otherwise, with the same payload
+func setInspectionEvent(ctx contractapi.TransactionContextInterface, inspection *AIDefectInspection) error {
+	name := common.EventInspectionAdded
+	if inspection.DefectDetected {
+		name = common.EventDefectDetected
+	}
+
+	return common.SetEvent(ctx.GetStub(), name, &DefectInspectionEvent{
+		PartNumber:      inspection.PartNumber,
+		SerialNumber:    inspection.SerialNumber,
+		InspectionDate:  inspection.InspectionDate,
+		Organization:    inspection.Organization,
+		ModelName:       inspection.ModelName,
+		ModelVersion:    inspection.ModelVersion,
+		DefectDetected:  inspection.DefectDetected,
+		DefectType:      inspection.DefectType,
+		ConfidenceScore: inspection.ConfidenceScore,
+		SubmissionID:    inspection.SubmissionID,
+		TxID:            inspection.TxID,
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+func TestAddDefectInspectionEmitsEvents(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	mroLab := newContext(stub, "MROLabMSP")
+
+	_, err := contract.AddDefectInspection(mroLab, sampleDefectInspection("BLADE-001", ""))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+	event := stub.events["tx1"]
+	if event == nil || event.EventName != common.EventDefectDetected {
+		t.Fatalf("expected a DefectDetected event, got %v", event)
+	}
+	var payload DefectInspectionEvent
+	_ = json.Unmarshal(event.Payload, &payload)
+	if payload.SerialNumber != "BLADE-001" || !payload.DefectDetected || payload.DefectType == "" ||
+		payload.ConfidenceScore == 0 || payload.Organization != "MROLabMSP" || payload.TxID != "tx1" {
+		t.Errorf("unexpected payload %+v", payload)
+	}
+	// The inspector is private data
+	if strings.Contains(string(event.Payload), "Dr. Smith") {
+		t.Errorf("expected public fields only, got %s", event.Payload)
+	}
+
+	// An inspection without a defect is only added
+	stub.setTransaction("tx2", txSeconds+60)
+	_, err = contract.AddDefectInspection(mroLab, invalidInspection(map[string]interface{}{
+		"serialNumber": "BLADE-002", "defectDetected": false, "defectType": "",
+	}))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+	if event := stub.events["tx2"]; event == nil || event.EventName != common.EventInspectionAdded {
+		t.Errorf("expected an InspectionAdded event, got %v", event)
+	}
+
+	// A resubmission writes nothing and emits nothing
+	stub.setTransaction("tx3", txSeconds+120)
+	_, err = contract.AddDefectInspection(mroLab, sampleDefectInspection("BLADE-001", ""))
+	if err != nil {
+		t.Fatalf("resubmission failed: %v", err)
+	}
+	if event := stub.events["tx3"]; event != nil {
+		t.Errorf("expected no event for a resubmission, got %s", event.EventName)
+	}
+}
@@ -0,0 +1,22 @@
+package main
+
+import "github.com/mahmoudhafez3/thermotrace/chaincode/common"
+
+// defaultOrgs is the organization registry the chaincode was deployed with. It applies until an
+// admin first registers or deactivates an organization; from then on the registry is read from
+// world state. The collections several organizations read cannot become an organization's private
+// collection.
+var defaultOrgs = common.NewOrgRegistry(
+	common.Organization{MSPID: "ManufacturerMSP", Role: common.RoleManufacturer, PrivateCollection: "aiDefectPrivateManufacturerCollection"},
+	common.Organization{MSPID: "MROLabMSP", Role: common.RoleMRO, PrivateCollection: "aiDefectPrivateMROLabCollection"},
+).WithSharedCollections("aiDefectPublicCollection")
+
+// defaultLinks names the chaincodes called on the channel until the organizations link others
+var defaultLinks = map[string]string{common.LinkPartRegistry: partRegistryChaincode}
+
+// registryDefaults supplies defaultOrgs and defaultLinks to the registry transactions of
+// common.RegistryContract, which SmartContract embeds
+type registryDefaults struct{}
+
+func (registryDefaults) Organizations() *common.OrgRegistry { return defaultOrgs }
+func (registryDefaults) Links() map[string]string           { return defaultLinks }
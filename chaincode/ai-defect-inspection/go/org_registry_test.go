@@ -0,0 +1,72 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestOrganizationRegistry(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	_, err := contract.AddDefectInspection(newContext(stub, "AirlineMSP"), sampleDefectInspection("BLADE-001", ""))
+	if err == nil || !strings.Contains(err.Error(), "organization AirlineMSP is not registered") {
+		t.Fatalf("expected an unregistered organization to be rejected, got %v", err)
+	}
+
+	_, err = contract.RegisterOrganization(newContext(stub, "ManufacturerMSP"), "AirlineMSP", "operator", "aiDefectPrivateAirlineCollection")
+	if err == nil || !strings.Contains(err.Error(), "only admins") {
+		t.Errorf("expected a client to be rejected, got %v", err)
+	}
+	// A new organization needs the admins of a majority of the active organizations
+	change, err := contract.RegisterOrganization(newAdminContext(stub, "MROLabMSP"), "AirlineMSP", "operator", "aiDefectPrivateAirlineCollection")
+	if err != nil || change.Applied {
+		t.Fatalf("expected the registration to be pending, got %+v (%v)", change, err)
+	}
+	change, err = contract.RegisterOrganization(newAdminContext(stub, "ManufacturerMSP"), "AirlineMSP", "operator", "aiDefectPrivateAirlineCollection")
+	if err != nil || !change.Applied {
+		t.Fatalf("expected the registration to be applied, got %+v (%v)", change, err)
+	}
+	if org := change.Organization; !org.Active || org.UpdatedBy != "x509::CN=ManufacturerMSP" || org.UpdatedAt != "2025-10-20T08:00:00Z" {
+		t.Errorf("unexpected organization %+v", org)
+	}
+
+	stub.setTransaction("tx2", txSeconds+60)
+	_, err = contract.AddDefectInspection(newContext(stub, "AirlineMSP"), sampleDefectInspection("BLADE-001", ""))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+	inspection, err := contract.GetDefectInspection(newContext(stub, "AirlineMSP"), "BLADE-001")
+	if err != nil || inspection.Inspector != "Dr. Smith" {
+		t.Errorf("expected the airline to read its inspector, got %+v (%v)", inspection, err)
+	}
+
+	// Inspections listed by every registered organization skip the registry entry
+	all, err := contract.GetAllDefectInspections(newContext(stub, "ManufacturerMSP"))
+	if err != nil || len(all) != 1 {
+		t.Errorf("expected 1 inspection, got %d (%v)", len(all), err)
+	}
+
+	stub.setTransaction("tx3", txSeconds+120)
+	// One admin of three organizations cannot deactivate another organization
+	change, err = contract.DeactivateOrganization(newAdminContext(stub, "ManufacturerMSP"), "AirlineMSP")
+	if err != nil || change.Applied || change.Required != 2 {
+		t.Fatalf("expected the deactivation to be pending, got %+v (%v)", change, err)
+	}
+	if _, err = contract.GetDefectInspection(newContext(stub, "AirlineMSP"), "BLADE-001"); err != nil {
+		t.Errorf("expected AirlineMSP to stay active, got %v", err)
+	}
+	change, err = contract.DeactivateOrganization(newAdminContext(stub, "MROLabMSP"), "AirlineMSP")
+	if err != nil || !change.Applied {
+		t.Fatalf("expected the deactivation to be applied, got %+v (%v)", change, err)
+	}
+	_, err = contract.GetDefectInspection(newContext(stub, "AirlineMSP"), "BLADE-001")
+	if err == nil || !strings.Contains(err.Error(), "organization AirlineMSP is deactivated") {
+		t.Errorf("expected the deactivated organization to be rejected, got %v", err)
+	}
+
+	orgs, err := contract.GetOrganizations(newContext(stub, "MROLabMSP"))
+	if err != nil || len(orgs) != 3 || orgs[0].MSPID != "AirlineMSP" || orgs[0].Active {
+		t.Errorf("unexpected organizations %+v (%v)", orgs, err)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxPageSize limits the number of records returned by a single page query
+const maxPageSize = 100
+
+// DefectInspectionPage is one page of latest AI defect inspections. Bookmark is passed to the next
+// call and is empty on the last page.
+type DefectInspectionPage struct {
+	Records      []*AIDefectInspection `json:"records"`
+	Bookmark     string                `json:"bookmark"`
+	FetchedCount int32                 `json:"fetchedCount"`
+}
+
+// GetDefectInspectionsPage returns up to pageSize latest AI defect inspections, starting at bookmark
+// ("" for the first page). Fabric only allows paginated queries in evaluated (read-only) transactions,
+// so a submitted transaction calling it fails.
+func (s *SmartContract) GetDefectInspectionsPage(ctx contractapi.TransactionContextInterface,
+	pageSize int32, bookmark string) (*DefectInspectionPage, error) {
+
+	if pageSize < 1 || pageSize > maxPageSize {
+		return nil, fmt.Errorf("page size must be between 1 and %d", maxPageSize)
+	}
+
+	privateCollectionName, err := getPrivateCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty start key excludes the composite keys of inspection events and indexes
+	resultsIterator, metadata, err := ctx.GetStub().GetStateByRangeWithPagination("", "", pageSize, bookmark)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state by range: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	page := &DefectInspectionPage{Records: []*AIDefectInspection{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate: %v", err)
+		}
+
+		var inspection AIDefectInspection
+		err = json.Unmarshal(queryResponse.Value, &inspection)
+		if err != nil {
+			continue
+		}
+
+		readPrivateData(ctx, privateCollectionName, &inspection)
+		page.Records = append(page.Records, &inspection)
+	}
+
+	page.FetchedCount = metadata.FetchedRecordsCount
+	// The last page has fewer records than requested; a full page may still be followed by an empty one
+	if page.FetchedCount == pageSize {
+		page.Bookmark = metadata.Bookmark
+	}
+
+	return page, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestGetDefectInspectionsPage(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	for i := 0; i < 5; i++ {
+		addIndexedInspection(t, stub, i, func(inspection *AIDefectInspection) {
+			inspection.SerialNumber = fmt.Sprintf("SN-%d", i)
+		})
+	}
+	ctx := newContext(stub, "MROLabMSP")
+
+	var serials []string
+	var counts []int32
+	bookmark := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("pagination did not terminate")
+		}
+		page, err := contract.GetDefectInspectionsPage(ctx, 2, bookmark)
+		if err != nil {
+			t.Fatalf("GetDefectInspectionsPage failed: %v", err)
+		}
+		counts = append(counts, page.FetchedCount)
+		for _, inspection := range page.Records {
+			serials = append(serials, inspection.SerialNumber)
+			if inspection.Inspector != "Dr. Smith" {
+				t.Errorf("expected inspector for %s, got %q", inspection.SerialNumber, inspection.Inspector)
+			}
+		}
+
+		if page.Bookmark == "" {
+			break
+		}
+		bookmark = page.Bookmark
+	}
+
+	if fmt.Sprint(counts) != "[2 2 1]" {
+		t.Errorf("expected pages of [2 2 1], got %v", counts)
+	}
+	if fmt.Sprint(serials) != "[SN-0 SN-1 SN-2 SN-3 SN-4]" {
+		t.Errorf("unexpected serial numbers %v", serials)
+	}
+
+	for _, pageSize := range []int32{0, maxPageSize + 1} {
+		if _, err := contract.GetDefectInspectionsPage(ctx, pageSize, ""); err == nil {
+			t.Errorf("expected page size %d to be rejected", pageSize)
+		}
+	}
+}
+
+func TestGetAllDefectInspectionsReadsEveryPage(t *testing.T) {
+	stub := newMockStub()
+	for i := 0; i < maxPageSize*2+3; i++ {
+		addIndexedInspection(t, stub, i, func(inspection *AIDefectInspection) {
+			inspection.SerialNumber = fmt.Sprintf("SN-%03d", i)
+		})
+	}
+
+	all, err := new(SmartContract).GetAllDefectInspections(newContext(stub, "ManufacturerMSP"))
+	if err != nil {
+		t.Fatalf("GetAllDefectInspections failed: %v", err)
+	}
+	if len(all) != maxPageSize*2+3 {
+		t.Errorf("expected %d inspections, got %d", maxPageSize*2+3, len(all))
+	}
+
+	// Both list functions use paginated queries, which Fabric only allows in evaluated transactions
+	stub.submitted = true
+	_, err = new(SmartContract).GetAllDefectInspections(newContext(stub, "ManufacturerMSP"))
+	if err == nil || !strings.Contains(err.Error(), "read-only") {
+		t.Errorf("expected GetAllDefectInspections to fail in a submitted transaction, got %v", err)
+	}
+}
+
+func TestListDefectInspectionsRequiresRegisteredOrganization(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	_, err := contract.AddDefectInspection(newContext(stub, "ManufacturerMSP"), sampleDefectInspection("BLADE-001", ""))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+
+	ctx := newContext(stub, "AirlineMSP")
+	_, err = contract.GetDefectInspectionsPage(ctx, maxPageSize, "")
+	if err == nil || !strings.Contains(err.Error(), "organization AirlineMSP is not registered") {
+		t.Errorf("expected GetDefectInspectionsPage to reject an unregistered organization, got %v", err)
+	}
+	_, err = contract.GetAllDefectInspections(ctx)
+	if err == nil || !strings.Contains(err.Error(), "organization AirlineMSP is not registered") {
+		t.Errorf("expected GetAllDefectInspections to reject an unregistered organization, got %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// partRegistryChaincode is the default name of the chaincode on the same channel that keeps the part
+// registry (see LinkChaincode)
+const partRegistryChaincode = "bladeinspection"
+
+// checkPartInspectable rejects inspections of parts that the part registry does not hold or that have
+// been scrapped. The registry is read from the chaincode linked as "partRegistry", so it must be
+// installed on the endorsing peers; if it cannot be called, inspections are rejected.
+func checkPartInspectable(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) error {
+	chaincodeName, err := common.LinkedChaincodeName[registryDefaults](ctx, common.LinkPartRegistry)
+	if err != nil {
+		return err
+	}
+	response := ctx.GetStub().InvokeChaincode(chaincodeName,
+		[][]byte{[]byte("GetPart"), []byte(partNumber), []byte(serialNumber)}, "")
+	if response.Status != shim.OK {
+		return fmt.Errorf("failed to look up part in %s: %s", chaincodeName, response.Message)
+	}
+
+	var part common.Part
+	err = json.Unmarshal(response.Payload, &part)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal part: %v", err)
+	}
+	return common.CheckInspectable(&part, partNumber, serialNumber)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// mockPartRegistry answers GetPart like the bladeinspection chaincode. Parts are registered in service
+// unless a test sets their status by serial number; an empty status means the part is not registered.
+type mockPartRegistry struct {
+	statuses map[string]string
+	failure  string // error returned for every call, as by a chaincode that cannot be launched
+}
+
+func (cc *mockPartRegistry) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (cc *mockPartRegistry) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	if cc.failure != "" {
+		return shim.Error(cc.failure)
+	}
+	function, args := stub.GetFunctionAndParameters()
+	if function != "GetPart" || len(args) != 2 {
+		return shim.Error("unexpected call " + function)
+	}
+	status, ok := cc.statuses[args[1]]
+	if !ok {
+		status = common.PartInService
+	}
+	if status == "" {
+		return shim.Error("part " + args[0] + " " + args[1] + " is not registered")
+	}
+	partJSON, _ := json.Marshal(common.Part{PartNumber: args[0], SerialNumber: args[1], Status: status})
+	return shim.Success(partJSON)
+}
+
+func TestAddDefectInspectionRequiresInspectablePart(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+	stub.parts.statuses["BLADE-001"] = ""
+	stub.parts.statuses["BLADE-002"] = common.PartScrapped
+	stub.parts.statuses["BLADE-003"] = common.PartQuarantined
+
+	_, err := contract.AddDefectInspection(ctx, sampleDefectInspection("BLADE-001", ""))
+	if err == nil || !strings.Contains(err.Error(), "part 6A7614 BLADE-001 is not registered") {
+		t.Errorf("expected an unregistered part to be rejected, got %v", err)
+	}
+	_, err = contract.AddDefectInspection(ctx, sampleDefectInspection("BLADE-002", ""))
+	if err == nil || !strings.Contains(err.Error(), "part 6A7614 BLADE-002 is scrapped") {
+		t.Errorf("expected a scrapped part to be rejected, got %v", err)
+	}
+	if _, err = contract.AddDefectInspection(ctx, sampleDefectInspection("BLADE-003", "")); err != nil {
+		t.Errorf("expected a quarantined part to be inspectable, got %v", err)
+	}
+	if stub.State["BLADE-001"] != nil || stub.State["BLADE-002"] != nil {
+		t.Error("expected nothing to be written for rejected parts")
+	}
+}
+
+func TestAddDefectInspectionRequiresReachablePartRegistry(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+
+	stub.parts.failure = "chaincode bladeinspection not found"
+	_, err := contract.AddDefectInspection(ctx, sampleDefectInspection("BLADE-001", ""))
+	if err == nil || err.Error() != "failed to look up part in bladeinspection: chaincode bladeinspection not found" {
+		t.Errorf("expected a failed lookup to reject the inspection, got %v", err)
+	}
+	if stub.State["BLADE-001"] != nil {
+		t.Error("expected nothing to be written when the part registry cannot be called")
+	}
+
+	// The organizations link the registry under the name it is deployed with
+	renamed := &mockPartRegistry{statuses: map[string]string{}}
+	stub.MockPeerChaincode("partregistry", shimtest.NewMockStub("partregistry", renamed), "")
+	for _, mspID := range []string{"ManufacturerMSP", "MROLabMSP"} {
+		if _, err = contract.LinkChaincode(newAdminContext(stub, mspID), common.LinkPartRegistry, "partregistry"); err != nil {
+			t.Fatalf("LinkChaincode failed: %v", err)
+		}
+	}
+	if name, _ := contract.GetLinkedChaincode(ctx, common.LinkPartRegistry); name != "partregistry" {
+		t.Errorf("expected the part registry to be linked as partregistry, got %s", name)
+	}
+	if _, err = contract.AddDefectInspection(ctx, sampleDefectInspection("BLADE-001", "")); err != nil {
+		t.Errorf("expected the linked part registry to be called, got %v", err)
+	}
+}
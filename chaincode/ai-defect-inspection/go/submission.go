@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// deriveSubmissionID identifies a submission by the inputs of the AI run: the serial number, the video
+// and image it analysed and the model. Rerunning the same inputs yields the same ID.
+func deriveSubmissionID(inspection *AIDefectInspection) string {
+	return common.DeriveSubmissionID(inspection.SerialNumber, inspection.RawVideoHash, inspection.ProcessedImageHash,
+		inspection.ModelName, inspection.ModelVersion)
+}
+
+// submissionContentHash hashes the public content of a submission. Fields set by the chaincode, the
+// requested status and the submission time, which a client stamps on every attempt, are left out. The
+// private inspector is left out so that the hash, which every org can read, discloses nothing about it.
+func submissionContentHash(inspection *AIDefectInspection) (string, error) {
+	content := *inspection
+	content.Organization, content.TxID, content.BlockchainTimestamp, content.SubmittedAt = "", "", "", ""
+	content.Status, content.StatusHistory = "", nil
+	contentJSON, err := common.MarshalPublic(&content)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal submission content: %v", err)
+	}
+	return common.ContentHash(contentJSON), nil
+}
+
+// checkSubmission looks up the caller's submission ID, deriving it if the inspection has none. It returns
+// the inspection created by an identical earlier submission, or the submission to record for a new ID,
+// and fails with a *common.SubmissionConflictError if the ID was used for different content.
+func checkSubmission(ctx contractapi.TransactionContextInterface, inspection *AIDefectInspection) (*common.Submission, *AIDefectInspection, error) {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get MSP ID: %v", err)
+	}
+	privateCollectionName, err := getPrivateCollectionName(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if inspection.SubmissionID == "" {
+		inspection.SubmissionID = deriveSubmissionID(inspection)
+	}
+	contentHash, err := submissionContentHash(inspection)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	submissionKey, err := common.SubmissionKey(ctx.GetStub(), mspID, inspection.SubmissionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	recordJSON, err := ctx.GetStub().GetState(submissionKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read submission: %v", err)
+	}
+	recorded, err := common.MatchSubmission(recordJSON, inspection.SubmissionID, contentHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if recorded == nil {
+		return &common.Submission{SubmissionID: inspection.SubmissionID, ContentHash: contentHash}, nil, nil
+	}
+
+	publicDataJSON, err := ctx.GetStub().GetState(recorded.RecordKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read public data: %v", err)
+	}
+	if publicDataJSON == nil {
+		return nil, nil, fmt.Errorf("inspection of submission %s does not exist", inspection.SubmissionID)
+	}
+	var existing AIDefectInspection
+	err = json.Unmarshal(publicDataJSON, &existing)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal public data: %v", err)
+	}
+	readPrivateData(ctx, privateCollectionName, &existing)
+	return nil, &existing, nil
+}
+
+// putSubmission records a new submission as having created the inspection stored under recordKey
+func putSubmission(ctx contractapi.TransactionContextInterface, submission *common.Submission, recordKey string) error {
+	mspID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get MSP ID: %v", err)
+	}
+	submissionKey, err := common.SubmissionKey(ctx.GetStub(), mspID, submission.SubmissionID)
+	if err != nil {
+		return err
+	}
+
+	submission.RecordKey = recordKey
+	submission.TxID = ctx.GetStub().GetTxID()
+	submissionJSON, err := json.Marshal(submission)
+	if err != nil {
+		return fmt.Errorf("failed to marshal submission: %v", err)
+	}
+	err = ctx.GetStub().PutState(submissionKey, submissionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to put submission: %v", err)
+	}
+	return nil
+}
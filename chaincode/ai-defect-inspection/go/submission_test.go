@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// withSubmissionID returns the sample inspection submitted under an explicit submission ID
+func withSubmissionID(inspectionJSON, submissionID string) string {
+	var inspection AIDefectInspection
+	_ = json.Unmarshal([]byte(inspectionJSON), &inspection)
+	inspection.SubmissionID = submissionID
+	b, _ := json.Marshal(inspection)
+	return string(b)
+}
+
+func TestAddDefectInspectionIsIdempotent(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	mroLab := newContext(stub, "MROLabMSP")
+
+	first, err := contract.AddDefectInspection(mroLab, sampleDefectInspection("BLADE-001", "2025-10-20T07:59:00Z"))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+	if len(first.SubmissionID) != 64 {
+		t.Errorf("expected a derived submission ID, got %q", first.SubmissionID)
+	}
+	keys := len(stub.State)
+
+	// A retry stamps a new submission time, but submits the same content
+	stub.setTransaction("tx2", txSeconds+60)
+	again, err := contract.AddDefectInspection(mroLab, sampleDefectInspection("BLADE-001", "2025-10-20T08:00:30Z"))
+	if err != nil {
+		t.Fatalf("resubmission failed: %v", err)
+	}
+	if again.TxID != "tx1" || again.Inspector != "Dr. Smith" || again.SubmittedAt != first.SubmittedAt {
+		t.Errorf("expected the existing inspection, got %+v", again)
+	}
+	if len(stub.State) != keys {
+		t.Errorf("expected the resubmission to write nothing")
+	}
+	history, _ := contract.GetDefectInspectionHistory(mroLab, "BLADE-001")
+	if len(history) != 1 {
+		t.Errorf("expected a single inspection, got %d", len(history))
+	}
+
+	// Another organization's submission IDs are separate
+	stub.setTransaction("tx3", txSeconds+120)
+	other, err := contract.AddDefectInspection(newContext(stub, "ManufacturerMSP"), sampleDefectInspection("BLADE-001", ""))
+	if err != nil || other.TxID != "tx3" {
+		t.Errorf("expected a new inspection for ManufacturerMSP, got %+v (%v)", other, err)
+	}
+}
+
+func TestAddDefectInspectionRejectsSubmissionConflicts(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	mroLab := newContext(stub, "MROLabMSP")
+
+	_, err := contract.AddDefectInspection(mroLab, withSubmissionID(sampleDefectInspection("BLADE-001", ""), "run-42"))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+	_, err = contract.AddDefectInspection(mroLab, sampleDefectInspection("BLADE-002", ""))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+
+	stub.setTransaction("tx2", txSeconds+60)
+	for name, inspectionJSON := range map[string]string{
+		"explicit ID": withSubmissionID(sampleDefectInspection("BLADE-003", ""), "run-42"),
+		"derived ID":  sampleModelInspection("BLADE-002", "v1.0", 0.5, ""),
+	} {
+		_, err = contract.AddDefectInspection(mroLab, inspectionJSON)
+		var conflict *common.SubmissionConflictError
+		if !errors.As(err, &conflict) || conflict.Code != common.CodeSubmissionConflict || conflict.ExistingTxID != "tx1" {
+			t.Errorf("%s: expected a submission conflict, got %v", name, err)
+		}
+	}
+
+	// A new submission ID for the same content is a new inspection
+	_, err = contract.AddDefectInspection(mroLab, withSubmissionID(sampleDefectInspection("BLADE-001", ""), "run-43"))
+	if err != nil {
+		t.Errorf("expected a new submission ID to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// inspectionSchemaJSON is the JSON Schema of AddDefectInspection's input, published for clients
+//
+//go:embed schemas/ai_defect_inspection.schema.json
+var inspectionSchemaJSON []byte
+
+var inspectionSchema = common.MustCompileSchema(inspectionSchemaJSON)
+
+// parseInspection validates an inspection's JSON against the schema and then the rules the schema cannot
+// express: no private fields, hash and CID formats, RFC3339 dates, and the ROI and bounding box geometry.
+// Invalid input is rejected with a *common.ValidationError listing every field error.
+func parseInspection(inspectionJSON string) (*AIDefectInspection, error) {
+	var errs common.FieldErrors
+	errs.CheckNoPrivateFields([]byte(inspectionJSON), &AIDefectInspection{})
+	errs = append(errs, inspectionSchema.Validate([]byte(inspectionJSON))...)
+	if len(errs) > 0 {
+		return nil, errs.Err()
+	}
+
+	var inspection AIDefectInspection
+	err := json.Unmarshal([]byte(inspectionJSON), &inspection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse inspection JSON: %v", err)
+	}
+
+	errs.CheckRFC3339("inspectionDate", inspection.InspectionDate)
+	if inspection.SubmittedAt != "" {
+		errs.CheckRFC3339("submittedAt", inspection.SubmittedAt)
+	}
+	errs.CheckSHA256("rawVideoHash", inspection.RawVideoHash)
+	errs.CheckSHA256("processedImageHash", inspection.ProcessedImageHash)
+	if inspection.ModelHash != "" {
+		errs.CheckSHA256("modelHash", inspection.ModelHash)
+	}
+	errs.CheckCID("rawVideoIPFS", inspection.RawVideoIPFS)
+	errs.CheckCID("processedImageIPFS", inspection.ProcessedImageIPFS)
+
+	roiValid := true
+	if inspection.ROI_Y1 >= inspection.ROI_Y2 {
+		errs.Add("roi_y2", common.CodeOrder, fmt.Sprintf("must be greater than roi_y1 (%d)", inspection.ROI_Y1))
+		roiValid = false
+	}
+	if inspection.ROI_X1 >= inspection.ROI_X2 {
+		errs.Add("roi_x2", common.CodeOrder, fmt.Sprintf("must be greater than roi_x1 (%d)", inspection.ROI_X1))
+		roiValid = false
+	}
+
+	if inspection.DefectDetected {
+		if inspection.DefectType == "" {
+			errs.Add("defectType", common.CodeRequired, "is required when a defect is detected")
+		}
+		checkBox(&errs, "bbox", &inspection, roiValid,
+			inspection.BBox_X1, inspection.BBox_Y1, inspection.BBox_X2, inspection.BBox_Y2)
+	}
+	if inspection.HasGroundTruth {
+		checkBox(&errs, "gt_bbox", &inspection, roiValid,
+			inspection.GT_BBox_X1, inspection.GT_BBox_Y1, inspection.GT_BBox_X2, inspection.GT_BBox_Y2)
+	}
+
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	return &inspection, nil
+}
+
+// checkBox records errors unless a bounding box (prefix_x1 ... prefix_y2, in full-frame pixels) is
+// ordered and, when the ROI itself is valid, lies inside the ROI
+func checkBox(errs *common.FieldErrors, prefix string, inspection *AIDefectInspection, roiValid bool, x1, y1, x2, y2 float64) {
+	if x1 >= x2 {
+		errs.Add(prefix+"_x2", common.CodeOrder, fmt.Sprintf("must be greater than %s_x1 (%g)", prefix, x1))
+	}
+	if y1 >= y2 {
+		errs.Add(prefix+"_y2", common.CodeOrder, fmt.Sprintf("must be greater than %s_y1 (%g)", prefix, y1))
+	}
+	if !roiValid {
+		return
+	}
+
+	xMin, xMax := float64(inspection.ROI_X1), float64(inspection.ROI_X2)
+	yMin, yMax := float64(inspection.ROI_Y1), float64(inspection.ROI_Y2)
+	for _, coordinate := range []struct {
+		name     string
+		value    float64
+		min, max float64
+	}{
+		{"_x1", x1, xMin, xMax}, {"_x2", x2, xMin, xMax},
+		{"_y1", y1, yMin, yMax}, {"_y2", y2, yMin, yMax},
+	} {
+		if coordinate.value < coordinate.min || coordinate.value > coordinate.max {
+			errs.Add(prefix+coordinate.name, common.CodeOutOfBounds,
+				fmt.Sprintf("must be inside the ROI (%g to %g)", coordinate.min, coordinate.max))
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// invalidInspection returns the sample inspection with changes applied to its JSON fields
+func invalidInspection(changes map[string]interface{}) string {
+	var fields map[string]interface{}
+	_ = json.Unmarshal([]byte(sampleDefectInspection("BLADE-001", "")), &fields)
+	for field, value := range changes {
+		if value == nil {
+			delete(fields, field)
+		} else {
+			fields[field] = value
+		}
+	}
+	inspectionJSON, _ := json.Marshal(fields)
+	return string(inspectionJSON)
+}
+
+// expectFieldErrors checks that err is a validation error with exactly the expected field codes
+func expectFieldErrors(t *testing.T, name string, err error, expected map[string]string) {
+	t.Helper()
+	var validationErr *common.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("%s: expected a validation error, got %v", name, err)
+	}
+	got := map[string]string{}
+	for _, fieldError := range validationErr.Errors {
+		got[fieldError.Field] = fieldError.Code
+	}
+	if len(got) != len(expected) {
+		t.Errorf("%s: expected %v, got %v", name, expected, got)
+	}
+	for field, code := range expected {
+		if got[field] != code {
+			t.Errorf("%s: expected %s on %s, got %v", name, code, field, got)
+		}
+	}
+}
+
+func TestAddDefectInspectionValidatesSchema(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	_, err := contract.AddDefectInspection(newContext(stub, "MROLabMSP"), invalidInspection(map[string]interface{}{
+		"serialNumber":    nil,
+		"confidenceScore": 1.2,
+		"iou":             -0.1,
+		"roi_x1":          "20",
+		"operator":        "x",
+	}))
+	expectFieldErrors(t, "schema", err, map[string]string{
+		"serialNumber":    "required",
+		"confidenceScore": "number_lte",
+		"iou":             "number_gte",
+		"roi_x1":          "invalid_type",
+		"operator":        "additional_property_not_allowed",
+	})
+	if len(stub.State) != 0 {
+		t.Errorf("expected nothing written")
+	}
+
+	// The message is JSON, so clients can parse the field errors
+	var decoded common.ValidationError
+	if err := json.Unmarshal([]byte(err.Error()), &decoded); err != nil || decoded.Code != common.CodeValidationFailed {
+		t.Errorf("expected a JSON error message, got %s", err)
+	}
+
+	_, err = contract.AddDefectInspection(newContext(stub, "MROLabMSP"), "not json")
+	if err == nil || !strings.Contains(err.Error(), "invalid_json") {
+		t.Errorf("expected a document error, got %v", err)
+	}
+}
+
+func TestAddDefectInspectionValidatesFormats(t *testing.T) {
+	_, err := new(SmartContract).AddDefectInspection(newContext(newMockStub(), "MROLabMSP"), invalidInspection(map[string]interface{}{
+		"rawVideoHash":       "sha256:" + strings.Repeat("a", 64),
+		"processedImageHash": strings.Repeat("g", 64),
+		"modelHash":          "",
+		"rawVideoIPFS":       "ipfs://QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG",
+		"inspectionDate":     "2025-10-20",
+	}))
+	expectFieldErrors(t, "formats", err, map[string]string{
+		"rawVideoHash":       common.CodeInvalidHash,
+		"processedImageHash": common.CodeInvalidHash,
+		"rawVideoIPFS":       common.CodeInvalidCID,
+		"inspectionDate":     common.CodeInvalidTimestamp,
+	})
+}
+
+func TestAddDefectInspectionValidatesGeometry(t *testing.T) {
+	tests := []struct {
+		name     string
+		changes  map[string]interface{}
+		expected map[string]string
+	}{
+		{"inverted ROI", map[string]interface{}{"roi_y1": 480},
+			map[string]string{"roi_y2": common.CodeOrder}},
+		{"inverted bbox", map[string]interface{}{"bbox_x1": 200},
+			map[string]string{"bbox_x2": common.CodeOrder}},
+		{"bbox outside ROI", map[string]interface{}{"bbox_x2": 640, "bbox_y1": 5},
+			map[string]string{"bbox_x2": common.CodeOutOfBounds, "bbox_y1": common.CodeOutOfBounds}},
+		{"defect without type", map[string]interface{}{"defectType": ""},
+			map[string]string{"defectType": common.CodeRequired}},
+		{"ground truth outside ROI", map[string]interface{}{"hasGroundTruth": true,
+			"gt_bbox_x1": 10, "gt_bbox_y1": 80, "gt_bbox_x2": 180, "gt_bbox_y2": 140},
+			map[string]string{"gt_bbox_x1": common.CodeOutOfBounds}},
+	}
+	for _, test := range tests {
+		_, err := new(SmartContract).AddDefectInspection(newContext(newMockStub(), "MROLabMSP"), invalidInspection(test.changes))
+		expectFieldErrors(t, test.name, err, test.expected)
+	}
+
+	// Without a detected defect the bounding box is not checked
+	_, err := new(SmartContract).AddDefectInspection(newContext(newMockStub(), "MROLabMSP"), invalidInspection(map[string]interface{}{
+		"defectDetected": false, "defectType": "", "bbox_x1": 0, "bbox_y1": 0, "bbox_x2": 0, "bbox_y2": 0,
+	}))
+	if err != nil {
+		t.Errorf("expected an inspection without a defect to be accepted, got %v", err)
+	}
+}
+
+func TestAddDefectInspectionTakesPrivateFieldsFromTransient(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	mroLab := newContext(stub, "MROLabMSP")
+
+	// The inspector would be written to the block as an argument
+	_, err := contract.AddDefectInspection(mroLab, invalidInspection(map[string]interface{}{"inspector": "Dr. Smith"}))
+	expectFieldErrors(t, "private argument", err, map[string]string{"inspector": common.CodePrivateField})
+	if len(stub.State) != 0 {
+		t.Errorf("expected nothing written")
+	}
+
+	stub.TransientMap[common.TransientPrivateKey] = []byte(`{"inspector":"Dr. Jones","serialNumber":"BLADE-002"}`)
+	_, err = contract.AddDefectInspection(mroLab, sampleDefectInspection("BLADE-001", ""))
+	if err == nil || !strings.Contains(err.Error(), "invalid transient") {
+		t.Errorf("expected public fields in the transient map to be rejected, got %v", err)
+	}
+
+	stub.TransientMap[common.TransientPrivateKey] = []byte(`{"inspector":"Dr. Jones"}`)
+	inspection, err := contract.AddDefectInspection(mroLab, sampleDefectInspection("BLADE-001", ""))
+	if err != nil || inspection.Inspector != "Dr. Jones" {
+		t.Fatalf("expected the inspector from the transient map, got %+v (%v)", inspection, err)
+	}
+	key, _ := inspectionKey(mroLab, "BLADE-001", "tx1")
+	if string(stub.PvtState["aiDefectPrivateMROLabCollection"][key]) != `{"inspector":"Dr. Jones"}` {
+		t.Errorf("unexpected private data %s", stub.PvtState["aiDefectPrivateMROLabCollection"][key])
+	}
+}
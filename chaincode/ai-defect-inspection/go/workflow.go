@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// WorkflowStatus returns the inspection's status and the organization that submitted it
+func (inspection *AIDefectInspection) WorkflowStatus() (string, string) {
+	return inspection.Status, inspection.Organization
+}
+
+// AppendStatusChange moves the inspection to the status of change and records it in the status history
+func (inspection *AIDefectInspection) AppendStatusChange(change common.StatusChange) {
+	inspection.Status = change.Status
+	inspection.StatusHistory = append(inspection.StatusHistory, change)
+}
+
+// StatusActorAttributes identifies an inspection in the keys of its transition actors
+func (inspection *AIDefectInspection) StatusActorAttributes() []string {
+	return []string{inspection.SerialNumber, inspection.TxID}
+}
+
+// changeInspectionStatus moves an inspection of the caller's org, identified by its serial number and TxID,
+// to status (see common.ChangeStatus) and updates the serial number's latest inspection if it is that inspection
+func (s *SmartContract) changeInspectionStatus(ctx contractapi.TransactionContextInterface,
+	serialNumber, txID, status, comment string) (*AIDefectInspection, error) {
+
+	err := common.CheckStatusChange(ctx, status, comment, inspectionMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := inspectionKey(ctx, serialNumber, txID)
+	if err != nil {
+		return nil, err
+	}
+	publicDataJSON, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public data: %v", err)
+	}
+	if publicDataJSON == nil {
+		return nil, fmt.Errorf("inspection %s (%s) does not exist", serialNumber, txID)
+	}
+	var inspection AIDefectInspection
+	err = json.Unmarshal(publicDataJSON, &inspection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal public data: %v", err)
+	}
+
+	privateCollectionName, err := getPrivateCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = common.ChangeStatus(ctx, &inspection, fmt.Sprintf("%s (%s)", serialNumber, txID), privateCollectionName, status, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	publicDataJSON, err = common.MarshalPublic(&inspection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public data: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, publicDataJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to put public data: %v", err)
+	}
+
+	// The latest inspection of the serial number is a copy, kept in step while it is this inspection
+	latest, err := readLatestInspection(ctx, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.TxID == txID {
+		err = ctx.GetStub().PutState(serialNumber, publicDataJSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update latest inspection: %v", err)
+		}
+	}
+
+	readPrivateData(ctx, privateCollectionName, &inspection)
+	return &inspection, nil
+}
+
+// SubmitDefectInspection submits a draft AI inspection for review. Only certified inspectors of the
+// submitting organization may submit it.
+func (s *SmartContract) SubmitDefectInspection(ctx contractapi.TransactionContextInterface,
+	serialNumber string, txID string) (*AIDefectInspection, error) {
+	return s.changeInspectionStatus(ctx, serialNumber, txID, common.StatusSubmitted, "")
+}
+
+// ReviewDefectInspection records the Level 3 review of a submitted AI inspection. Only Level 3 supervisors
+// certified in thermography may review, and not inspections they recorded themselves.
+func (s *SmartContract) ReviewDefectInspection(ctx contractapi.TransactionContextInterface,
+	serialNumber string, txID string, comment string) (*AIDefectInspection, error) {
+	return s.changeInspectionStatus(ctx, serialNumber, txID, common.StatusReviewed, comment)
+}
+
+// ReleaseDefectInspection releases a reviewed AI inspection, after which it can no longer be changed.
+// Only supervisors may release inspections.
+func (s *SmartContract) ReleaseDefectInspection(ctx contractapi.TransactionContextInterface,
+	serialNumber string, txID string, comment string) (*AIDefectInspection, error) {
+	return s.changeInspectionStatus(ctx, serialNumber, txID, common.StatusReleased, comment)
+}
+
+// RejectDefectInspection rejects a submitted or reviewed AI inspection for the given reason. Only
+// supervisors may reject inspections.
+func (s *SmartContract) RejectDefectInspection(ctx contractapi.TransactionContextInterface,
+	serialNumber string, txID string, reason string) (*AIDefectInspection, error) {
+	return s.changeInspectionStatus(ctx, serialNumber, txID, common.StatusRejected, reason)
+}
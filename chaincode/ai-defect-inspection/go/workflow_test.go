@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// certifiedSupervisor holds the certificate attributes of a Level 3 thermography supervisor
+var certifiedSupervisor = map[string]string{"role": "supervisor", "ndt.level": "3", "ndt.method": "thermography"}
+
+// withStatus returns the sample inspection requested with a status
+func withStatus(inspectionJSON, status string) string {
+	var inspection AIDefectInspection
+	_ = json.Unmarshal([]byte(inspectionJSON), &inspection)
+	inspection.Status = status
+	b, _ := json.Marshal(inspection)
+	return string(b)
+}
+
+func TestDefectInspectionApprovalWorkflow(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	inspector := newContext(stub, "MROLabMSP")
+	supervisor := newNamedContext(stub, "MROLabMSP", "supervisor1", certifiedSupervisor)
+
+	recorded, err := contract.AddDefectInspection(inspector, withStatus(sampleDefectInspection("BLADE-001", ""), common.StatusDraft))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+	if recorded.Status != common.StatusDraft || len(recorded.StatusHistory) != 1 {
+		t.Errorf("expected a draft, got %s %+v", recorded.Status, recorded.StatusHistory)
+	}
+
+	stub.setTransaction("tx2", txSeconds+60)
+	_, err = contract.ReleaseDefectInspection(supervisor, "BLADE-001", "tx1", "")
+	if err == nil || !strings.Contains(err.Error(), "cannot change status from draft to released") {
+		t.Errorf("expected the release of a draft to fail, got %v", err)
+	}
+	if _, err = contract.SubmitDefectInspection(inspector, "BLADE-001", "tx1"); err != nil {
+		t.Fatalf("SubmitDefectInspection failed: %v", err)
+	}
+
+	stub.setTransaction("tx3", txSeconds+120)
+	level2 := map[string]string{"role": "supervisor", "ndt.level": "2", "ndt.method": "thermography"}
+	_, err = contract.ReviewDefectInspection(newNamedContext(stub, "MROLabMSP", "supervisor2", level2), "BLADE-001", "tx1", "")
+	if err == nil || !strings.Contains(err.Error(), "only Level 3 supervisors") {
+		t.Errorf("expected a Level 2 supervisor to be rejected, got %v", err)
+	}
+	_, err = contract.ReviewDefectInspection(newContextWithAttrs(stub, "MROLabMSP", "client", certifiedSupervisor), "BLADE-001", "tx1", "")
+	if err == nil || !strings.Contains(err.Error(), "someone other than the inspector") {
+		t.Errorf("expected the submitter to be rejected as reviewer, got %v", err)
+	}
+	if _, err = contract.ReviewDefectInspection(supervisor, "BLADE-001", "tx1", "indication confirmed"); err != nil {
+		t.Fatalf("ReviewDefectInspection failed: %v", err)
+	}
+
+	stub.setTransaction("tx4", txSeconds+180)
+	if _, err = contract.ReleaseDefectInspection(inspector, "BLADE-001", "tx1", ""); err == nil {
+		t.Error("expected an inspector to be rejected as releaser")
+	}
+	released, err := contract.ReleaseDefectInspection(supervisor, "BLADE-001", "tx1", "")
+	if err != nil {
+		t.Fatalf("ReleaseDefectInspection failed: %v", err)
+	}
+	if released.Inspector != "Dr. Smith" {
+		t.Errorf("expected the private fields of the caller's org, got %q", released.Inspector)
+	}
+
+	// The latest inspection follows, with the public history of each transition
+	latest, err := contract.GetDefectInspection(newContext(stub, "ManufacturerMSP"), "BLADE-001")
+	if err != nil {
+		t.Fatalf("GetDefectInspection failed: %v", err)
+	}
+	if latest.Status != common.StatusReleased || len(latest.StatusHistory) != 4 {
+		t.Fatalf("expected a released inspection with 4 status changes, got %s %+v", latest.Status, latest.StatusHistory)
+	}
+	review := latest.StatusHistory[2]
+	if review.Status != common.StatusReviewed || review.Role != "supervisor" || review.Comment != "indication confirmed" || review.TxID != "tx3" {
+		t.Errorf("unexpected review %+v", review)
+	}
+	actor, err := common.ReadStatusActor(inspector, "aiDefectPrivateMROLabCollection", latest, common.StatusReleased)
+	if err != nil || actor == nil || actor.ID != "x509::CN=supervisor1" || actor.TxID != "tx4" {
+		t.Errorf("unexpected releaser %+v (%v)", actor, err)
+	}
+
+	// Released inspections are immutable
+	stub.setTransaction("tx5", txSeconds+240)
+	_, err = contract.RejectDefectInspection(supervisor, "BLADE-001", "tx1", "model drift")
+	if err == nil || !strings.Contains(err.Error(), "released") {
+		t.Errorf("expected a released inspection to be final, got %v", err)
+	}
+	_, err = contract.RejectDefectInspection(newNamedContext(stub, "ManufacturerMSP", "qs1", certifiedSupervisor), "BLADE-001", "tx1", "model drift")
+	if err == nil || !strings.Contains(err.Error(), "only the owning organization MROLabMSP") {
+		t.Errorf("expected another org to be rejected, got %v", err)
+	}
+}
+
+func TestRejectDefectInspection(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	supervisor := newNamedContext(stub, "MROLabMSP", "supervisor1", certifiedSupervisor)
+
+	first, err := contract.AddDefectInspection(newContext(stub, "MROLabMSP"), sampleDefectInspection("BLADE-001", ""))
+	if err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+	if first.Status != common.StatusSubmitted {
+		t.Errorf("expected a submitted inspection, got %q", first.Status)
+	}
+
+	// A later inspection is the latest; rejecting the first leaves it alone
+	stub.setTransaction("tx2", txSeconds+60)
+	if _, err = contract.AddDefectInspection(newContext(stub, "MROLabMSP"), sampleModelInspection("BLADE-001", "v2.0", 0.8, "")); err != nil {
+		t.Fatalf("AddDefectInspection failed: %v", err)
+	}
+	stub.setTransaction("tx3", txSeconds+120)
+	if _, err = contract.RejectDefectInspection(supervisor, "BLADE-001", "tx1", ""); err == nil {
+		t.Error("expected a rejection without a reason to fail")
+	}
+	rejected, err := contract.RejectDefectInspection(supervisor, "BLADE-001", "tx1", "wrong ROI")
+	if err != nil {
+		t.Fatalf("RejectDefectInspection failed: %v", err)
+	}
+	if rejected.Status != common.StatusRejected || rejected.StatusHistory[1].Comment != "wrong ROI" {
+		t.Errorf("unexpected rejection %s %+v", rejected.Status, rejected.StatusHistory)
+	}
+	latest, _ := contract.GetDefectInspection(supervisor, "BLADE-001")
+	if latest.TxID != "tx2" || latest.Status != common.StatusSubmitted {
+		t.Errorf("expected the latest inspection to be unchanged, got %s %s", latest.TxID, latest.Status)
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// inspectionVersionObjectType prefixes the composite keys of superseded inspection versions:
+// inspectionVersion~PartNumber~SerialNumber~OccasionLabel~InspectionDate~Version. The current
+// version stays under the inspection key, so queries see only current versions unless they ask.
+const inspectionVersionObjectType = "inspectionVersion"
+
+// Reason codes of an amendment
+const (
+	ReasonTranscriptionError = "transcription_error" // a value was mistyped or misread from the source
+	ReasonMeasurementError   = "measurement_error"   // the blade was remeasured
+	ReasonUnitError          = "unit_error"          // the source values were recorded in the wrong unit
+	ReasonEquipmentError     = "equipment_error"     // the gauge was faulty or out of calibration
+	ReasonOther              = "other"
+)
+
+// amendableStatuses lists the statuses of inspections that can be amended. Reviewed and released
+// inspections, and those recorded before the approval workflow, are signed off and stay as they are.
+var amendableStatuses = map[string]bool{common.StatusDraft: true, common.StatusSubmitted: true, common.StatusRejected: true}
+
+// amendmentReasonCodes lists the accepted reason codes
+var amendmentReasonCodes = []string{ReasonTranscriptionError, ReasonMeasurementError, ReasonUnitError, ReasonEquipmentError, ReasonOther}
+
+// isReasonCode reports whether code is an accepted reason code
+func isReasonCode(code string) bool {
+	for _, reasonCode := range amendmentReasonCodes {
+		if code == reasonCode {
+			return true
+		}
+	}
+	return false
+}
+
+// Amendment records why an inspection version superseded the previous version
+type Amendment struct {
+	ReasonCode     string `json:"reasonCode"`
+	Justification  string `json:"justification"`
+	SupersedesTxID string `json:"supersedesTxId"` // TxID of the superseded version
+}
+
+// versionOf returns the version number of an inspection; inspections recorded before amendments existed are version 1
+func versionOf(inspection *BladeInspection) int {
+	if inspection.Version == 0 {
+		return 1
+	}
+	return inspection.Version
+}
+
+// versionKey returns the composite key of a superseded inspection version
+func versionKey(ctx contractapi.TransactionContextInterface, inspection *BladeInspection) (string, error) {
+	return common.CompositeKey(ctx.GetStub(), inspectionVersionObjectType, inspection.PartNumber, inspection.SerialNumber,
+		inspection.OccasionLabel, inspection.InspectionDate, fmt.Sprintf("%04d", versionOf(inspection)))
+}
+
+// recordKey returns the key an inspection version is stored under: the inspection key while it is current
+func recordKey(ctx contractapi.TransactionContextInterface, inspection *BladeInspection) (string, error) {
+	if inspection.SupersededBy != "" {
+		return versionKey(ctx, inspection)
+	}
+	return inspectionKey(ctx, inspection)
+}
+
+// supersede moves the current version of an inspection event, stored under key, to its version key with
+// a link to the amendment replacing it, together with its private data. The amendment must come from the
+// owning org and follow the current version, which must be a draft, submitted or rejected.
+func supersede(ctx contractapi.TransactionContextInterface, privateCollectionName, key string, currentBytes []byte,
+	amendment *BladeInspection) error {
+
+	if currentBytes == nil {
+		return fmt.Errorf("inspection %s %s (%s, %s) does not exist", amendment.PartNumber, amendment.SerialNumber,
+			amendment.OccasionLabel, amendment.InspectionDate)
+	}
+	var current BladeInspection
+	err := json.Unmarshal(currentBytes, &current)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal public data: %v", err)
+	}
+	if current.Organization != amendment.Organization {
+		return fmt.Errorf("only the owning organization %s can amend inspection %s", current.Organization,
+			bladeKey(current.PartNumber, current.SerialNumber))
+	}
+	if versionOf(&current) != amendment.Version-1 {
+		return fmt.Errorf("inspection %s is at version %d, not %d: amend the current version",
+			bladeKey(current.PartNumber, current.SerialNumber), versionOf(&current), amendment.Version-1)
+	}
+	if !amendableStatuses[current.Status] {
+		status := current.Status
+		if status == "" {
+			status = "recorded before the approval workflow"
+		}
+		return fmt.Errorf("inspection %s is %s and can no longer be amended: only draft, submitted and rejected inspections can be amended",
+			bladeKey(current.PartNumber, current.SerialNumber), status)
+	}
+
+	privateDataBytes, err := ctx.GetStub().GetPrivateData(privateCollectionName, key)
+	if err != nil {
+		return fmt.Errorf("failed to read private data: %v", err)
+	}
+
+	current.Version = versionOf(&current)
+	current.SupersededBy = amendment.TxID
+	supersededKey, err := versionKey(ctx, &current)
+	if err != nil {
+		return err
+	}
+	publicDataBytes, err := common.MarshalPublic(&current)
+	if err != nil {
+		return fmt.Errorf("failed to marshal public data: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData(inspectionPublicCollection, supersededKey, publicDataBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write superseded version: %v", err)
+	}
+	if privateDataBytes != nil {
+		err = ctx.GetStub().PutPrivateData(privateCollectionName, supersededKey, privateDataBytes)
+		if err != nil {
+			return fmt.Errorf("failed to write superseded private data: %v", err)
+		}
+	}
+
+	amendment.Amendment.SupersedesTxID = current.TxID
+	return nil
+}
+
+// AmendInspection corrects a recorded inspection event with a new version that supersedes the current one,
+// which stays queryable with GetInspectionVersions. version is the current version being amended (1 for an
+// inspection never amended), the reason code one of transcription_error, measurement_error, unit_error,
+// equipment_error or other, and the justification explains the correction. The part number, serial number,
+// occasion label and inspection date identify the event and cannot be amended. Only draft, submitted and
+// rejected inspections can be amended: once reviewed or released they are final. The new version is assessed
+// and enters the approval workflow like a new inspection; private fields are sent as for AddInspection.
+// Resubmitting an identical amendment returns it, while a different amendment of the same version fails
+// with SUBMISSION_CONFLICT. Only certified inspectors of the owning organization may amend inspections.
+func (s *SmartContract) AmendInspection(ctx contractapi.TransactionContextInterface, inspectionJSON string, version int,
+	reasonCode string, justification string) (*BladeInspection, error) {
+
+	err := checkInspector(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs common.FieldErrors
+	if !isReasonCode(reasonCode) {
+		errs.Add("reasonCode", common.CodeInvalidValue, "must be one of "+strings.Join(amendmentReasonCodes, ", "))
+	}
+	if strings.TrimSpace(justification) == "" {
+		errs.Add("justification", common.CodeRequired, "is required to amend an inspection")
+	}
+	if version < 1 {
+		errs.Add("version", common.CodeInvalidValue, "must be the current version of the inspection, 1 or above")
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	inspection, errs := validateInspection([]byte(inspectionJSON))
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	err = readTransientPrivate(ctx, []*BladeInspection{inspection})
+	if err != nil {
+		return nil, err
+	}
+	inspection.Version = version + 1
+	inspection.Amendment = &Amendment{ReasonCode: reasonCode, Justification: justification}
+
+	recorded, key, err := s.addInspection(ctx, inspection, newTxWrites())
+	if err != nil {
+		return nil, err
+	}
+	if recorded.TxID != ctx.GetStub().GetTxID() {
+		// Resubmitted: the earlier transaction emitted the event
+		return recorded, nil
+	}
+
+	err = setInspectionEvent(ctx, recorded.Organization, "", []InspectionEventItem{newInspectionEventItem(key, recorded)})
+	if err != nil {
+		return nil, err
+	}
+	return recorded, nil
+}
+
+// readSupersededVersions reads the superseded versions of a blade's inspection events; attributes
+// narrows them down to an occasion label and inspection date
+func readSupersededVersions(ctx contractapi.TransactionContextInterface, attributes ...string) ([]*BladeInspection, error) {
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(inspectionPublicCollection,
+		inspectionVersionObjectType, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read superseded versions: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	return collectInspections(ctx, resultsIterator, nil)
+}
+
+// GetInspectionVersions retrieves every version of an inspection event, oldest first: the superseded
+// versions, each with the TxID of the amendment that superseded it, and the current version
+func (s *SmartContract) GetInspectionVersions(ctx contractapi.TransactionContextInterface, partNumber, serialNumber,
+	occasionLabel, inspectionDate string) ([]*BladeInspection, error) {
+
+	versions, err := readSupersededVersions(ctx, partNumber, serialNumber, occasionLabel, inspectionDate)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := inspectionKey(ctx, &BladeInspection{PartNumber: partNumber, SerialNumber: serialNumber,
+		OccasionLabel: occasionLabel, InspectionDate: inspectionDate})
+	if err != nil {
+		return nil, err
+	}
+	publicDataBytes, err := ctx.GetStub().GetPrivateData(inspectionPublicCollection, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public data: %v", err)
+	}
+	if publicDataBytes == nil {
+		return nil, fmt.Errorf("inspection %s %s (%s, %s) does not exist", partNumber, serialNumber, occasionLabel, inspectionDate)
+	}
+	var current BladeInspection
+	err = json.Unmarshal(publicDataBytes, &current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal public data: %v", err)
+	}
+	privateCollectionName, err := getPrivateCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = readInspectionPrivate(ctx, privateCollectionName, &current)
+	if err != nil {
+		return nil, err
+	}
+
+	versions = append(versions, &current)
+	sortChronologically(versions)
+	return versions, nil
+}
+
+// GetBladeHistoryWithSuperseded retrieves every inspection event of a blade like GetBladeHistory, together
+// with the versions amendments have superseded. Versions of an event follow each other in commit order.
+func (s *SmartContract) GetBladeHistoryWithSuperseded(ctx contractapi.TransactionContextInterface,
+	partNumber, serialNumber string) ([]*BladeInspection, error) {
+
+	history, err := s.GetBladeHistory(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	superseded, err := readSupersededVersions(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	history = append(history, superseded...)
+	sortChronologically(history)
+	return history, nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// correctedInspection returns the sample inspection with a corrected AR source value
+func correctedInspection(serialNumber, ar string) string {
+	var inspection BladeInspection
+	_ = json.Unmarshal([]byte(sampleInspection(serialNumber, "manual", "MROLabMSP")), &inspection)
+	inspection.Measurements.Source.AR = ar
+	inspectionJSON, _ := json.Marshal(inspection)
+	return string(inspectionJSON)
+}
+
+func TestAmendInspection(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+	date := "2025-10-20T08:00:00Z"
+
+	if _, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP")); err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	_, err := contract.AddInspection(ctx, correctedInspection("RGA46870", "243.10 mm"))
+	var conflict *common.SubmissionConflictError
+	if !errors.As(err, &conflict) {
+		t.Errorf("expected a changed resubmission to conflict, got %v", err)
+	}
+	var readded BladeInspection
+	_ = json.Unmarshal([]byte(correctedInspection("RGA46870", "243.10 mm")), &readded)
+	readded.SubmissionID = "row-2"
+	readdedJSON, _ := json.Marshal(readded)
+	_, err = contract.AddInspection(ctx, string(readdedJSON))
+	if err == nil || !strings.Contains(err.Error(), "use AmendInspection") {
+		t.Errorf("expected a second AddInspection to point to AmendInspection, got %v", err)
+	}
+
+	stub.setTransaction("tx2", 1760947260)
+	stub.setInspector("A. Jones")
+	amended, err := contract.AmendInspection(ctx, correctedInspection("RGA46870", "243.10 mm"), 1, ReasonTranscriptionError, "AR was typed as 243.04")
+	if err != nil {
+		t.Fatalf("AmendInspection failed: %v", err)
+	}
+	if amended.Version != 2 || amended.Amendment == nil || amended.Amendment.SupersedesTxID != "tx1" ||
+		amended.Amendment.ReasonCode != ReasonTranscriptionError || amended.Measurements.AR != 243.10 || amended.Status != common.StatusSubmitted {
+		t.Errorf("unexpected amendment %+v", amended)
+	}
+	name, payload := eventPayload(t, stub, "tx2")
+	if name != common.EventInspectionAmended || payload.Inspections[0].Version != 2 {
+		t.Errorf("unexpected %s event %+v", name, payload)
+	}
+
+	// Queries return the current version unless asked for superseded ones
+	current, err := contract.GetInspection(ctx, "6A7614", "RGA46870")
+	if err != nil || current.TxID != "tx2" || current.Inspector != "A. Jones" {
+		t.Errorf("expected the amended version as current inspection, got %+v (%v)", current, err)
+	}
+	history, err := contract.GetBladeHistory(ctx, "6A7614", "RGA46870")
+	if err != nil || len(history) != 1 || history[0].TxID != "tx2" {
+		t.Errorf("expected the current version only, got %d (%v)", len(history), err)
+	}
+	versions, err := contract.GetInspectionVersions(ctx, "6A7614", "RGA46870", "manual", date)
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("expected two versions, got %d (%v)", len(versions), err)
+	}
+	if versions[0].Version != 1 || versions[0].SupersededBy != "tx2" || versions[0].Inspector != "J. Smith" ||
+		versions[0].Measurements.AR != 243.04 || versions[1].TxID != "tx2" {
+		t.Errorf("unexpected versions %+v, %+v", versions[0], versions[1])
+	}
+	all, err := contract.GetBladeHistoryWithSuperseded(ctx, "6A7614", "RGA46870")
+	if err != nil || len(all) != 2 || all[0].TxID != "tx1" || all[1].TxID != "tx2" {
+		t.Errorf("expected both versions in commit order, got %d (%v)", len(all), err)
+	}
+
+	// The amended version is reviewed on its own
+	stub.setTransaction("tx3", 1760947320)
+	if _, err = contract.ReviewInspection(newNamedContext(stub, "MROLabMSP", "supervisor1", certifiedSupervisor), "6A7614", "RGA46870", "manual", date, ""); err != nil {
+		t.Errorf("ReviewInspection failed: %v", err)
+	}
+
+	// Retrying the amendment returns it, a different amendment of the same version conflicts
+	stub.setTransaction("tx4", 1760947380)
+	again, err := contract.AmendInspection(ctx, correctedInspection("RGA46870", "243.10 mm"), 1, ReasonTranscriptionError, "AR was typed as 243.04")
+	if err != nil || again.TxID != "tx2" || stub.events["tx4"] != nil {
+		t.Errorf("expected the recorded amendment, got %+v (%v)", again, err)
+	}
+	_, err = contract.AmendInspection(ctx, correctedInspection("RGA46870", "243.20 mm"), 1, ReasonMeasurementError, "remeasured")
+	if !errors.As(err, &conflict) {
+		t.Errorf("expected a submission conflict, got %v", err)
+	}
+	_, err = contract.AmendInspection(ctx, correctedInspection("RGA46870", "243.20 mm"), 3, ReasonMeasurementError, "remeasured")
+	if err == nil || !strings.Contains(err.Error(), "is at version 2, not 3") {
+		t.Errorf("expected a stale version to be rejected, got %v", err)
+	}
+}
+
+func TestAmendInspectionRejectsInvalidAmendments(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+
+	if _, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP")); err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+
+	stub.setTransaction("tx2", 1760947260)
+	_, err := contract.AmendInspection(ctx, correctedInspection("RGA46870", "243.10 mm"), 0, "typo", " ")
+	codes := fieldCodes(t, err)
+	if len(codes) != 3 || codes["reasonCode"] != common.CodeInvalidValue || codes["justification"] != common.CodeRequired ||
+		codes["version"] != common.CodeInvalidValue {
+		t.Errorf("unexpected field errors %v", codes)
+	}
+
+	_, err = contract.AmendInspection(newContext(stub, "ManufacturerMSP"), sampleInspection("RGA46870", "manual", "ManufacturerMSP"), 1, ReasonOther, "wrong blade")
+	if err == nil || !strings.Contains(err.Error(), "only the owning organization MROLabMSP") {
+		t.Errorf("expected another org to be rejected, got %v", err)
+	}
+	_, err = contract.AmendInspection(ctx, correctedInspection("RGA85382", "243.10 mm"), 1, ReasonOther, "wrong blade")
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected an unknown inspection to be rejected, got %v", err)
+	}
+}
+
+func TestAmendInspectionKeepsSignedOffInspections(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+	supervisor := newNamedContext(stub, "MROLabMSP", "supervisor1", certifiedSupervisor)
+	date := "2025-10-20T08:00:00Z"
+
+	if _, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP")); err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	stub.setTransaction("tx2", 1760947260)
+	if _, err := contract.ReviewInspection(supervisor, "6A7614", "RGA46870", "manual", date, ""); err != nil {
+		t.Fatalf("ReviewInspection failed: %v", err)
+	}
+	stub.setTransaction("tx3", 1760947320)
+	_, err := contract.AmendInspection(ctx, correctedInspection("RGA46870", "243.10 mm"), 1, ReasonTranscriptionError, "typo")
+	if err == nil || !strings.Contains(err.Error(), "is reviewed and can no longer be amended") {
+		t.Errorf("expected a reviewed inspection to be kept, got %v", err)
+	}
+
+	stub.setTransaction("tx4", 1760947380)
+	if _, err = contract.ReleaseInspection(supervisor, "6A7614", "RGA46870", "manual", date, ""); err != nil {
+		t.Fatalf("ReleaseInspection failed: %v", err)
+	}
+	stub.setTransaction("tx5", 1760947440)
+	_, err = contract.AmendInspection(ctx, correctedInspection("RGA46870", "243.10 mm"), 1, ReasonTranscriptionError, "typo")
+	if err == nil || !strings.Contains(err.Error(), "is released and can no longer be amended") {
+		t.Errorf("expected a released inspection to be kept, got %v", err)
+	}
+
+	// The released record is neither replaced nor linked to a superseding version
+	versions, err := contract.GetInspectionVersions(ctx, "6A7614", "RGA46870", "manual", date)
+	if err != nil || len(versions) != 1 || versions[0].Status != common.StatusReleased || versions[0].SupersededBy != "" ||
+		versions[0].Measurements.AR != 243.04 {
+		t.Errorf("expected the released version only, got %+v (%v)", versions, err)
+	}
+	if stub.events["tx5"] != nil {
+		t.Error("expected no event for a rejected amendment")
+	}
+}
+
+func TestAmendRejectedInspection(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+	supervisor := newNamedContext(stub, "MROLabMSP", "supervisor1", certifiedSupervisor)
+
+	if _, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP")); err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	stub.setTransaction("tx2", 1760947260)
+	if _, err := contract.RejectInspection(supervisor, "6A7614", "RGA46870", "manual", "2025-10-20T08:00:00Z", "AR misread"); err != nil {
+		t.Fatalf("RejectInspection failed: %v", err)
+	}
+
+	// A corrected version of a rejected inspection goes back through review
+	stub.setTransaction("tx3", 1760947320)
+	amended, err := contract.AmendInspection(ctx, correctedInspection("RGA46870", "243.10 mm"), 1, ReasonTranscriptionError, "AR misread")
+	if err != nil || amended.Version != 2 || amended.Status != common.StatusSubmitted {
+		t.Errorf("expected a submitted second version, got %+v (%v)", amended, err)
+	}
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// installationObjectType prefixes the world state keys of installation records, one per time a part
+// was installed: installation~PartNumber~SerialNumber~Sequence (zero-padded so keys sort in order)
+const installationObjectType = "installation"
+
+// assemblyPositionObjectType prefixes the keys of the occupied positions of an assembly, each holding
+// the key of the installation in it: assemblyPosition~ParentPartNumber~ParentSerialNumber~Position
+const assemblyPositionObjectType = "assemblyPosition"
+
+// Installation records a part installed in a parent assembly, e.g. a blade in a rotor stage or a
+// rotor stage in an engine, from its installation until its removal
+type Installation struct {
+	PartNumber         string `json:"partNumber"`
+	SerialNumber       string `json:"serialNumber"`
+	ParentPartNumber   string `json:"parentPartNumber"`
+	ParentSerialNumber string `json:"parentSerialNumber"`
+	Position           int    `json:"position"`    // slot in the parent, from 1
+	Sequence           int    `json:"sequence"`    // installations of the part so far, from 1
+	InstalledAt        string `json:"installedAt"` // RFC3339
+	RemovedAt          string `json:"removedAt,omitempty"`
+	RemovalReason      string `json:"removalReason,omitempty"`
+	Organization       string `json:"organization"` // MSP ID of the installing organization
+	InstallTxID        string `json:"installTxId"`
+	RemoveTxID         string `json:"removeTxId,omitempty"`
+}
+
+// AssemblyBlade is a part currently installed in an assembly with the state of its latest inspection
+type AssemblyBlade struct {
+	PartNumber         string `json:"partNumber"`
+	SerialNumber       string `json:"serialNumber"`
+	ParentPartNumber   string `json:"parentPartNumber"`
+	ParentSerialNumber string `json:"parentSerialNumber"`
+	Position           int    `json:"position"`
+	InstalledAt        string `json:"installedAt"`
+	Disposition        string `json:"disposition,omitempty"`      // of the current inspection, empty if never inspected
+	InspectionDate     string `json:"inspectionDate,omitempty"`   // of the current inspection
+	InspectionStatus   string `json:"inspectionStatus,omitempty"` // approval status of the current inspection
+}
+
+// installationKey returns the world state key of a part's installation with the given sequence
+func installationKey(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string, sequence int) (string, error) {
+	return common.CompositeKey(ctx.GetStub(), installationObjectType, partNumber, serialNumber, fmt.Sprintf("%04d", sequence))
+}
+
+// assemblyPositionKey returns the world state key of a position in an assembly
+func assemblyPositionKey(ctx contractapi.TransactionContextInterface, parentPartNumber, parentSerialNumber string,
+	position int) (string, error) {
+
+	return common.CompositeKey(ctx.GetStub(), assemblyPositionObjectType, parentPartNumber, parentSerialNumber,
+		fmt.Sprintf("%04d", position))
+}
+
+// readInstallations reads every installation of a part, oldest first
+func readInstallations(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) ([]*Installation, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(installationObjectType, []string{partNumber, serialNumber})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read installations: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	installations := []*Installation{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate: %v", err)
+		}
+		var installation Installation
+		err = json.Unmarshal(queryResponse.Value, &installation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal installation: %v", err)
+		}
+		installations = append(installations, &installation)
+	}
+	return installations, nil
+}
+
+// currentInstallation returns the installation a part is currently in (nil if it is not installed)
+func currentInstallation(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (*Installation, error) {
+	installations, err := readInstallations(ctx, partNumber, serialNumber)
+	if err != nil || len(installations) == 0 {
+		return nil, err
+	}
+	last := installations[len(installations)-1]
+	if last.RemovedAt != "" {
+		return nil, nil
+	}
+	return last, nil
+}
+
+// putInstallation writes an installation record
+func putInstallation(ctx contractapi.TransactionContextInterface, installation *Installation) error {
+	key, err := installationKey(ctx, installation.PartNumber, installation.SerialNumber, installation.Sequence)
+	if err != nil {
+		return err
+	}
+	installationBytes, err := json.Marshal(installation)
+	if err != nil {
+		return fmt.Errorf("failed to marshal installation: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, installationBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write installation: %v", err)
+	}
+	return nil
+}
+
+// checkMaintainer returns the caller's organization unless it is neither a manufacturer nor an MRO
+func checkMaintainer(ctx contractapi.TransactionContextInterface, action string) (*common.Organization, error) {
+	org, err := callerOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if org.Role != common.RoleManufacturer && org.Role != common.RoleMRO {
+		return nil, fmt.Errorf("only manufacturer and MRO organizations can %s, %s is a %s organization", action, org.MSPID, org.Role)
+	}
+	return org, nil
+}
+
+// checkInstallationDate returns an error unless date is an RFC3339 time that is not later than the
+// transaction and not earlier than after
+func checkInstallationDate(ctx contractapi.TransactionContextInterface, field, date, after string) error {
+	var errs common.FieldErrors
+	errs.CheckRFC3339(field, date)
+	if err := errs.Err(); err != nil {
+		return err
+	}
+	t, _ := time.Parse(time.RFC3339, date)
+
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	if t.After(time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos))) {
+		errs.Add(field, common.CodeInvalidTimestamp, "must not be in the future")
+	}
+	if previous, err := time.Parse(time.RFC3339, after); err == nil && t.Before(previous) {
+		errs.Add(field, common.CodeOrder, "must not be before "+after)
+	}
+	return errs.Err()
+}
+
+// InstallPart records a part installed at a position (from 1) of a parent assembly at installedAt
+// (RFC3339), e.g. a blade in a rotor stage or a stage in an engine. Both must be registered, the part
+// in service and not installed elsewhere, the parent not scrapped, and the position free. A part cannot
+// be installed in itself or in one of its own sub-assemblies. Only manufacturer and MRO organizations
+// may install parts.
+func (s *SmartContract) InstallPart(ctx contractapi.TransactionContextInterface, parentPartNumber, parentSerialNumber,
+	partNumber, serialNumber string, position int, installedAt string) (*Installation, error) {
+
+	org, err := checkMaintainer(ctx, "install parts")
+	if err != nil {
+		return nil, err
+	}
+	if position < 1 {
+		var errs common.FieldErrors
+		errs.Add("position", common.CodeInvalidValue, "must be 1 or above")
+		return nil, errs.Err()
+	}
+
+	part, err := readPart(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if err := common.CheckInspectable(part, partNumber, serialNumber); err != nil {
+		return nil, err
+	}
+	if part.Status != common.PartInService {
+		return nil, fmt.Errorf("part %s %s is %s: only parts in service can be installed", partNumber, serialNumber, part.Status)
+	}
+	parent, err := readPart(ctx, parentPartNumber, parentSerialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if err := common.CheckInspectable(parent, parentPartNumber, parentSerialNumber); err != nil {
+		return nil, err
+	}
+
+	// A part is reinstalled only after its removal from the previous assembly
+	installations, err := readInstallations(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	lastRemoval := ""
+	if len(installations) > 0 {
+		last := installations[len(installations)-1]
+		if last.RemovedAt == "" {
+			return nil, fmt.Errorf("part %s %s is installed in %s %s at position %d: remove it first", partNumber, serialNumber,
+				last.ParentPartNumber, last.ParentSerialNumber, last.Position)
+		}
+		lastRemoval = last.RemovedAt
+	}
+
+	// Walk up from the parent: meeting the part would make it part of itself
+	ancestorPartNumber, ancestorSerialNumber := parentPartNumber, parentSerialNumber
+	for {
+		if ancestorPartNumber == partNumber && ancestorSerialNumber == serialNumber {
+			return nil, fmt.Errorf("part %s %s cannot be installed in itself or its own sub-assembly %s %s",
+				partNumber, serialNumber, parentPartNumber, parentSerialNumber)
+		}
+		ancestor, err := currentInstallation(ctx, ancestorPartNumber, ancestorSerialNumber)
+		if err != nil {
+			return nil, err
+		}
+		if ancestor == nil {
+			break
+		}
+		ancestorPartNumber, ancestorSerialNumber = ancestor.ParentPartNumber, ancestor.ParentSerialNumber
+	}
+
+	positionKey, err := assemblyPositionKey(ctx, parentPartNumber, parentSerialNumber, position)
+	if err != nil {
+		return nil, err
+	}
+	occupant, err := ctx.GetStub().GetState(positionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read assembly position: %v", err)
+	}
+	if occupant != nil {
+		return nil, fmt.Errorf("position %d of %s %s is occupied", position, parentPartNumber, parentSerialNumber)
+	}
+
+	err = checkInstallationDate(ctx, "installedAt", installedAt, lastRemoval)
+	if err != nil {
+		return nil, err
+	}
+
+	installation := &Installation{
+		PartNumber:         partNumber,
+		SerialNumber:       serialNumber,
+		ParentPartNumber:   parentPartNumber,
+		ParentSerialNumber: parentSerialNumber,
+		Position:           position,
+		Sequence:           len(installations) + 1,
+		InstalledAt:        installedAt,
+		Organization:       org.MSPID,
+		InstallTxID:        ctx.GetStub().GetTxID(),
+	}
+	err = putInstallation(ctx, installation)
+	if err != nil {
+		return nil, err
+	}
+	key, err := installationKey(ctx, partNumber, serialNumber, installation.Sequence)
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.GetStub().PutState(positionKey, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to write assembly position: %v", err)
+	}
+	return installation, nil
+}
+
+// RemovePart records the removal of an installed part from its parent assembly at removedAt (RFC3339),
+// freeing its position. Parts installed in the removed part stay installed in it. Only manufacturer
+// and MRO organizations may remove parts.
+func (s *SmartContract) RemovePart(ctx contractapi.TransactionContextInterface, partNumber, serialNumber,
+	removedAt, reason string) (*Installation, error) {
+
+	_, err := checkMaintainer(ctx, "remove parts")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("a reason is required to remove a part")
+	}
+
+	installation, err := currentInstallation(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if installation == nil {
+		return nil, fmt.Errorf("part %s %s is not installed", partNumber, serialNumber)
+	}
+	err = checkInstallationDate(ctx, "removedAt", removedAt, installation.InstalledAt)
+	if err != nil {
+		return nil, err
+	}
+
+	installation.RemovedAt = removedAt
+	installation.RemovalReason = reason
+	installation.RemoveTxID = ctx.GetStub().GetTxID()
+	err = putInstallation(ctx, installation)
+	if err != nil {
+		return nil, err
+	}
+	positionKey, err := assemblyPositionKey(ctx, installation.ParentPartNumber, installation.ParentSerialNumber, installation.Position)
+	if err != nil {
+		return nil, err
+	}
+	err = ctx.GetStub().DelState(positionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to free assembly position: %v", err)
+	}
+	return installation, nil
+}
+
+// readAssembly reads the parts installed in an assembly, ordered by position
+func readAssembly(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) ([]*Installation, error) {
+	resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(assemblyPositionObjectType, []string{partNumber, serialNumber})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read assembly: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	var installations []*Installation
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, fmt.Errorf("failed to iterate: %v", err)
+		}
+		installationBytes, err := ctx.GetStub().GetState(string(queryResponse.Value))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read installation: %v", err)
+		}
+		if installationBytes == nil {
+			return nil, fmt.Errorf("installation %s does not exist", queryResponse.Value)
+		}
+		var installation Installation
+		err = json.Unmarshal(installationBytes, &installation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal installation: %v", err)
+		}
+		installations = append(installations, &installation)
+	}
+	return installations, nil
+}
+
+// GetAssemblyBlades retrieves every part currently installed in an assembly, including those in its
+// sub-assemblies (e.g. the blades in each rotor stage of an engine), with the disposition, date and
+// approval status of its current inspection. Each part follows its parent, in position order.
+func (s *SmartContract) GetAssemblyBlades(ctx contractapi.TransactionContextInterface,
+	partNumber, serialNumber string) ([]*AssemblyBlade, error) {
+
+	if _, err := s.GetPart(ctx, partNumber, serialNumber); err != nil {
+		return nil, err
+	}
+
+	blades := []*AssemblyBlade{}
+	var walk func(partNumber, serialNumber string) error
+	walk = func(partNumber, serialNumber string) error {
+		installations, err := readAssembly(ctx, partNumber, serialNumber)
+		if err != nil {
+			return err
+		}
+		for _, installation := range installations {
+			blade := &AssemblyBlade{
+				PartNumber:         installation.PartNumber,
+				SerialNumber:       installation.SerialNumber,
+				ParentPartNumber:   installation.ParentPartNumber,
+				ParentSerialNumber: installation.ParentSerialNumber,
+				Position:           installation.Position,
+				InstalledAt:        installation.InstalledAt,
+			}
+			inspection, err := readCurrentInspection(ctx, installation.PartNumber, installation.SerialNumber)
+			if err != nil {
+				return err
+			}
+			if inspection != nil {
+				blade.Disposition = inspection.Disposition
+				blade.InspectionDate = inspection.InspectionDate
+				blade.InspectionStatus = inspection.Status
+			}
+			blades = append(blades, blade)
+
+			err = walk(installation.PartNumber, installation.SerialNumber)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	err := walk(partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	return blades, nil
+}
+
+// GetInstallationHistory retrieves every installation of a part, oldest first, including the current one
+func (s *SmartContract) GetInstallationHistory(ctx contractapi.TransactionContextInterface,
+	partNumber, serialNumber string) ([]*Installation, error) {
+
+	if _, err := s.GetPart(ctx, partNumber, serialNumber); err != nil {
+		return nil, err
+	}
+	return readInstallations(ctx, partNumber, serialNumber)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// registerAssemblies registers an engine and a rotor stage for installing the sample blades
+func registerAssemblies(t *testing.T, contract *SmartContract, stub *mockStub) {
+	t.Helper()
+	manufacturer := newContext(stub, "ManufacturerMSP")
+	for _, part := range [][2]string{{"EN1000", "ENG001"}, {"RS2000", "STG001"}} {
+		partJSON := fmt.Sprintf(`{"partNumber":"%s","serialNumber":"%s","manufacturer":"Rolls-Royce","material":"Ti-6Al-4V",`+
+			`"manufactureDate":"2018-06-01"}`, part[0], part[1])
+		if _, err := contract.RegisterPart(manufacturer, partJSON); err != nil {
+			t.Fatalf("RegisterPart failed: %v", err)
+		}
+	}
+}
+
+func TestAssemblyHierarchy(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+	registerAssemblies(t, contract, stub)
+
+	if _, err := contract.InstallPart(ctx, "EN1000", "ENG001", "RS2000", "STG001", 1, "2025-01-10T00:00:00Z"); err != nil {
+		t.Fatalf("InstallPart failed: %v", err)
+	}
+	for position, serialNumber := range []string{"RGA46870", "RGA85382"} {
+		if _, err := contract.InstallPart(ctx, "RS2000", "STG001", "6A7614", serialNumber, position+1, "2025-01-12T00:00:00Z"); err != nil {
+			t.Fatalf("InstallPart %s failed: %v", serialNumber, err)
+		}
+	}
+	if _, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP")); err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+
+	// The engine holds the stage, and through it the blades with their inspection state
+	blades, err := contract.GetAssemblyBlades(ctx, "EN1000", "ENG001")
+	if err != nil {
+		t.Fatalf("GetAssemblyBlades failed: %v", err)
+	}
+	var serials []string
+	for _, blade := range blades {
+		serials = append(serials, fmt.Sprintf("%s@%s/%d", blade.SerialNumber, blade.ParentSerialNumber, blade.Position))
+	}
+	if fmt.Sprint(serials) != "[STG001@ENG001/1 RGA46870@STG001/1 RGA85382@STG001/2]" {
+		t.Fatalf("unexpected assembly %v", serials)
+	}
+	if blades[1].Disposition != DispositionUnassessed || blades[1].InspectionStatus != common.StatusSubmitted ||
+		blades[1].InspectionDate != "2025-10-20T08:00:00Z" || blades[2].Disposition != "" {
+		t.Errorf("unexpected inspection state %+v, %+v", blades[1], blades[2])
+	}
+
+	// A removed blade leaves the assembly and can be installed again later
+	stub.setTransaction("tx2", 1760947260)
+	_, err = contract.RemovePart(ctx, "6A7614", "RGA85382", "2025-01-11T00:00:00Z", "blade release")
+	if codes := fieldCodes(t, err); codes["removedAt"] != common.CodeOrder {
+		t.Errorf("expected a removal before the installation to be rejected, got %v", codes)
+	}
+	removed, err := contract.RemovePart(ctx, "6A7614", "RGA85382", "2025-06-01T00:00:00Z", "tip rub")
+	if err != nil || removed.RemovedAt != "2025-06-01T00:00:00Z" || removed.RemoveTxID != "tx2" {
+		t.Fatalf("unexpected removal %+v (%v)", removed, err)
+	}
+	if blades, _ = contract.GetAssemblyBlades(ctx, "RS2000", "STG001"); len(blades) != 1 {
+		t.Errorf("expected one blade left in the stage, got %d", len(blades))
+	}
+	if _, err = contract.RemovePart(ctx, "6A7614", "RGA85382", "2025-06-02T00:00:00Z", "again"); err == nil {
+		t.Error("expected a removed blade to be rejected")
+	}
+
+	stub.setTransaction("tx3", 1760947320)
+	if _, err = contract.InstallPart(ctx, "RS2000", "STG001", "6A7614", "RGA85382", 3, "2025-07-01T00:00:00Z"); err != nil {
+		t.Fatalf("InstallPart failed: %v", err)
+	}
+	history, err := contract.GetInstallationHistory(ctx, "6A7614", "RGA85382")
+	if err != nil || len(history) != 2 {
+		t.Fatalf("expected two installations, got %d (%v)", len(history), err)
+	}
+	if history[0].Position != 2 || history[0].RemovalReason != "tip rub" || history[1].Position != 3 || history[1].RemovedAt != "" ||
+		history[1].Sequence != 2 || history[1].InstallTxID != "tx3" {
+		t.Errorf("unexpected installation history %+v, %+v", history[0], history[1])
+	}
+}
+
+func TestInstallPartRejectsInvalidInstallations(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+	registerAssemblies(t, contract, stub)
+	registerPart(stub, "RGA00001", common.PartQuarantined)
+
+	if _, err := contract.InstallPart(ctx, "EN1000", "ENG001", "RS2000", "STG001", 1, "2025-01-10T00:00:00Z"); err != nil {
+		t.Fatalf("InstallPart failed: %v", err)
+	}
+	if _, err := contract.InstallPart(ctx, "RS2000", "STG001", "6A7614", "RGA46870", 1, "2025-01-12T00:00:00Z"); err != nil {
+		t.Fatalf("InstallPart failed: %v", err)
+	}
+
+	tests := []struct {
+		name, parentPartNumber, parentSerialNumber, partNumber, serialNumber string
+		position                                                             int
+		installedAt, message                                                 string
+	}{
+		{"occupied position", "RS2000", "STG001", "6A7614", "RGA85382", 1, "2025-01-12T00:00:00Z", "position 1 of RS2000 STG001 is occupied"},
+		{"installed elsewhere", "RS2000", "STG001", "6A7614", "RGA46870", 2, "2025-01-12T00:00:00Z", "is installed in RS2000 STG001 at position 1"},
+		{"own sub-assembly", "RS2000", "STG001", "EN1000", "ENG001", 2, "2025-01-12T00:00:00Z", "cannot be installed in itself"},
+		{"itself", "EN1000", "ENG001", "EN1000", "ENG001", 2, "2025-01-12T00:00:00Z", "cannot be installed in itself"},
+		{"unregistered part", "RS2000", "STG001", "6A7614", "RGA99999", 2, "2025-01-12T00:00:00Z", "is not registered"},
+		{"unregistered parent", "RS2000", "STG999", "6A7614", "RGA85382", 2, "2025-01-12T00:00:00Z", "RS2000 STG999 is not registered"},
+		{"quarantined part", "RS2000", "STG001", "6A7614", "RGA00001", 2, "2025-01-12T00:00:00Z", "only parts in service can be installed"},
+		{"invalid position", "RS2000", "STG001", "6A7614", "RGA85382", 0, "2025-01-12T00:00:00Z", common.CodeValidationFailed},
+		{"future date", "RS2000", "STG001", "6A7614", "RGA85382", 2, "2025-12-01T00:00:00Z", "must not be in the future"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := contract.InstallPart(ctx, tt.parentPartNumber, tt.parentSerialNumber, tt.partNumber, tt.serialNumber,
+				tt.position, tt.installedAt)
+			if err == nil || !strings.Contains(err.Error(), tt.message) {
+				t.Errorf("expected an error containing %q, got %v", tt.message, err)
+			}
+		})
+	}
+
+	_, err := contract.InstallPart(newContext(stub, "RegulatorMSP"), "RS2000", "STG001", "6A7614", "RGA85382", 2, "2025-01-12T00:00:00Z")
+	if err == nil {
+		t.Error("expected an unregistered organization to be rejected")
+	}
+}
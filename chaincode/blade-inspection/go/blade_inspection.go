@@ -3,56 +3,73 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/hyperledger/fabric-chaincode-go/shim"
 	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
 )
 
 // SmartContract provides functions for managing blade inspections
 type SmartContract struct {
 	contractapi.Contract
+	common.RegistryContract[registryDefaults]
 }
 
-// BladeInspection represents a chord measurement inspection record (complete view)
+// BladeInspection represents a chord measurement inspection record. Fields tagged `ledger:"private"` are
+// stored in the submitting org's private collection, all others in the public collection.
 type BladeInspection struct {
 	PartNumber     string            `json:"partNumber"`
 	SerialNumber   string            `json:"serialNumber"`
 	OccasionLabel  string            `json:"occasionLabel"`  // e.g., "before_surfacing", "manual", "after_surfacing"
 	InspectionDate string            `json:"inspectionDate"` // ISO 8601 format: "2025-10-20T08:00:00Z"
 	SubmittedAt    string            `json:"submittedAt"`    // ISO 8601 format
-	Inspector      string            `json:"inspector"`
-	Organization   string            `json:"organization"`
+	Inspector      string            `json:"inspector,omitempty" ledger:"private"`
+	InspectorSalt  string            `json:"inspectorSalt,omitempty" ledger:"private"`
+	Organization   string            `json:"organization"` // MSP ID of the submitter
 	Measurements   ChordMeasurements `json:"measurements"`
 	CSVHash        string            `json:"csvHash"`
+	SubmissionID   string            `json:"submissionId,omitempty"` // idempotency key, derived from the event if empty
 
-	// Blockchain metadata (populated by GetBladeHistory)
-	TxID                string `json:"txId,omitempty"`
-	BlockchainTimestamp string `json:"blockchainTimestamp,omitempty"` // ISO 8601 format
-}
+	// Conformance (computed by AddInspection from the part's tolerance spec)
+	Disposition          string   `json:"disposition,omitempty"` // serviceable, repair, scrap or unassessed
+	OutOfLimitPoints     []string `json:"outOfLimitPoints,omitempty"`
+	ToleranceSpecVersion int      `json:"toleranceSpecVersion,omitempty"`
 
-// BladeInspectionPublic represents the public data (all fields except Inspector)
-type BladeInspectionPublic struct {
-	PartNumber     string            `json:"partNumber"`
-	SerialNumber   string            `json:"serialNumber"`
-	OccasionLabel  string            `json:"occasionLabel"`
-	InspectionDate string            `json:"inspectionDate"`
-	SubmittedAt    string            `json:"submittedAt"`
-	Organization   string            `json:"organization"`
-	Measurements   ChordMeasurements `json:"measurements"`
-	CSVHash        string            `json:"csvHash"`
+	// Approval workflow (draft, submitted, reviewed, released or rejected; see workflow.go)
+	Status        string                `json:"status,omitempty"` // draft if requested, submitted by default
+	StatusHistory []common.StatusChange `json:"statusHistory,omitempty"`
 
-	// Blockchain metadata
+	// Versioning (set by AmendInspection; see amendment.go)
+	Version      int        `json:"version,omitempty"`      // 1 for the inspection as first recorded
+	Amendment    *Amendment `json:"amendment,omitempty"`    // why this version superseded the previous one
+	SupersededBy string     `json:"supersededBy,omitempty"` // TxID of the amendment that superseded this version
+
+	// Blockchain metadata (recorded when the inspection is committed)
 	TxID                string `json:"txId,omitempty"`
-	BlockchainTimestamp string `json:"blockchainTimestamp,omitempty"`
+	BlockchainTimestamp string `json:"blockchainTimestamp,omitempty"` // ISO 8601 format
+
+	// Submitter identity (taken from the client certificate, kept in the org's private collection)
+	SubmittedBy      string `json:"submittedBy,omitempty" ledger:"private"`      // client identity ID
+	SubmitterSubject string `json:"submitterSubject,omitempty" ledger:"private"` // certificate subject DN
 }
 
-// BladeInspectionPrivate represents the private data (Inspector only)
+// BladeInspectionPrivate is the private data of an inspection returned by GetInspectionPrivate
 type BladeInspectionPrivate struct {
-	Inspector string `json:"inspector"`
+	Inspector        string `json:"inspector"`
+	InspectorSalt    string `json:"inspectorSalt,omitempty"`
+	SubmittedBy      string `json:"submittedBy,omitempty"`
+	SubmitterSubject string `json:"submitterSubject,omitempty"`
 }
 
-// ChordMeasurements stores the 14 chord measurement points (all in mm)
+// ChordMeasurements stores the 14 chord measurement points. AR..AB are always in mm and are computed
+// by the chaincode from the Source values, which are kept exactly as recorded for audit.
 type ChordMeasurements struct {
+	Unit            string            `json:"unit"`            // unit of the source values: "mm" or "in"
+	SourcePrecision int               `json:"sourcePrecision"` // decimal places of the source values
+	Source          ChordSourceValues `json:"source"`
+
 	AR float64 `json:"ar"`
 	AP float64 `json:"ap"`
 	AN float64 `json:"an"`
@@ -69,336 +86,622 @@ type ChordMeasurements struct {
 	AB float64 `json:"ab"`
 }
 
+// ChordSourceValues are the chord measurements as recorded in the source file (e.g. "242.98 mm" or "9.581")
+type ChordSourceValues struct {
+	AR string `json:"ar"`
+	AP string `json:"ap"`
+	AN string `json:"an"`
+	AM string `json:"am"`
+	AL string `json:"al"`
+	AK string `json:"ak"`
+	AJ string `json:"aj"`
+	AH string `json:"ah"`
+	AG string `json:"ag"`
+	AF string `json:"af"`
+	AE string `json:"ae"`
+	AD string `json:"ad"`
+	AC string `json:"ac"`
+	AB string `json:"ab"`
+}
+
+// chordPoint is a single named chord measurement
+type chordPoint struct {
+	Name  string
+	Value float64
+}
+
+// chordPointNames lists the 14 chord measurement points in blade order
+var chordPointNames = []string{"AR", "AP", "AN", "AM", "AL", "AK", "AJ", "AH", "AG", "AF", "AE", "AD", "AC", "AB"}
+
+// pointRefs returns pointers to the 14 chord measurements in blade order (AR..AB)
+func (m *ChordMeasurements) pointRefs() []*float64 {
+	return []*float64{&m.AR, &m.AP, &m.AN, &m.AM, &m.AL, &m.AK, &m.AJ, &m.AH, &m.AG, &m.AF, &m.AE, &m.AD, &m.AC, &m.AB}
+}
+
+// points returns the 14 chord measurements in blade order (AR..AB)
+func (m ChordMeasurements) points() []chordPoint {
+	points := make([]chordPoint, len(chordPointNames))
+	for i, ref := range m.pointRefs() {
+		points[i] = chordPoint{chordPointNames[i], *ref}
+	}
+	return points
+}
+
+// values returns the 14 source values in blade order (AR..AB)
+func (v ChordSourceValues) values() []string {
+	return []string{v.AR, v.AP, v.AN, v.AM, v.AL, v.AK, v.AJ, v.AH, v.AG, v.AF, v.AE, v.AD, v.AC, v.AB}
+}
+
+// inspectionPublicCollection is shared by both orgs (collections_config.json)
+const inspectionPublicCollection = "inspectionPublicCollection"
+
+// inspectionObjectType prefixes the composite keys of individual inspection events:
+// inspection~PartNumber~SerialNumber~OccasionLabel~InspectionDate
+const inspectionObjectType = "inspection"
+
+// bladeKey returns the key of the current (latest) inspection for a blade
+func bladeKey(partNumber, serialNumber string) string {
+	return common.SimpleKey(partNumber, serialNumber)
+}
+
+// inspectionKey returns the composite key of a single inspection event
+func inspectionKey(ctx contractapi.TransactionContextInterface, inspection *BladeInspection) (string, error) {
+	return common.CompositeKey(ctx.GetStub(), inspectionObjectType,
+		inspection.PartNumber, inspection.SerialNumber, inspection.OccasionLabel, inspection.InspectionDate)
+}
+
+// isLaterInspection reports whether inspection a was performed at or after inspection b
+func isLaterInspection(a, b *BladeInspection) bool {
+	ta, errA := time.Parse(time.RFC3339, a.InspectionDate)
+	tb, errB := time.Parse(time.RFC3339, b.InspectionDate)
+	if errA != nil || errB != nil {
+		return errB != nil
+	}
+	return !ta.Before(tb)
+}
+
 // InitLedger initializes the ledger
 func (s *SmartContract) InitLedger(ctx contractapi.TransactionContextInterface) error {
 	fmt.Println("Blade Inspection Chaincode initialized")
 	return nil
 }
 
-// AddInspection adds a new blade inspection using PDC (overwrites previous in world state)
-func (s *SmartContract) AddInspection(ctx contractapi.TransactionContextInterface, inspectionJSON string) error {
-	var inspection BladeInspection
-	err := json.Unmarshal([]byte(inspectionJSON), &inspection)
+// getPrivateCollectionName returns the private collection the org registry assigns to the client's MSP ID
+func getPrivateCollectionName(ctx contractapi.TransactionContextInterface) (string, error) {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
-		return fmt.Errorf("failed to unmarshal inspection: %v", err)
+		return "", fmt.Errorf("failed to get client MSP ID: %v", err)
 	}
 
-	// Validate required fields
-	if inspection.PartNumber == "" || inspection.SerialNumber == "" {
-		return fmt.Errorf("partNumber and serialNumber are required")
+	registry, err := common.LoadRegistry[registryDefaults](ctx)
+	if err != nil {
+		return "", err
 	}
+	return registry.PrivateCollection(clientMSPID)
+}
+
+// inspectionMethod is the certification (ndt.method attribute) required to submit blade measurements
+const inspectionMethod = "dimensional"
 
-	// Get the client's MSP ID to determine which private collection to use
+// checkInspector rejects callers whose certificate does not certify them for blade measurements
+func checkInspector(ctx contractapi.TransactionContextInterface) error {
+	return common.CheckInspector(ctx.GetClientIdentity(), inspectionMethod)
+}
+
+// stampSubmitter sets the organization and submitter of an inspection from the caller's certificate.
+// A claimed organization must match the caller's MSP ID, and the inspector defaults to the certificate's
+// common name.
+func stampSubmitter(ctx contractapi.TransactionContextInterface, inspection *BladeInspection) error {
 	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
 	if err != nil {
 		return fmt.Errorf("failed to get client MSP ID: %v", err)
 	}
+	if inspection.Organization != "" && inspection.Organization != clientMSPID {
+		return fmt.Errorf("organization %s does not match the submitter's MSP ID %s", inspection.Organization, clientMSPID)
+	}
 
-	// Determine the org-specific private collection name
-	var privateCollectionName string
-	switch clientMSPID {
-	case "ManufacturerMSP":
-		privateCollectionName = "inspectionPrivateManufacturerCollection"
-	case "MROLabMSP":
-		privateCollectionName = "inspectionPrivateMROLabCollection"
-	default:
-		return fmt.Errorf("unknown MSP ID: %s", clientMSPID)
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client ID: %v", err)
+	}
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return fmt.Errorf("failed to get client certificate: %v", err)
 	}
+	if cert == nil {
+		return fmt.Errorf("failed to get client certificate")
+	}
+
+	inspection.Organization = clientMSPID
+	inspection.SubmittedBy = clientID
+	inspection.SubmitterSubject = cert.Subject.String()
+	if inspection.Inspector == "" {
+		inspection.Inspector = cert.Subject.CommonName
+	}
+	return nil
+}
 
-	// Create composite key: PartNumber_SerialNumber (no timestamp/occasion)
-	key := fmt.Sprintf("%s_%s", inspection.PartNumber, inspection.SerialNumber)
+// readTransientPrivate sets the private fields of the submitted inspections from the transient map, which
+// is not written to the block. The entry is an object applied to every inspection or, for a batch, an array
+// with one object per inspection. Without the entry the private fields stay empty.
+func readTransientPrivate(ctx contractapi.TransactionContextInterface, inspections []*BladeInspection) error {
+	transientJSON, err := common.TransientPrivate(ctx.GetStub())
+	if err != nil || transientJSON == nil {
+		return err
+	}
 
-	// Split into public and private data
-	publicData := BladeInspectionPublic{
-		PartNumber:     inspection.PartNumber,
-		SerialNumber:   inspection.SerialNumber,
-		OccasionLabel:  inspection.OccasionLabel,
-		InspectionDate: inspection.InspectionDate,
-		SubmittedAt:    inspection.SubmittedAt,
-		Organization:   inspection.Organization,
-		Measurements:   inspection.Measurements,
-		CSVHash:        inspection.CSVHash,
+	var items []json.RawMessage
+	if json.Unmarshal(transientJSON, &items) != nil {
+		items = make([]json.RawMessage, len(inspections))
+		for i := range items {
+			items[i] = transientJSON
+		}
+	}
+	if len(items) != len(inspections) {
+		return fmt.Errorf("transient %q holds %d items for %d inspections", common.TransientPrivateKey, len(items), len(inspections))
 	}
 
-	privateData := BladeInspectionPrivate{
-		Inspector: inspection.Inspector,
+	for i, inspection := range inspections {
+		err = common.UnmarshalTransientPrivate(items[i], inspection)
+		if err != nil {
+			return err
+		}
+		if inspection.InspectorSalt != "" && len(inspection.InspectorSalt) < minInspectorSaltLength {
+			return fmt.Errorf("inspectorSalt must have at least %d characters so that it cannot be guessed", minInspectorSaltLength)
+		}
 	}
+	return nil
+}
 
-	// Marshal public data
-	publicDataBytes, err := json.Marshal(publicData)
+// AddInspection records a new blade inspection event using PDC, updates the blade's current inspection and
+// returns the recorded inspection. Resubmitting the same content under the same submission ID returns the
+// existing inspection; different content under a used ID fails with SUBMISSION_CONFLICT. A new inspection
+// emits an OutOfTolerance event if it has points outside serviceable limits and InspectionAdded otherwise.
+// The inspector is sent in the transient map under "private" ({"inspector":"...","inspectorSalt":"..."})
+// and defaults to the certificate's common name; private fields in the arguments, which end up in the
+// block, are rejected. The salt is a random value from the client that VerifyInspectionPrivate needs.
+// The inspection is submitted for review unless its status is "draft". Only certified inspectors may
+// submit inspections.
+func (s *SmartContract) AddInspection(ctx contractapi.TransactionContextInterface, inspectionJSON string) (*BladeInspection, error) {
+	err := checkInspector(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to marshal public data: %v", err)
+		return nil, err
 	}
 
-	// Marshal private data
-	privateDataBytes, err := json.Marshal(privateData)
+	inspection, errs := validateInspection([]byte(inspectionJSON))
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	err = readTransientPrivate(ctx, []*BladeInspection{inspection})
 	if err != nil {
-		return fmt.Errorf("failed to marshal private data: %v", err)
+		return nil, err
 	}
 
-	// Write public data to public collection
-	err = ctx.GetStub().PutPrivateData("inspectionPublicCollection", key, publicDataBytes)
+	recorded, key, err := s.addInspection(ctx, inspection, newTxWrites())
 	if err != nil {
-		return fmt.Errorf("failed to write public data: %v", err)
+		return nil, err
+	}
+	if recorded.TxID != ctx.GetStub().GetTxID() {
+		// Resubmitted: the earlier transaction emitted the event
+		return recorded, nil
 	}
 
-	// Write private data to org-specific private collection
-	err = ctx.GetStub().PutPrivateData(privateCollectionName, key, privateDataBytes)
+	err = setInspectionEvent(ctx, recorded.Organization, "", []InspectionEventItem{newInspectionEventItem(key, recorded)})
 	if err != nil {
-		return fmt.Errorf("failed to write private data: %v", err)
+		return nil, err
 	}
+	return recorded, nil
+}
 
-	return nil
+// txWrites tracks the records written earlier in the same transaction, which GetPrivateData does not return
+type txWrites struct {
+	events      map[string]bool
+	current     map[string]*BladeInspection
+	submissions map[string]*common.Submission
 }
 
-// GetInspection retrieves the current (latest) inspection for a blade (public + private data if accessible)
-func (s *SmartContract) GetInspection(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (*BladeInspection, error) {
-	key := fmt.Sprintf("%s_%s", partNumber, serialNumber)
+func newTxWrites() *txWrites {
+	return &txWrites{events: map[string]bool{}, current: map[string]*BladeInspection{}, submissions: map[string]*common.Submission{}}
+}
+
+// addInspection assesses and writes a single inspection event that has passed validateInspection,
+// returning the stored inspection and its key. For a resubmission it returns the existing inspection,
+// whose TxID is that of the earlier transaction, without writing anything.
+func (s *SmartContract) addInspection(ctx contractapi.TransactionContextInterface, inspection *BladeInspection,
+	writes *txWrites) (*BladeInspection, string, error) {
 
-	// Get public data
-	publicDataBytes, err := ctx.GetStub().GetPrivateData("inspectionPublicCollection", key)
+	// Normalize the source measurements to mm
+	if err := normalizeMeasurements(&inspection.Measurements); err != nil {
+		return nil, "", fmt.Errorf("invalid measurements: %v", err)
+	}
+
+	// Determine the org-specific private collection name
+	privateCollectionName, err := getPrivateCollectionName(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read public data: %v", err)
+		return nil, "", err
 	}
-	if publicDataBytes == nil {
-		return nil, fmt.Errorf("inspection %s does not exist", key)
+
+	// The organization and submitter come from the caller's certificate, not the input
+	err = stampSubmitter(ctx, inspection)
+	if err != nil {
+		return nil, "", err
 	}
 
-	var publicData BladeInspectionPublic
-	err = json.Unmarshal(publicDataBytes, &publicData)
+	// A resubmission returns the inspection its submission ID already created
+	submission, resubmitted, err := checkSubmission(ctx, privateCollectionName, inspection, writes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal public data: %v", err)
+		return nil, "", err
+	}
+	if resubmitted != nil {
+		resubmittedKey, err := inspectionKey(ctx, resubmitted)
+		if err != nil {
+			return nil, "", err
+		}
+		return resubmitted, resubmittedKey, nil
 	}
 
-	// Get the client's MSP ID to determine which private collection to read from
-	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	// New inspections need a registered part that has not been scrapped; amendments correct earlier ones
+	if inspection.Amendment == nil {
+		err = checkPartInspectable(ctx, inspection.PartNumber, inspection.SerialNumber)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	// Get transaction metadata
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get client MSP ID: %v", err)
+		return nil, "", fmt.Errorf("failed to get transaction timestamp: %v", err)
 	}
 
-	// Determine the org-specific private collection name
-	var privateCollectionName string
-	switch clientMSPID {
-	case "ManufacturerMSP":
-		privateCollectionName = "inspectionPrivateManufacturerCollection"
-	case "MROLabMSP":
-		privateCollectionName = "inspectionPrivateMROLabCollection"
-	default:
-		return nil, fmt.Errorf("unknown MSP ID: %s", clientMSPID)
+	// Record the transaction metadata
+	inspection.TxID = ctx.GetStub().GetTxID()
+	inspection.BlockchainTimestamp = time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).UTC().Format(time.RFC3339)
+
+	// Assess conformance against the latest tolerance spec for the part number
+	spec, err := s.readToleranceSpec(ctx, inspection.PartNumber, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	inspection.Disposition, inspection.OutOfLimitPoints = assessDisposition(spec, inspection.Measurements)
+	inspection.ToleranceSpecVersion = 0
+	if spec != nil {
+		inspection.ToleranceSpecVersion = spec.Version
 	}
 
-	// Try to get private data (Inspector) from org-specific collection
-	privateDataBytes, err := ctx.GetStub().GetPrivateData(privateCollectionName, key)
-	// Don't fail if private data doesn't exist - it might have been submitted by another org
+	if inspection.Version == 0 {
+		inspection.Version = 1
+	}
 
-	var privateData BladeInspectionPrivate
-	if err == nil && privateDataBytes != nil {
-		err = json.Unmarshal(privateDataBytes, &privateData)
+	// Start the approval workflow as a draft or submitted for review
+	inspection.Status, err = common.InitialStatus(inspection.Status)
+	if err != nil {
+		return nil, "", err
+	}
+	change, actor, err := common.NewStatusChange(ctx, inspection.Status, "")
+	if err != nil {
+		return nil, "", err
+	}
+	inspection.StatusHistory = []common.StatusChange{change}
+
+	// Create composite key: PartNumber~SerialNumber~OccasionLabel~InspectionDate
+	key, err := inspectionKey(ctx, inspection)
+	if err != nil {
+		return nil, "", err
+	}
+
+	existing, err := ctx.GetStub().GetPrivateData(inspectionPublicCollection, key)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read public data: %v", err)
+	}
+	if inspection.Amendment != nil {
+		// An amendment replaces the current version, which is kept under its version key
+		err = supersede(ctx, privateCollectionName, key, existing, inspection)
 		if err != nil {
-			return nil, fmt.Errorf("failed to unmarshal private data: %v", err)
+			return nil, "", err
 		}
+	} else if existing != nil || writes.events[key] {
+		return nil, "", fmt.Errorf("inspection %s %s (%s, %s) already exists: use AmendInspection to correct it",
+			inspection.PartNumber, inspection.SerialNumber, inspection.OccasionLabel, inspection.InspectionDate)
 	}
 
-	// Combine public and private data
-	inspection := BladeInspection{
-		PartNumber:     publicData.PartNumber,
-		SerialNumber:   publicData.SerialNumber,
-		OccasionLabel:  publicData.OccasionLabel,
-		InspectionDate: publicData.InspectionDate,
-		SubmittedAt:    publicData.SubmittedAt,
-		Inspector:      privateData.Inspector,
-		Organization:   publicData.Organization,
-		Measurements:   publicData.Measurements,
-		CSVHash:        publicData.CSVHash,
+	// Split into public and private data
+	publicDataBytes, err := common.MarshalPublic(inspection)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal public data: %v", err)
 	}
 
-	// Add blockchain metadata for current query
-	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
-	if err == nil {
-		t := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos))
-		inspection.BlockchainTimestamp = t.Format(time.RFC3339)
+	privateDataBytes, err := common.MarshalPrivate(inspection)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to marshal private data: %v", err)
 	}
-	inspection.TxID = ctx.GetStub().GetTxID()
 
-	return &inspection, nil
-}
+	// Write public data to public collection
+	err = ctx.GetStub().PutPrivateData(inspectionPublicCollection, key, publicDataBytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to write public data: %v", err)
+	}
 
-// GetInspectionPublic retrieves only public data (without Inspector name)
-func (s *SmartContract) GetInspectionPublic(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (*BladeInspectionPublic, error) {
-	key := fmt.Sprintf("%s_%s", partNumber, serialNumber)
+	// Write private data to org-specific private collection
+	err = ctx.GetStub().PutPrivateData(privateCollectionName, key, privateDataBytes)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to write private data: %v", err)
+	}
+	err = putInspectorClaim(ctx, privateCollectionName, inspection)
+	if err != nil {
+		return nil, "", err
+	}
+	err = common.PutStatusActor(ctx, privateCollectionName, inspection, actor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	writes.events[key] = true
+
+	// Record the submission, so that resubmissions find this inspection
+	err = putSubmission(ctx, inspection, submission, key, writes)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Update the blade's current inspection unless a later one is already recorded
+	currentKey := bladeKey(inspection.PartNumber, inspection.SerialNumber)
+	current, written := writes.current[currentKey]
+	if !written {
+		current, err = readCurrentInspection(ctx, inspection.PartNumber, inspection.SerialNumber)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	if current == nil || isLaterInspection(inspection, current) {
+		err = ctx.GetStub().PutPrivateData(inspectionPublicCollection, currentKey, publicDataBytes)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to write current inspection: %v", err)
+		}
+		writes.current[currentKey] = inspection
+	}
 
-	// Get public data only
-	publicDataBytes, err := ctx.GetStub().GetPrivateData("inspectionPublicCollection", key)
+	return inspection, key, nil
+}
+
+// readCurrentInspection reads the public data of a blade's current inspection (nil if none exists)
+func readCurrentInspection(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (*BladeInspection, error) {
+	publicDataBytes, err := ctx.GetStub().GetPrivateData(inspectionPublicCollection, bladeKey(partNumber, serialNumber))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read public data: %v", err)
 	}
 	if publicDataBytes == nil {
-		return nil, fmt.Errorf("inspection %s does not exist", key)
+		return nil, nil
 	}
 
-	var publicData BladeInspectionPublic
-	err = json.Unmarshal(publicDataBytes, &publicData)
+	var inspection BladeInspection
+	err = json.Unmarshal(publicDataBytes, &inspection)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal public data: %v", err)
 	}
 
-	// Add blockchain metadata
-	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
-	if err == nil {
-		t := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos))
-		publicData.BlockchainTimestamp = t.Format(time.RFC3339)
-	}
-	publicData.TxID = ctx.GetStub().GetTxID()
-
-	return &publicData, nil
+	return &inspection, nil
 }
 
-// GetInspectionPrivate retrieves only private data (Inspector name - only for owning org)
-func (s *SmartContract) GetInspectionPrivate(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (*BladeInspectionPrivate, error) {
-	key := fmt.Sprintf("%s_%s", partNumber, serialNumber)
-
-	// Get private data only (will fail if caller is not from the owning org)
-	privateDataBytes, err := ctx.GetStub().GetPrivateData("inspectionPrivateCollection", key)
+// readInspectionPrivate fills in the private fields (Inspector, submitter) of an inspection version from the caller's org
+// collection. Missing private data is not an error - the inspection might have been submitted by another org.
+func readInspectionPrivate(ctx contractapi.TransactionContextInterface, privateCollectionName string, inspection *BladeInspection) error {
+	key, err := recordKey(ctx, inspection)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read private data: %v", err)
+		return err
 	}
-	if privateDataBytes == nil {
-		return nil, fmt.Errorf("private data for inspection %s does not exist", key)
+
+	privateDataBytes, err := ctx.GetStub().GetPrivateData(privateCollectionName, key)
+	if err != nil || privateDataBytes == nil {
+		return nil
 	}
 
-	var privateData BladeInspectionPrivate
-	err = json.Unmarshal(privateDataBytes, &privateData)
+	err = common.UnmarshalPrivate(privateDataBytes, inspection)
 	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal private data: %v", err)
+		return fmt.Errorf("failed to unmarshal private data: %v", err)
 	}
 
-	return &privateData, nil
+	return nil
 }
 
-// GetBladeHistory retrieves complete inspection history for a blade from blockchain
-func (s *SmartContract) GetBladeHistory(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) ([]*BladeInspection, error) {
-	key := fmt.Sprintf("%s_%s", partNumber, serialNumber)
+// collectInspections reads public inspections from resultsIterator and returns every one accepted by filter,
+// combined with private data from the caller's org collection (nil filter accepts all)
+func collectInspections(ctx contractapi.TransactionContextInterface, resultsIterator shim.StateQueryIteratorInterface,
+	filter func(*BladeInspection) bool) ([]*BladeInspection, error) {
 
-	historyIter, err := ctx.GetStub().GetHistoryForKey(key)
+	privateCollectionName, err := getPrivateCollectionName(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get history: %v", err)
+		return nil, err
 	}
-	defer historyIter.Close()
 
-	var history []*BladeInspection
-	for historyIter.HasNext() {
-		modification, err := historyIter.Next()
+	var inspections []*BladeInspection
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
 		if err != nil {
 			return nil, err
 		}
 
-		// Skip deleted records
-		if modification.IsDelete {
+		var inspection BladeInspection
+		err = json.Unmarshal(queryResponse.Value, &inspection)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal public data: %v", err)
+		}
+
+		if filter != nil && !filter(&inspection) {
 			continue
 		}
 
-		var inspection BladeInspection
-		err = json.Unmarshal(modification.Value, &inspection)
+		err = readInspectionPrivate(ctx, privateCollectionName, &inspection)
 		if err != nil {
 			return nil, err
 		}
 
-		// // Add blockchain metadata (convert timestamp to ISO 8601 string)
-		// inspection.TxID = modification.TxId
-		// inspection.BlockchainTimestamp = fmt.Sprintf("%d.%09d", modification.Timestamp.Seconds, modification.Timestamp.Nanos)
-		// Add blockchain metadata (convert timestamp to ISO 8601 string)
-		inspection.TxID = modification.TxId
-		t := time.Unix(modification.Timestamp.Seconds, int64(modification.Timestamp.Nanos))
-		inspection.BlockchainTimestamp = t.Format(time.RFC3339)
+		inspections = append(inspections, &inspection)
+	}
 
-		history = append(history, &inspection)
+	return inspections, nil
+}
+
+// GetInspection retrieves the current (latest) inspection for a blade (public + private data if accessible)
+func (s *SmartContract) GetInspection(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (*BladeInspection, error) {
+	inspection, err := readCurrentInspection(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if inspection == nil {
+		return nil, fmt.Errorf("inspection %s does not exist", bladeKey(partNumber, serialNumber))
 	}
 
-	return history, nil
+	// Determine the org-specific private collection name
+	privateCollectionName, err := getPrivateCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Try to get private data (Inspector) from org-specific collection
+	err = readInspectionPrivate(ctx, privateCollectionName, inspection)
+	if err != nil {
+		return nil, err
+	}
+
+	return inspection, nil
 }
 
-// // GetAllInspections retrieves current inspection status for all blades
-// func (s *SmartContract) GetAllInspections(ctx contractapi.TransactionContextInterface) ([]*BladeInspection, error) {
-// 	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
-// 	if err != nil {
-// 		return nil, err
-// 	}
-// 	defer resultsIterator.Close()
-
-// 	var inspections []*BladeInspection
-// 	for resultsIterator.HasNext() {
-// 		queryResponse, err := resultsIterator.Next()
-// 		if err != nil {
-// 			return nil, err
-// 		}
-
-// 		var inspection BladeInspection
-// 		err = json.Unmarshal(queryResponse.Value, &inspection)
-// 		if err != nil {
-// 			return nil, err
-// 		}
-// 		inspections = append(inspections, &inspection)
-// 	}
-
-// 	return inspections, nil
-// }
+// GetInspectionPublic retrieves only public data (without Inspector name)
+func (s *SmartContract) GetInspectionPublic(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (*BladeInspection, error) {
+	publicData, err := readCurrentInspection(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if publicData == nil {
+		return nil, fmt.Errorf("inspection %s does not exist", bladeKey(partNumber, serialNumber))
+	}
 
-// GetAllInspections retrieves current inspection status for all blades
-func (s *SmartContract) GetAllInspections(ctx contractapi.TransactionContextInterface) ([]*BladeInspection, error) {
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	return publicData, nil
+}
+
+// GetInspectionPrivate retrieves the private data (inspector and submitter) of a blade's current inspection.
+// The owning org reads its own collection; another org can read it only once the owner has shared it
+// with ShareInspectionPrivate.
+func (s *SmartContract) GetInspectionPrivate(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (*BladeInspectionPrivate, error) {
+	inspection, err := readCurrentInspection(ctx, partNumber, serialNumber)
 	if err != nil {
 		return nil, err
 	}
-	defer resultsIterator.Close()
+	if inspection == nil {
+		return nil, fmt.Errorf("inspection %s does not exist", bladeKey(partNumber, serialNumber))
+	}
 
-	// Get current transaction metadata once
-	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
-	var blockchainTimestamp string
-	if err == nil {
-		t := time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos))
-		blockchainTimestamp = t.Format(time.RFC3339)
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP ID: %v", err)
 	}
-	txID := ctx.GetStub().GetTxID()
 
-	var inspections []*BladeInspection
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
+	var privateDataBytes []byte
+	if inspection.Organization == clientMSPID {
+		privateCollectionName, err := getPrivateCollectionName(ctx)
 		if err != nil {
 			return nil, err
 		}
-
-		var inspection BladeInspection
-		err = json.Unmarshal(queryResponse.Value, &inspection)
+		key, err := inspectionKey(ctx, inspection)
 		if err != nil {
 			return nil, err
 		}
+		privateDataBytes, err = ctx.GetStub().GetPrivateData(privateCollectionName, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private data: %v", err)
+		}
+		if privateDataBytes == nil {
+			return nil, fmt.Errorf("private data for inspection %s does not exist", bladeKey(partNumber, serialNumber))
+		}
+	} else {
+		// Unregistered and deactivated orgs cannot read shared data either
+		if _, err := getPrivateCollectionName(ctx); err != nil {
+			return nil, err
+		}
+		privateDataBytes, err = readSharedPrivate(ctx, inspection)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var privateData BladeInspectionPrivate
+	err = json.Unmarshal(privateDataBytes, &privateData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal private data: %v", err)
+	}
 
-		// Add blockchain metadata
-		inspection.BlockchainTimestamp = blockchainTimestamp
-		inspection.TxID = txID
+	return &privateData, nil
+}
 
-		inspections = append(inspections, &inspection)
+// GetBladeHistory retrieves every inspection event recorded for a blade in chronological order, each in
+// its current version with the TxID and timestamp of the transaction that committed it (see
+// GetBladeHistoryWithSuperseded for amended versions)
+func (s *SmartContract) GetBladeHistory(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) ([]*BladeInspection, error) {
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(inspectionPublicCollection,
+		inspectionObjectType, []string{partNumber, serialNumber})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %v", err)
 	}
+	defer resultsIterator.Close()
 
-	return inspections, nil
+	history, err := collectInspections(ctx, resultsIterator, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Composite keys sort by occasion label
+	sortChronologically(history)
+	return history, nil
+}
+
+// sortChronologically orders inspections by inspection date, then commit time
+func sortChronologically(inspections []*BladeInspection) {
+	sort.SliceStable(inspections, func(i, j int) bool {
+		ti, _ := time.Parse(time.RFC3339, inspections[i].InspectionDate)
+		tj, _ := time.Parse(time.RFC3339, inspections[j].InspectionDate)
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return inspections[i].BlockchainTimestamp < inspections[j].BlockchainTimestamp
+	})
+}
+
+// GetAllInspections retrieves current inspection status for all blades
+// (use GetInspectionsPage for large fleets)
+func (s *SmartContract) GetAllInspections(ctx contractapi.TransactionContextInterface) ([]*BladeInspection, error) {
+	var inspections []*BladeInspection
+	bookmark := ""
+	for {
+		page, err := s.GetInspectionsPage(ctx, maxPageSize, bookmark)
+		if err != nil {
+			return nil, err
+		}
+		inspections = append(inspections, page.Records...)
+
+		if page.Bookmark == "" {
+			return inspections, nil
+		}
+		bookmark = page.Bookmark
+	}
 }
 
 // InspectionExists checks if a blade has any inspection record
 func (s *SmartContract) InspectionExists(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (bool, error) {
-	key := fmt.Sprintf("%s_%s", partNumber, serialNumber)
-	inspectionJSON, err := ctx.GetStub().GetState(key)
+	publicData, err := readCurrentInspection(ctx, partNumber, serialNumber)
 	if err != nil {
-		return false, fmt.Errorf("failed to read from world state: %v", err)
+		return false, err
 	}
 
-	return inspectionJSON != nil, nil
+	return publicData != nil, nil
 }
 
 // GetInspectionCount returns total number of unique blades with inspections
 func (s *SmartContract) GetInspectionCount(ctx contractapi.TransactionContextInterface) (int, error) {
-	resultsIterator, err := ctx.GetStub().GetStateByRange("", "")
+	// Range queries skip composite keys, so only the current inspection of each blade is counted
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByRange(inspectionPublicCollection, "", "")
 	if err != nil {
-		return 0, err
+		return 0, fmt.Errorf("failed to read public data: %v", err)
 	}
 	defer resultsIterator.Close()
 
@@ -414,36 +717,18 @@ func (s *SmartContract) GetInspectionCount(ctx contractapi.TransactionContextInt
 	return count, nil
 }
 
-// GetInspectionsByOccasion retrieves all current inspections with a specific occasion label
+// GetInspectionsByOccasion retrieves all inspection events with a specific occasion label
 func (s *SmartContract) GetInspectionsByOccasion(ctx contractapi.TransactionContextInterface, occasionLabel string) ([]*BladeInspection, error) {
-	queryString := fmt.Sprintf(`{
-		"selector": {
-			"occasionLabel": "%s"
-		}
-	}`, occasionLabel)
-
-	resultsIterator, err := ctx.GetStub().GetQueryResult(queryString)
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(inspectionPublicCollection,
+		inspectionObjectType, []string{})
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to read public data: %v", err)
 	}
 	defer resultsIterator.Close()
 
-	var inspections []*BladeInspection
-	for resultsIterator.HasNext() {
-		queryResponse, err := resultsIterator.Next()
-		if err != nil {
-			return nil, err
-		}
-
-		var inspection BladeInspection
-		err = json.Unmarshal(queryResponse.Value, &inspection)
-		if err != nil {
-			return nil, err
-		}
-		inspections = append(inspections, &inspection)
-	}
-
-	return inspections, nil
+	return collectInspections(ctx, resultsIterator, func(publicData *BladeInspection) bool {
+		return publicData.OccasionLabel == occasionLabel
+	})
 }
 
 func main() {
@@ -0,0 +1,515 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/hyperledger/fabric-protos-go/ledger/queryresult"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// mockStub extends shimtest.MockStub with the private data range queries it does not implement
+type mockStub struct {
+	*shimtest.MockStub
+	events map[string]*peer.ChaincodeEvent // chaincode event by transaction ID
+}
+
+func newMockStub() *mockStub {
+	stub := &mockStub{MockStub: shimtest.NewMockStub("bladeinspection", nil), events: map[string]*peer.ChaincodeEvent{}}
+	stub.setInspector("J. Smith")
+	stub.TxID = "tx1"
+	stub.TxTimestamp = &timestamp.Timestamp{Seconds: 1760947200}
+	for _, serialNumber := range []string{"RGA46870", "RGA85382", "RGA85742", "RGA00000"} {
+		registerPart(stub, serialNumber, common.PartInService)
+	}
+	return stub
+}
+
+// registerPart writes a sample part 6A7614 with the given serial number and status to the registry
+func registerPart(stub *mockStub, serialNumber, status string) {
+	key, _ := stub.CreateCompositeKey(partObjectType, []string{"6A7614", serialNumber})
+	partJSON, _ := json.Marshal(common.Part{PartNumber: "6A7614", SerialNumber: serialNumber, Manufacturer: "ManufacturerMSP",
+		Material: "CMSX-4", ManufactureDate: "2019-03-14", Status: status, RegisteredBy: "ManufacturerMSP"})
+	_ = stub.PutState(key, partJSON)
+}
+
+// GetPrivateDataByRange returns the keys of a collection in lexical order within [startKey, endKey).
+// As on a peer, an empty start key excludes composite keys.
+func (stub *mockStub) GetPrivateDataByRange(collection, startKey, endKey string) (shim.StateQueryIteratorInterface, error) {
+	if startKey == "" {
+		startKey = "\x01"
+	}
+
+	var keys []string
+	for key := range stub.PvtState[collection] {
+		if key >= startKey && (endKey == "" || key < endKey) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	iter := &mockIterator{}
+	for _, key := range keys {
+		iter.results = append(iter.results, &queryresult.KV{Key: key, Value: stub.PvtState[collection][key]})
+	}
+	return iter, nil
+}
+
+// GetPrivateDataByPartialCompositeKey returns the composite keys of a collection sharing the given prefix
+func (stub *mockStub) GetPrivateDataByPartialCompositeKey(collection, objectType string, attributes []string) (shim.StateQueryIteratorInterface, error) {
+	prefix, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return nil, err
+	}
+	return stub.GetPrivateDataByRange(collection, prefix, prefix+string(utf8.MaxRune))
+}
+
+// GetPrivateDataHash returns the SHA-256 hash of a private data value, as every peer of the channel holds it
+func (stub *mockStub) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	value, ok := stub.PvtState[collection][key]
+	if !ok {
+		return nil, nil
+	}
+	hash := sha256.Sum256(value)
+	return hash[:], nil
+}
+
+// DelPrivateData deletes a private data value
+func (stub *mockStub) DelPrivateData(collection, key string) error {
+	delete(stub.PvtState[collection], key)
+	return nil
+}
+
+// SetEvent records the chaincode event of the current transaction. As on a peer, a later call replaces it.
+func (stub *mockStub) SetEvent(name string, payload []byte) error {
+	stub.events[stub.TxID] = &peer.ChaincodeEvent{TxId: stub.TxID, EventName: name, Payload: payload}
+	return nil
+}
+
+// testInspectorSalt is the inspector salt setInspector sends
+const testInspectorSalt = "9f4c1e7a2b8d3065"
+
+// setInspector sends the inspector of the next proposals with testInspectorSalt in the transient map, as the
+// clients do. An empty name sends no private fields.
+func (stub *mockStub) setInspector(inspector string) {
+	stub.TransientMap = nil
+	if inspector != "" {
+		stub.TransientMap = map[string][]byte{common.TransientPrivateKey: []byte(
+			`{"inspector":"` + inspector + `","inspectorSalt":"` + testInspectorSalt + `"}`)}
+	}
+}
+
+// setTransaction starts a new mock transaction with the given ID and timestamp
+func (stub *mockStub) setTransaction(txID string, seconds int64) {
+	stub.TxID = txID
+	stub.TxTimestamp = &timestamp.Timestamp{Seconds: seconds}
+}
+
+type mockIterator struct {
+	results []*queryresult.KV
+}
+
+func (it *mockIterator) HasNext() bool { return len(it.results) > 0 }
+func (it *mockIterator) Close() error  { return nil }
+
+func (it *mockIterator) Next() (*queryresult.KV, error) {
+	kv := it.results[0]
+	it.results = it.results[1:]
+	return kv, nil
+}
+
+// mockIdentity is a client identity with a fixed MSP ID and NodeOU
+type mockIdentity struct {
+	mspID string
+	ou    string
+	attrs map[string]string
+	name  string // common name, the MSP ID if empty
+}
+
+func (id *mockIdentity) GetID() (string, error) {
+	if id.name != "" {
+		return "x509::CN=" + id.name, nil
+	}
+	return "x509::CN=" + id.mspID, nil
+}
+
+func (id *mockIdentity) GetMSPID() (string, error)                 { return id.mspID, nil }
+func (id *mockIdentity) AssertAttributeValue(string, string) error { return nil }
+
+func (id *mockIdentity) GetAttributeValue(name string) (string, bool, error) {
+	value, found := id.attrs[name]
+	return value, found, nil
+}
+
+func (id *mockIdentity) GetX509Certificate() (*x509.Certificate, error) {
+	return &x509.Certificate{Subject: pkix.Name{CommonName: id.mspID, OrganizationalUnit: []string{id.ou}}}, nil
+}
+
+func newContext(stub *mockStub, mspID string) contractapi.TransactionContextInterface {
+	return newContextWithOU(stub, mspID, "client")
+}
+
+func newAdminContext(stub *mockStub, mspID string) contractapi.TransactionContextInterface {
+	return newContextWithOU(stub, mspID, "admin")
+}
+
+// certifiedInspector holds the certificate attributes of a level 2 dimensional inspector
+var certifiedInspector = map[string]string{"role": "inspector", "ndt.level": "2", "ndt.method": "dimensional"}
+
+func newContextWithOU(stub *mockStub, mspID, ou string) contractapi.TransactionContextInterface {
+	return newContextWithAttrs(stub, mspID, ou, certifiedInspector)
+}
+
+func newContextWithAttrs(stub *mockStub, mspID, ou string, attrs map[string]string) contractapi.TransactionContextInterface {
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(&mockIdentity{mspID: mspID, ou: ou, attrs: attrs})
+	return ctx
+}
+
+// newNamedContext returns the context of a client with its own identity ID
+func newNamedContext(stub *mockStub, mspID, name string, attrs map[string]string) contractapi.TransactionContextInterface {
+	ctx := new(contractapi.TransactionContext)
+	ctx.SetStub(stub)
+	ctx.SetClientIdentity(&mockIdentity{mspID: mspID, ou: "client", attrs: attrs, name: name})
+	return ctx
+}
+
+// inMillimetres fills the source values of m from its mm values (two decimal places)
+func inMillimetres(m ChordMeasurements) ChordMeasurements {
+	values := make([]string, len(chordPointNames))
+	for i, point := range m.points() {
+		values[i] = strconv.FormatFloat(point.Value, 'f', 2, 64) + " mm"
+	}
+	m.Unit = UnitMillimetre
+	m.SourcePrecision = 2
+	m.Source = ChordSourceValues{
+		AR: values[0], AP: values[1], AN: values[2], AM: values[3], AL: values[4], AK: values[5], AJ: values[6],
+		AH: values[7], AG: values[8], AF: values[9], AE: values[10], AD: values[11], AC: values[12], AB: values[13],
+	}
+	return m
+}
+
+func sampleInspection(serialNumber, occasion, organization string) string {
+	return sampleInspectionOn(serialNumber, occasion, "2025-10-20T08:00:00Z", organization)
+}
+
+func sampleInspectionOn(serialNumber, occasion, inspectionDate, organization string) string {
+	inspection := BladeInspection{
+		PartNumber:     "6A7614",
+		SerialNumber:   serialNumber,
+		OccasionLabel:  occasion,
+		InspectionDate: inspectionDate,
+		SubmittedAt:    "2025-10-20T08:05:00Z",
+		Organization:   organization,
+		Measurements: inMillimetres(ChordMeasurements{
+			AR: 243.04, AP: 251.18, AN: 259.19, AM: 263.54, AL: 264.42, AK: 264.63, AJ: 265.11,
+			AH: 267.61, AG: 269.70, AF: 272.86, AE: 276.36, AD: 279.39, AC: 283.06, AB: 256.12,
+		}),
+		CSVHash: "d2a84f4b8b650937ec8f73cd8be2c74add5a911ba64df27458ed8229da804a26",
+	}
+	inspectionJSON, _ := json.Marshal(inspection)
+	return string(inspectionJSON)
+}
+
+func TestChaincodeMetadata(t *testing.T) {
+	if _, err := contractapi.NewChaincode(&SmartContract{}); err != nil {
+		t.Fatalf("failed to create chaincode: %v", err)
+	}
+}
+
+func TestAddAndGetInspection(t *testing.T) {
+	for _, mspID := range []string{"MROLabMSP", "ManufacturerMSP"} {
+		t.Run(mspID, func(t *testing.T) {
+			stub := newMockStub()
+			ctx := newContext(stub, mspID)
+			contract := new(SmartContract)
+
+			_, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "before_surfacing", mspID))
+			if err != nil {
+				t.Fatalf("AddInspection failed: %v", err)
+			}
+
+			inspection, err := contract.GetInspection(ctx, "6A7614", "RGA46870")
+			if err != nil {
+				t.Fatalf("GetInspection failed: %v", err)
+			}
+			if inspection.Inspector != "J. Smith" || inspection.Organization != mspID {
+				t.Errorf("unexpected inspection: %+v", inspection)
+			}
+			if inspection.Measurements.AB != 256.12 {
+				t.Errorf("expected AB 256.12, got %v", inspection.Measurements.AB)
+			}
+
+			exists, err := contract.InspectionExists(ctx, "6A7614", "RGA46870")
+			if err != nil || !exists {
+				t.Errorf("expected inspection to exist, got %v (%v)", exists, err)
+			}
+
+			exists, err = contract.InspectionExists(ctx, "6A7614", "RGA00000")
+			if err != nil || exists {
+				t.Errorf("expected inspection not to exist, got %v (%v)", exists, err)
+			}
+		})
+	}
+}
+
+func TestInspectorHiddenFromOtherOrg(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	_, err := contract.AddInspection(newContext(stub, "MROLabMSP"), sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+
+	inspection, err := contract.GetInspection(newContext(stub, "ManufacturerMSP"), "6A7614", "RGA46870")
+	if err != nil {
+		t.Fatalf("GetInspection failed: %v", err)
+	}
+	if inspection.Inspector != "" {
+		t.Errorf("expected inspector to be hidden, got %q", inspection.Inspector)
+	}
+	if inspection.OccasionLabel != "manual" {
+		t.Errorf("expected occasion manual, got %q", inspection.OccasionLabel)
+	}
+
+	// The inspector, its claim and the submitting actor are only written to the submitting org's collection
+	for key, value := range stub.PvtState[inspectionPublicCollection] {
+		if strings.Contains(string(value), "J. Smith") {
+			t.Errorf("public record %q contains the inspector: %s", key, value)
+		}
+	}
+	if len(stub.PvtState["inspectionPrivateMROLabCollection"]) != 3 || len(stub.PvtState["inspectionPrivateManufacturerCollection"]) != 0 {
+		t.Errorf("expected the inspector in the MROLab collection only")
+	}
+}
+
+func TestAddInspectionStampsSubmitter(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	// An MROLab user cannot claim to be the manufacturer
+	_, err := contract.AddInspection(newContext(stub, "MROLabMSP"), sampleInspection("RGA46870", "manual", "ManufacturerMSP"))
+	if err == nil || !strings.Contains(err.Error(), "organization ManufacturerMSP does not match the submitter's MSP ID MROLabMSP") {
+		t.Fatalf("expected a claimed organization mismatch, got %v", err)
+	}
+	_, err = contract.AddInspectionsBatch(newContext(stub, "MROLabMSP"), "["+sampleInspection("RGA46870", "manual", "ManufacturerMSP")+"]")
+	if err == nil || !strings.Contains(err.Error(), "does not match the submitter's MSP ID") {
+		t.Fatalf("expected a claimed organization mismatch in a batch, got %v", err)
+	}
+
+	// Organization and inspector are taken from the certificate when omitted
+	stub.setInspector("")
+	_, err = contract.AddInspection(newContext(stub, "MROLabMSP"), sampleInspection("RGA46870", "manual", ""))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	inspection, err := contract.GetInspection(newContext(stub, "MROLabMSP"), "6A7614", "RGA46870")
+	if err != nil {
+		t.Fatalf("GetInspection failed: %v", err)
+	}
+	if inspection.Organization != "MROLabMSP" || inspection.Inspector != "MROLabMSP" {
+		t.Errorf("expected organization and inspector from the certificate, got %q and %q", inspection.Organization, inspection.Inspector)
+	}
+	if inspection.SubmittedBy != "x509::CN=MROLabMSP" || !strings.Contains(inspection.SubmitterSubject, "CN=MROLabMSP") {
+		t.Errorf("unexpected submitter %q (%q)", inspection.SubmittedBy, inspection.SubmitterSubject)
+	}
+
+	// The submitter is private to the submitting org
+	for key, value := range stub.PvtState[inspectionPublicCollection] {
+		if strings.Contains(string(value), "x509::") {
+			t.Errorf("public record %q contains the submitter: %s", key, value)
+		}
+	}
+}
+
+func TestQueriesReadPublicCollection(t *testing.T) {
+	stub := newMockStub()
+	mroCtx := newContext(stub, "MROLabMSP")
+	mfrCtx := newContext(stub, "ManufacturerMSP")
+	contract := new(SmartContract)
+
+	submissions := []struct {
+		ctx          contractapi.TransactionContextInterface
+		serialNumber string
+		occasion     string
+		organization string
+	}{
+		{mroCtx, "RGA46870", "before_surfacing", "MROLabMSP"},
+		{mroCtx, "RGA85382", "before_surfacing", "MROLabMSP"},
+		{mfrCtx, "RGA85742", "after_surfacing", "ManufacturerMSP"},
+	}
+	for _, sub := range submissions {
+		_, err := contract.AddInspection(sub.ctx, sampleInspection(sub.serialNumber, sub.occasion, sub.organization))
+		if err != nil {
+			t.Fatalf("AddInspection failed: %v", err)
+		}
+	}
+
+	for _, ctx := range []contractapi.TransactionContextInterface{mroCtx, mfrCtx} {
+		count, err := contract.GetInspectionCount(ctx)
+		if err != nil || count != 3 {
+			t.Errorf("expected count 3, got %d (%v)", count, err)
+		}
+
+		all, err := contract.GetAllInspections(ctx)
+		if err != nil || len(all) != 3 {
+			t.Fatalf("expected 3 inspections, got %d (%v)", len(all), err)
+		}
+
+		before, err := contract.GetInspectionsByOccasion(ctx, "before_surfacing")
+		if err != nil || len(before) != 2 {
+			t.Fatalf("expected 2 before_surfacing inspections, got %d (%v)", len(before), err)
+		}
+		for _, inspection := range before {
+			if inspection.OccasionLabel != "before_surfacing" {
+				t.Errorf("unexpected occasion %q", inspection.OccasionLabel)
+			}
+		}
+	}
+
+	// Each org only sees the inspector names from its own collection
+	all, _ := contract.GetAllInspections(mfrCtx)
+	for _, inspection := range all {
+		if (inspection.Inspector != "") != (inspection.Organization == "ManufacturerMSP") {
+			t.Errorf("unexpected inspector visibility for %s: %q", inspection.SerialNumber, inspection.Inspector)
+		}
+	}
+}
+
+func TestAddInspectionRejectsUnknownMSP(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	_, err := contract.AddInspection(newContext(stub, "RegulatorMSP"), sampleInspection("RGA46870", "manual", "RegulatorMSP"))
+	if err == nil {
+		t.Fatal("expected unknown MSP to be rejected")
+	}
+}
+
+func TestAddInspectionRequiresCertifiedInspector(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	inspection := sampleInspection("RGA46870", "manual", "MROLabMSP")
+
+	for _, attrs := range []map[string]string{
+		nil,
+		{"role": "inspector", "ndt.level": "1", "ndt.method": "dimensional"},
+		{"role": "inspector", "ndt.level": "2", "ndt.method": "thermography"},
+	} {
+		_, err := contract.AddInspection(newContextWithAttrs(stub, "MROLabMSP", "client", attrs), inspection)
+		if err == nil || !strings.HasPrefix(err.Error(), "only ") {
+			t.Errorf("%v: expected the caller to be rejected, got %v", attrs, err)
+		}
+		_, err = contract.AddInspectionsBatch(newContextWithAttrs(stub, "MROLabMSP", "client", attrs), "["+inspection+"]")
+		if err == nil || !strings.HasPrefix(err.Error(), "only ") {
+			t.Errorf("%v: expected the batch to be rejected, got %v", attrs, err)
+		}
+	}
+	if len(stub.PvtState) != 0 {
+		t.Errorf("expected nothing to be written")
+	}
+
+	supervisor := map[string]string{"role": "supervisor", "ndt.level": "3", "ndt.method": "dimensional,thermography"}
+	if _, err := contract.AddInspection(newContextWithAttrs(stub, "MROLabMSP", "client", supervisor), inspection); err != nil {
+		t.Errorf("expected a certified supervisor to submit, got %v", err)
+	}
+}
+
+func TestBladeHistoryKeepsEveryOccasion(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	events := []struct {
+		txID           string
+		occasion       string
+		inspectionDate string
+		mspID          string
+	}{
+		{"tx-after", "after_surfacing", "2025-10-22T08:00:00Z", "ManufacturerMSP"},
+		{"tx-before", "before_surfacing", "2025-10-20T08:00:00Z", "MROLabMSP"},
+		{"tx-manual", "manual", "2025-10-21T08:00:00Z", "MROLabMSP"},
+	}
+	for i, event := range events {
+		stub.setTransaction(event.txID, 1760947200+int64(i)*3600)
+		stub.setInspector("Inspector " + event.mspID)
+		_, err := contract.AddInspection(newContext(stub, event.mspID),
+			sampleInspectionOn("RGA46870", event.occasion, event.inspectionDate, event.mspID))
+		if err != nil {
+			t.Fatalf("AddInspection %s failed: %v", event.occasion, err)
+		}
+	}
+
+	ctx := newContext(stub, "MROLabMSP")
+	history, err := contract.GetBladeHistory(ctx, "6A7614", "RGA46870")
+	if err != nil {
+		t.Fatalf("GetBladeHistory failed: %v", err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("expected 3 history records, got %d", len(history))
+	}
+
+	expected := []struct{ occasion, txID, timestamp string }{
+		{"before_surfacing", "tx-before", "2025-10-20T09:00:00Z"},
+		{"manual", "tx-manual", "2025-10-20T10:00:00Z"},
+		{"after_surfacing", "tx-after", "2025-10-20T08:00:00Z"},
+	}
+	for i, want := range expected {
+		got := history[i]
+		if got.OccasionLabel != want.occasion || got.TxID != want.txID || got.BlockchainTimestamp != want.timestamp {
+			t.Errorf("history[%d] = %s/%s/%s, expected %s/%s/%s", i,
+				got.OccasionLabel, got.TxID, got.BlockchainTimestamp, want.occasion, want.txID, want.timestamp)
+		}
+	}
+	if history[0].Inspector != "Inspector MROLabMSP" || history[2].Inspector != "" {
+		t.Errorf("unexpected inspector visibility: %q, %q", history[0].Inspector, history[2].Inspector)
+	}
+
+	// The current inspection is the latest by inspection date, not by submission order
+	current, err := contract.GetInspection(ctx, "6A7614", "RGA46870")
+	if err != nil {
+		t.Fatalf("GetInspection failed: %v", err)
+	}
+	if current.OccasionLabel != "after_surfacing" || current.TxID != "tx-after" {
+		t.Errorf("unexpected current inspection: %s (%s)", current.OccasionLabel, current.TxID)
+	}
+
+	count, err := contract.GetInspectionCount(ctx)
+	if err != nil || count != 1 {
+		t.Errorf("expected 1 blade, got %d (%v)", count, err)
+	}
+
+	manual, err := contract.GetInspectionsByOccasion(ctx, "manual")
+	if err != nil || len(manual) != 1 || manual[0].TxID != "tx-manual" {
+		t.Errorf("expected the manual inspection, got %v (%v)", manual, err)
+	}
+}
+
+func TestAddInspectionRejectsDuplicateEvent(t *testing.T) {
+	stub := newMockStub()
+	ctx := newContext(stub, "MROLabMSP")
+	contract := new(SmartContract)
+
+	_, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+
+	_, err = contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err == nil {
+		t.Fatal("expected duplicate inspection event to be rejected")
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// BladeInspectionEvent is the payload of the InspectionAdded, InspectionAmended and OutOfTolerance
+// events emitted by AddInspection, AddInspectionsBatch and AmendInspection. It lists the inspections
+// the transaction wrote, leaving out resubmitted duplicates, and holds public fields only.
+type BladeInspectionEvent struct {
+	Organization string                `json:"organization"`
+	CSVHash      string                `json:"csvHash,omitempty"`
+	TxID         string                `json:"txId"`
+	Inspections  []InspectionEventItem `json:"inspections"`
+}
+
+// InspectionEventItem identifies an inspection written by the transaction with its assessed conformance
+type InspectionEventItem struct {
+	Key                  string   `json:"key"`
+	PartNumber           string   `json:"partNumber"`
+	SerialNumber         string   `json:"serialNumber"`
+	OccasionLabel        string   `json:"occasionLabel"`
+	InspectionDate       string   `json:"inspectionDate"`
+	Disposition          string   `json:"disposition"`
+	OutOfLimitPoints     []string `json:"outOfLimitPoints,omitempty"`
+	ToleranceSpecVersion int      `json:"toleranceSpecVersion,omitempty"`
+	SubmissionID         string   `json:"submissionId"`
+	Version              int      `json:"version,omitempty"` // above 1 for an amended version
+}
+
+// newInspectionEventItem returns the event item of an inspection stored under key
+func newInspectionEventItem(key string, inspection *BladeInspection) InspectionEventItem {
+	return InspectionEventItem{
+		Key:                  key,
+		PartNumber:           inspection.PartNumber,
+		SerialNumber:         inspection.SerialNumber,
+		OccasionLabel:        inspection.OccasionLabel,
+		InspectionDate:       inspection.InspectionDate,
+		Disposition:          inspection.Disposition,
+		OutOfLimitPoints:     inspection.OutOfLimitPoints,
+		ToleranceSpecVersion: inspection.ToleranceSpecVersion,
+		SubmissionID:         inspection.SubmissionID,
+		Version:              inspection.Version,
+	}
+}
+
+// setInspectionEvent emits OutOfTolerance if any written inspection has points outside serviceable
+// limits, otherwise InspectionAmended for an amended version and InspectionAdded for new inspections,
+// with the same payload. Nothing is emitted when nothing was written.
+func setInspectionEvent(ctx contractapi.TransactionContextInterface, organization, csvHash string, items []InspectionEventItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	name := common.EventInspectionAdded
+	for _, item := range items {
+		if item.Version > 1 {
+			name = common.EventInspectionAmended
+		}
+	}
+	for _, item := range items {
+		if len(item.OutOfLimitPoints) > 0 {
+			name = common.EventOutOfTolerance
+			break
+		}
+	}
+
+	return common.SetEvent(ctx.GetStub(), name, &BladeInspectionEvent{
+		Organization: organization,
+		CSVHash:      csvHash,
+		TxID:         ctx.GetStub().GetTxID(),
+		Inspections:  items,
+	})
+}
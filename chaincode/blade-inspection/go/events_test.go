@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// eventPayload returns the name and blade inspection payload of a transaction's event
+func eventPayload(t *testing.T, stub *mockStub, txID string) (string, *BladeInspectionEvent) {
+	t.Helper()
+	event := stub.events[txID]
+	if event == nil {
+		t.Fatalf("expected an event for %s", txID)
+	}
+	var payload BladeInspectionEvent
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		t.Fatalf("failed to unmarshal payload: %v", err)
+	}
+	return event.EventName, &payload
+}
+
+func TestAddInspectionEmitsEvents(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+	if _, err := contract.SetToleranceSpec(newAdminContext(stub, "ManufacturerMSP"), "6A7614", sampleLimits(nominalChords)); err != nil {
+		t.Fatalf("SetToleranceSpec failed: %v", err)
+	}
+
+	_, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	name, payload := eventPayload(t, stub, "tx1")
+	if name != common.EventInspectionAdded || payload.Organization != "MROLabMSP" || len(payload.Inspections) != 1 ||
+		payload.Inspections[0].SerialNumber != "RGA46870" || payload.Inspections[0].Disposition != DispositionServiceable {
+		t.Errorf("unexpected %s event %+v", name, payload)
+	}
+	if strings.Contains(string(stub.events["tx1"].Payload), "J. Smith") {
+		t.Errorf("expected public fields only, got %s", stub.events["tx1"].Payload)
+	}
+
+	// A resubmission emits nothing
+	stub.setTransaction("tx2", 1760947260)
+	if _, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP")); err != nil {
+		t.Fatalf("resubmission failed: %v", err)
+	}
+	if event := stub.events["tx2"]; event != nil {
+		t.Errorf("expected no event for a resubmission, got %s", event.EventName)
+	}
+}
+
+func TestAddInspectionsBatchEmitsOutOfTolerance(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+	if _, err := contract.SetToleranceSpec(newAdminContext(stub, "ManufacturerMSP"), "6A7614", sampleLimits(nominalChords)); err != nil {
+		t.Fatalf("SetToleranceSpec failed: %v", err)
+	}
+
+	first := sampleInspection("RGA46870", "manual", "MROLabMSP")
+	if _, err := contract.AddInspection(ctx, first); err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+
+	var scrap BladeInspection
+	_ = json.Unmarshal([]byte(sampleInspection("RGA85382", "manual", "MROLabMSP")), &scrap)
+	scrap.Measurements.Source.AC = "281.00 mm"
+	scrapJSON, _ := json.Marshal(scrap)
+
+	stub.setTransaction("tx2", 1760947260)
+	_, err := contract.AddInspectionsBatch(ctx, batchJSON(t, first, string(scrapJSON)))
+	if err != nil {
+		t.Fatalf("AddInspectionsBatch failed: %v", err)
+	}
+	name, payload := eventPayload(t, stub, "tx2")
+	if name != common.EventOutOfTolerance || payload.CSVHash == "" || payload.TxID != "tx2" {
+		t.Errorf("unexpected %s event %+v", name, payload)
+	}
+	// The duplicate is not listed again
+	if len(payload.Inspections) != 1 || payload.Inspections[0].SerialNumber != "RGA85382" ||
+		payload.Inspections[0].Disposition != DispositionScrap || len(payload.Inspections[0].OutOfLimitPoints) != 1 {
+		t.Errorf("unexpected inspections %+v", payload.Inspections)
+	}
+
+	// A batch of duplicates emits nothing
+	stub.setTransaction("tx3", 1760947320)
+	if _, err := contract.AddInspectionsBatch(ctx, batchJSON(t, first, string(scrapJSON))); err != nil {
+		t.Fatalf("resubmission failed: %v", err)
+	}
+	if event := stub.events["tx3"]; event != nil {
+		t.Errorf("expected no event for duplicates, got %s", event.EventName)
+	}
+}
@@ -2,7 +2,13 @@ module github.com/mahmoudhafez3/thermotrace/chaincode/blade-inspection
 
 go 1.21
 
-require github.com/hyperledger/fabric-contract-api-go v1.2.2
+require (
+	github.com/golang/protobuf v1.5.3
+	github.com/hyperledger/fabric-chaincode-go v0.0.0-20230731094759-d626e9ab09b9
+	github.com/hyperledger/fabric-contract-api-go v1.2.2
+	github.com/hyperledger/fabric-protos-go v0.3.0
+	github.com/mahmoudhafez3/thermotrace/chaincode/common v0.0.0-00010101000000-000000000000
+)
 
 require (
 	github.com/go-openapi/jsonpointer v0.20.0 // indirect
@@ -12,9 +18,6 @@ require (
 	github.com/gobuffalo/envy v1.10.2 // indirect
 	github.com/gobuffalo/packd v1.0.2 // indirect
 	github.com/gobuffalo/packr v1.30.1 // indirect
-	github.com/golang/protobuf v1.5.3 // indirect
-	github.com/hyperledger/fabric-chaincode-go v0.0.0-20230731094759-d626e9ab09b9 // indirect
-	github.com/hyperledger/fabric-protos-go v0.3.0 // indirect
 	github.com/joho/godotenv v1.5.1 // indirect
 	github.com/josharian/intern v1.0.0 // indirect
 	github.com/mailru/easyjson v0.7.7 // indirect
@@ -31,3 +34,6 @@ require (
 	google.golang.org/protobuf v1.31.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
+
+// Shared ledger helpers, vendored when the chaincode is packaged
+replace github.com/mahmoudhafez3/thermotrace/chaincode/common => ../../common/go
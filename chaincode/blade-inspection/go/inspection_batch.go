@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// maxBatchSize limits the number of inspections in one AddInspectionsBatch transaction
+const maxBatchSize = 100
+
+// inspectionBatchObjectType prefixes the composite keys of batch records: inspectionBatch~CSVHash~TxID
+const inspectionBatchObjectType = "inspectionBatch"
+
+// BatchItemResult identifies an inspection written by AddInspectionsBatch
+type BatchItemResult struct {
+	Index          int    `json:"index"`
+	Key            string `json:"key"`
+	PartNumber     string `json:"partNumber"`
+	SerialNumber   string `json:"serialNumber"`
+	OccasionLabel  string `json:"occasionLabel"`
+	InspectionDate string `json:"inspectionDate"`
+	Disposition    string `json:"disposition"`
+	Duplicate      bool   `json:"duplicate,omitempty"` // resubmitted: recorded by an earlier transaction
+}
+
+// InspectionBatch records which inspections were imported from a CSV file in a single transaction
+type InspectionBatch struct {
+	CSVHash             string            `json:"csvHash"`
+	Organization        string            `json:"organization"`
+	Count               int               `json:"count"`
+	Items               []BatchItemResult `json:"items"`
+	TxID                string            `json:"txId"`
+	BlockchainTimestamp string            `json:"blockchainTimestamp"`
+}
+
+// AddInspectionsBatch validates and writes an array of inspections from one CSV file in a single transaction.
+// Any invalid item fails the whole batch, so either every inspection is written or none is. Field
+// errors are reported with the item's index as prefix, e.g. "3.measurements.source.ar". Items already
+// recorded with identical content are returned as duplicates, so a failed import can be resubmitted;
+// a batch made up only of duplicates writes nothing. The written inspections are listed in a single
+// OutOfTolerance event if any of them has points outside serviceable limits, InspectionAdded otherwise.
+// Private fields are sent in the transient map under "private", as one object for every item or an array
+// with one object per item. Only certified inspectors may submit batches.
+func (s *SmartContract) AddInspectionsBatch(ctx contractapi.TransactionContextInterface, inspectionsJSON string) (*InspectionBatch, error) {
+	err := checkInspector(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []json.RawMessage
+	err = json.Unmarshal([]byte(inspectionsJSON), &items)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inspections: %v", err)
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("batch is empty")
+	}
+	if len(items) > maxBatchSize {
+		return nil, fmt.Errorf("batch contains %d inspections, the maximum is %d", len(items), maxBatchSize)
+	}
+
+	// Validate every item before writing any, reporting field errors under the item's index
+	var errs common.FieldErrors
+	inspections := make([]*BladeInspection, len(items))
+	for i, item := range items {
+		var itemErrs common.FieldErrors
+		inspections[i], itemErrs = validateInspection(item)
+		errs = append(errs, itemErrs.Prefixed(strconv.Itoa(i))...)
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	err = readTransientPrivate(ctx, inspections)
+	if err != nil {
+		return nil, err
+	}
+
+	// Every item must come from the same CSV file
+	csvHash := inspections[0].CSVHash
+	if csvHash == "" {
+		return nil, fmt.Errorf("csvHash is required for batch submissions")
+	}
+
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	batch := &InspectionBatch{
+		CSVHash:             csvHash,
+		Count:               len(inspections),
+		TxID:                ctx.GetStub().GetTxID(),
+		BlockchainTimestamp: time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).UTC().Format(time.RFC3339),
+	}
+	var written []InspectionEventItem
+
+	writes := newTxWrites()
+	for i := range inspections {
+		if inspections[i].CSVHash != csvHash {
+			return nil, fmt.Errorf("inspection %d: csvHash does not match the batch", i)
+		}
+
+		publicData, key, err := s.addInspection(ctx, inspections[i], writes)
+		var conflict *common.SubmissionConflictError
+		if errors.As(err, &conflict) {
+			// Returned as is, so clients can parse it; the submission ID identifies the item
+			return nil, conflict
+		}
+		if err != nil {
+			return nil, fmt.Errorf("inspection %d (%s): %v", i, bladeKey(inspections[i].PartNumber, inspections[i].SerialNumber), err)
+		}
+
+		duplicate := publicData.TxID != batch.TxID
+		if !duplicate {
+			written = append(written, newInspectionEventItem(key, publicData))
+		}
+		batch.Organization = publicData.Organization
+		batch.Items = append(batch.Items, BatchItemResult{
+			Index:          i,
+			Key:            key,
+			PartNumber:     publicData.PartNumber,
+			SerialNumber:   publicData.SerialNumber,
+			OccasionLabel:  publicData.OccasionLabel,
+			InspectionDate: publicData.InspectionDate,
+			Disposition:    publicData.Disposition,
+			Duplicate:      duplicate,
+		})
+	}
+	if len(written) == 0 {
+		return batch, nil
+	}
+
+	batchKey, err := ctx.GetStub().CreateCompositeKey(inspectionBatchObjectType, []string{csvHash, batch.TxID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch key: %v", err)
+	}
+	batchBytes, err := json.Marshal(batch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData(inspectionPublicCollection, batchKey, batchBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write batch: %v", err)
+	}
+
+	err = setInspectionEvent(ctx, batch.Organization, csvHash, written)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// GetInspectionBatches retrieves the batch transactions that imported a CSV file
+func (s *SmartContract) GetInspectionBatches(ctx contractapi.TransactionContextInterface, csvHash string) ([]*InspectionBatch, error) {
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByPartialCompositeKey(inspectionPublicCollection,
+		inspectionBatchObjectType, []string{csvHash})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batches: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	batches := []*InspectionBatch{}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		var batch InspectionBatch
+		err = json.Unmarshal(queryResponse.Value, &batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal batch: %v", err)
+		}
+		batches = append(batches, &batch)
+	}
+
+	return batches, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func batchJSON(t *testing.T, inspections ...string) string {
+	t.Helper()
+	items := make([]json.RawMessage, len(inspections))
+	for i, inspection := range inspections {
+		items[i] = json.RawMessage(inspection)
+	}
+	data, err := json.Marshal(items)
+	if err != nil {
+		t.Fatalf("failed to marshal batch: %v", err)
+	}
+	return string(data)
+}
+
+func TestAddInspectionsBatch(t *testing.T) {
+	stub := newMockStub()
+	stub.setTransaction("tx-batch", 1760947200)
+	ctx := newContext(stub, "MROLabMSP")
+	contract := new(SmartContract)
+
+	batch, err := contract.AddInspectionsBatch(ctx, batchJSON(t,
+		sampleInspectionOn("RGA46870", "after_surfacing", "2025-10-22T08:00:00Z", "MROLabMSP"),
+		sampleInspectionOn("RGA46870", "before_surfacing", "2025-10-20T08:00:00Z", "MROLabMSP"),
+		sampleInspectionOn("RGA85382", "before_surfacing", "2025-10-20T08:00:00Z", "MROLabMSP"),
+	))
+	if err != nil {
+		t.Fatalf("AddInspectionsBatch failed: %v", err)
+	}
+	if batch.Count != 3 || len(batch.Items) != 3 || batch.TxID != "tx-batch" {
+		t.Fatalf("unexpected batch: %+v", batch)
+	}
+	for i, item := range batch.Items {
+		if item.Index != i || item.Key == "" {
+			t.Errorf("unexpected item %d: %+v", i, item)
+		}
+	}
+
+	// The later inspection stays current even though the earlier one was written after it in the batch
+	current, err := contract.GetInspection(ctx, "6A7614", "RGA46870")
+	if err != nil || current.OccasionLabel != "after_surfacing" {
+		t.Fatalf("expected after_surfacing to be current, got %+v (%v)", current, err)
+	}
+
+	count, err := contract.GetInspectionCount(ctx)
+	if err != nil || count != 2 {
+		t.Errorf("expected 2 blades, got %d (%v)", count, err)
+	}
+
+	batches, err := contract.GetInspectionBatches(ctx, batch.CSVHash)
+	if err != nil || len(batches) != 1 || batches[0].Count != 3 {
+		t.Errorf("expected the batch to be recorded, got %v (%v)", batches, err)
+	}
+}
+
+func TestAddInspectionsBatchRejectsInvalidBatches(t *testing.T) {
+	contract := new(SmartContract)
+	item := sampleInspection("RGA46870", "manual", "MROLabMSP")
+
+	var tooMany []string
+	for i := 0; i <= maxBatchSize; i++ {
+		tooMany = append(tooMany, sampleInspection(fmt.Sprintf("RGA%05d", i), "manual", "MROLabMSP"))
+	}
+
+	var otherFile BladeInspection
+	_ = json.Unmarshal([]byte(sampleInspection("RGA85382", "manual", "MROLabMSP")), &otherFile)
+	otherFile.CSVHash = strings.Repeat("0", 64)
+	otherFileJSON, _ := json.Marshal(otherFile)
+
+	invalid := sampleInspection("RGA85742", "manual", "MROLabMSP")
+	invalid = strings.Replace(invalid, `"ab":"256.12 mm"`, `"ab":""`, 1)
+
+	for _, tc := range []struct {
+		name      string
+		batch     string
+		errorText string
+	}{
+		{"empty", "[]", "batch is empty"},
+		{"too large", batchJSON(t, tooMany...), "maximum is 100"},
+		{"duplicate in batch", batchJSON(t, item, item), "inspection 1 (6A7614_RGA46870): inspection 6A7614 RGA46870 (manual, 2025-10-20T08:00:00Z) already exists"},
+		{"mixed files", batchJSON(t, item, string(otherFileJSON)), "csvHash does not match"},
+		{"invalid item", batchJSON(t, item, invalid), `{"field":"1.measurements.source.ab","code":"invalid_value","message":"value is missing"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := contract.AddInspectionsBatch(newContext(newMockStub(), "MROLabMSP"), tc.batch)
+			if err == nil || !strings.Contains(err.Error(), tc.errorText) {
+				t.Errorf("expected error containing %q, got %v", tc.errorText, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// inspectionSharedCollection holds private data an org has shared with another org (collections_config.json)
+const inspectionSharedCollection = "inspectionSharedCollection"
+
+// inspectionSharedCollectionMembers are the orgs in the policy of inspectionSharedCollection. Every member
+// peer holds every share, so the chaincode only returns a share to its grantee. Membership is fixed by the
+// collection definition: orgs added to the registry later cannot receive shares until the collection
+// definition and this list are updated together.
+var inspectionSharedCollectionMembers = map[string]bool{"ManufacturerMSP": true, "MROLabMSP": true}
+
+// checkSharedCollectionMember returns an error unless an org can hold shares in inspectionSharedCollection
+func checkSharedCollectionMember(mspID string) error {
+	if !inspectionSharedCollectionMembers[mspID] {
+		return fmt.Errorf("organization %s is not a member of %s, so private data cannot be shared with it",
+			mspID, inspectionSharedCollection)
+	}
+	return nil
+}
+
+// inspectionShareObjectType prefixes the keys of shared private data:
+// inspectionShare~GranteeMSP~PartNumber~SerialNumber~OccasionLabel~InspectionDate
+const inspectionShareObjectType = "inspectionShare"
+
+// shareKey returns the key under which an inspection event's private data is shared with an org
+func shareKey(ctx contractapi.TransactionContextInterface, grantee string, inspection *BladeInspection) (string, error) {
+	return common.CompositeKey(ctx.GetStub(), inspectionShareObjectType, grantee,
+		inspection.PartNumber, inspection.SerialNumber, inspection.OccasionLabel, inspection.InspectionDate)
+}
+
+// inspectorClaimObjectType prefixes the private keys under which the inspector of an inspection event is
+// stored on its own with its salt, so that a claimed inspector can be verified without the submitter
+// identity: inspectorClaim~PartNumber~SerialNumber~OccasionLabel~InspectionDate
+const inspectorClaimObjectType = "inspectorClaim"
+
+// minInspectorSaltLength is the shortest inspector salt accepted. Every org can read the hash of an
+// inspector claim, so without a salt that cannot be guessed the inspector could be found by trying names.
+const minInspectorSaltLength = 16
+
+// inspectorClaimKey returns the private key of the inspector of an inspection event
+func inspectorClaimKey(ctx contractapi.TransactionContextInterface, inspection *BladeInspection) (string, error) {
+	return common.CompositeKey(ctx.GetStub(), inspectorClaimObjectType,
+		inspection.PartNumber, inspection.SerialNumber, inspection.OccasionLabel, inspection.InspectionDate)
+}
+
+// marshalInspectorClaim encodes the inspector and its salt the way they are stored under the inspector claim key
+func marshalInspectorClaim(inspector, salt string) ([]byte, error) {
+	claimJSON, err := json.Marshal(BladeInspectionPrivate{Inspector: inspector, InspectorSalt: salt})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inspector claim: %v", err)
+	}
+	return claimJSON, nil
+}
+
+// putInspectorClaim writes the inspector of an inspection event with its salt to the owning org's private
+// collection. Without a salt the claim of an earlier version is removed and none is written, since its
+// hash would give the inspector away.
+func putInspectorClaim(ctx contractapi.TransactionContextInterface, privateCollectionName string, inspection *BladeInspection) error {
+	key, err := inspectorClaimKey(ctx, inspection)
+	if err != nil {
+		return err
+	}
+	if inspection.InspectorSalt == "" {
+		err = ctx.GetStub().DelPrivateData(privateCollectionName, key)
+		if err != nil {
+			return fmt.Errorf("failed to delete inspector claim: %v", err)
+		}
+		return nil
+	}
+	claimJSON, err := marshalInspectorClaim(inspection.Inspector, inspection.InspectorSalt)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutPrivateData(privateCollectionName, key, claimJSON)
+	if err != nil {
+		return fmt.Errorf("failed to write inspector claim: %v", err)
+	}
+	return nil
+}
+
+// ownedCurrentInspection reads a blade's current inspection and checks that it was submitted by the caller's org
+func ownedCurrentInspection(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (*BladeInspection, error) {
+	inspection, err := readCurrentInspection(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if inspection == nil {
+		return nil, fmt.Errorf("inspection %s does not exist", bladeKey(partNumber, serialNumber))
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if inspection.Organization != clientMSPID {
+		return nil, fmt.Errorf("only the owning organization %s can share inspection %s",
+			inspection.Organization, bladeKey(partNumber, serialNumber))
+	}
+	return inspection, nil
+}
+
+// ShareInspectionPrivate grants another org access to the private data (inspector and submitter) of a
+// blade's current inspection by copying it, unchanged, from the caller's collection to the shared
+// collection, from which only the grantee can read it. Only the org that submitted the inspection can
+// share it, and only with members of the shared collection (see inspectionSharedCollectionMembers).
+func (s *SmartContract) ShareInspectionPrivate(ctx contractapi.TransactionContextInterface,
+	partNumber, serialNumber, granteeMSPID string) error {
+
+	inspection, err := ownedCurrentInspection(ctx, partNumber, serialNumber)
+	if err != nil {
+		return err
+	}
+
+	registry, err := common.LoadRegistry[registryDefaults](ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := registry.Lookup(granteeMSPID); err != nil {
+		return err
+	}
+	if granteeMSPID == inspection.Organization {
+		return fmt.Errorf("cannot share inspection %s with its owning organization", bladeKey(partNumber, serialNumber))
+	}
+	if err := checkSharedCollectionMember(granteeMSPID); err != nil {
+		return err
+	}
+
+	privateCollectionName, err := getPrivateCollectionName(ctx)
+	if err != nil {
+		return err
+	}
+	key, err := inspectionKey(ctx, inspection)
+	if err != nil {
+		return err
+	}
+	privateDataBytes, err := ctx.GetStub().GetPrivateData(privateCollectionName, key)
+	if err != nil {
+		return fmt.Errorf("failed to read private data: %v", err)
+	}
+	if privateDataBytes == nil {
+		return fmt.Errorf("private data for inspection %s does not exist", bladeKey(partNumber, serialNumber))
+	}
+
+	sharedKey, err := shareKey(ctx, granteeMSPID, inspection)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().PutPrivateData(inspectionSharedCollection, sharedKey, privateDataBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write shared private data: %v", err)
+	}
+	return nil
+}
+
+// RevokeInspectionPrivateShare removes an org's access to the private data of a blade's current inspection
+func (s *SmartContract) RevokeInspectionPrivateShare(ctx contractapi.TransactionContextInterface,
+	partNumber, serialNumber, granteeMSPID string) error {
+
+	inspection, err := ownedCurrentInspection(ctx, partNumber, serialNumber)
+	if err != nil {
+		return err
+	}
+
+	sharedKey, err := shareKey(ctx, granteeMSPID, inspection)
+	if err != nil {
+		return err
+	}
+	err = ctx.GetStub().DelPrivateData(inspectionSharedCollection, sharedKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete shared private data: %v", err)
+	}
+	return nil
+}
+
+// ownerCollection returns the private collection of the org that submitted an inspection. The org
+// may since have been deactivated, so the registry is read directly.
+func ownerCollection(ctx contractapi.TransactionContextInterface, inspection *BladeInspection) (string, error) {
+	registry, err := common.LoadRegistry[registryDefaults](ctx)
+	if err != nil {
+		return "", err
+	}
+	owner, ok := registry.Organizations[inspection.Organization]
+	if !ok {
+		return "", fmt.Errorf("organization %s is not registered", inspection.Organization)
+	}
+	return owner.PrivateCollection, nil
+}
+
+// readSharedPrivate reads the private data of an inspection event shared with the caller's org. Every
+// member of the shared collection can read every share from its peer, so the grantee is always the caller.
+// The copy is checked against the hash of the owner's original, so a grantee can rely on it.
+func readSharedPrivate(ctx contractapi.TransactionContextInterface, inspection *BladeInspection) ([]byte, error) {
+	grantee, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if err := checkSharedCollectionMember(grantee); err != nil {
+		return nil, err
+	}
+	sharedKey, err := shareKey(ctx, grantee, inspection)
+	if err != nil {
+		return nil, err
+	}
+	sharedBytes, err := ctx.GetStub().GetPrivateData(inspectionSharedCollection, sharedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read shared private data: %v", err)
+	}
+	if sharedBytes == nil {
+		return nil, fmt.Errorf("private data for inspection %s has not been shared with %s",
+			bladeKey(inspection.PartNumber, inspection.SerialNumber), grantee)
+	}
+
+	collection, err := ownerCollection(ctx, inspection)
+	if err != nil {
+		return nil, err
+	}
+	key, err := inspectionKey(ctx, inspection)
+	if err != nil {
+		return nil, err
+	}
+	verification, err := common.VerifyPrivateData(ctx.GetStub(), collection, key, sharedBytes)
+	if err != nil {
+		return nil, err
+	}
+	if !verification.Match {
+		return nil, fmt.Errorf("shared private data for inspection %s does not match the hash of %s's original",
+			bladeKey(inspection.PartNumber, inspection.SerialNumber), inspection.Organization)
+	}
+	return sharedBytes, nil
+}
+
+// VerifyInspectionPrivate checks a candidate private record of an inspection event against the hash of
+// the submitting org's private data, so an auditor of any org can confirm a claim without the data being
+// disclosed. A candidate with only the inspector and its salt ({"inspector":"...","inspectorSalt":"..."},
+// the salt given to the auditor by the owner, who reads it with GetInspectionPrivate) is checked against
+// the inspector stored on its own; one that also has submittedBy and submitterSubject against the whole
+// private record.
+// Evaluate it rather than submit it, so the candidate is never written to a block.
+func (s *SmartContract) VerifyInspectionPrivate(ctx contractapi.TransactionContextInterface, partNumber, serialNumber,
+	occasionLabel, inspectionDate string, candidateJSON string) (*common.PrivateDataVerification, error) {
+
+	key, err := inspectionKey(ctx, &BladeInspection{PartNumber: partNumber, SerialNumber: serialNumber,
+		OccasionLabel: occasionLabel, InspectionDate: inspectionDate})
+	if err != nil {
+		return nil, err
+	}
+	publicDataBytes, err := ctx.GetStub().GetPrivateData(inspectionPublicCollection, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public data: %v", err)
+	}
+	if publicDataBytes == nil {
+		return nil, fmt.Errorf("inspection %s %s (%s, %s) does not exist", partNumber, serialNumber, occasionLabel, inspectionDate)
+	}
+
+	var inspection BladeInspection
+	err = json.Unmarshal(publicDataBytes, &inspection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal public data: %v", err)
+	}
+
+	var candidate BladeInspection
+	err = common.UnmarshalPrivate([]byte(candidateJSON), &candidate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal candidate: %v", err)
+	}
+
+	collection, err := ownerCollection(ctx, &inspection)
+	if err != nil {
+		return nil, err
+	}
+
+	// Auditors rarely know the submitter identity, so an inspector on its own is checked against its claim key
+	if candidate.SubmittedBy == "" && candidate.SubmitterSubject == "" {
+		claimKey, err := inspectorClaimKey(ctx, &inspection)
+		if err != nil {
+			return nil, err
+		}
+		if candidate.InspectorSalt == "" {
+			return nil, fmt.Errorf("a candidate inspector needs the inspectorSalt recorded with the inspection")
+		}
+		claimBytes, err := marshalInspectorClaim(candidate.Inspector, candidate.InspectorSalt)
+		if err != nil {
+			return nil, err
+		}
+		return common.VerifyPrivateData(ctx.GetStub(), collection, claimKey, claimBytes)
+	}
+
+	// Re-encode the candidate the way AddInspection encodes private data, so that equal records hash equally
+	candidateBytes, err := common.MarshalPrivate(candidate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal candidate: %v", err)
+	}
+	return common.VerifyPrivateData(ctx.GetStub(), collection, key, candidateBytes)
+}
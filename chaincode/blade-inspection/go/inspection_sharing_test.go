@@ -0,0 +1,254 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+func TestGetInspectionPrivateReadsOwnCollection(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	_, err := contract.AddInspection(newContext(stub, "MROLabMSP"), sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+
+	private, err := contract.GetInspectionPrivate(newContext(stub, "MROLabMSP"), "6A7614", "RGA46870")
+	if err != nil {
+		t.Fatalf("GetInspectionPrivate failed: %v", err)
+	}
+	if private.Inspector != "J. Smith" || private.SubmittedBy != "x509::CN=MROLabMSP" {
+		t.Errorf("unexpected private data %+v", private)
+	}
+
+	_, err = contract.GetInspectionPrivate(newContext(stub, "ManufacturerMSP"), "6A7614", "RGA46870")
+	if err == nil || !strings.Contains(err.Error(), "has not been shared with ManufacturerMSP") {
+		t.Errorf("expected unshared private data to be unavailable, got %v", err)
+	}
+	_, err = contract.GetInspectionPrivate(newContext(stub, "MROLabMSP"), "6A7614", "RGA00000")
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected a missing inspection error, got %v", err)
+	}
+}
+
+func TestShareInspectionPrivate(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	mroCtx := newContext(stub, "MROLabMSP")
+	mfrCtx := newContext(stub, "ManufacturerMSP")
+
+	_, err := contract.AddInspection(mroCtx, sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+
+	err = contract.ShareInspectionPrivate(mfrCtx, "6A7614", "RGA46870", "ManufacturerMSP")
+	if err == nil || !strings.Contains(err.Error(), "only the owning organization MROLabMSP") {
+		t.Errorf("expected a non-owner to be rejected, got %v", err)
+	}
+	err = contract.ShareInspectionPrivate(mroCtx, "6A7614", "RGA46870", "RegulatorMSP")
+	if err == nil || !strings.Contains(err.Error(), "organization RegulatorMSP is not registered") {
+		t.Errorf("expected an unregistered grantee to be rejected, got %v", err)
+	}
+	err = contract.ShareInspectionPrivate(mroCtx, "6A7614", "RGA46870", "MROLabMSP")
+	if err == nil {
+		t.Errorf("expected sharing with the owner to be rejected")
+	}
+
+	err = contract.ShareInspectionPrivate(mroCtx, "6A7614", "RGA46870", "ManufacturerMSP")
+	if err != nil {
+		t.Fatalf("ShareInspectionPrivate failed: %v", err)
+	}
+	if len(stub.PvtState["inspectionPrivateManufacturerCollection"]) != 0 {
+		t.Errorf("expected nothing written to the grantee's own collection")
+	}
+
+	private, err := contract.GetInspectionPrivate(mfrCtx, "6A7614", "RGA46870")
+	if err != nil {
+		t.Fatalf("GetInspectionPrivate failed: %v", err)
+	}
+	if private.Inspector != "J. Smith" {
+		t.Errorf("expected the shared inspector, got %+v", private)
+	}
+
+	// A shared copy that differs from the owner's original is rejected
+	for key := range stub.PvtState[inspectionSharedCollection] {
+		stub.PvtState[inspectionSharedCollection][key] = []byte(`{"inspector":"Someone Else"}`)
+	}
+	_, err = contract.GetInspectionPrivate(mfrCtx, "6A7614", "RGA46870")
+	if err == nil || !strings.Contains(err.Error(), "does not match the hash of MROLabMSP's original") {
+		t.Errorf("expected a hash mismatch, got %v", err)
+	}
+
+	err = contract.RevokeInspectionPrivateShare(mroCtx, "6A7614", "RGA46870", "ManufacturerMSP")
+	if err != nil {
+		t.Fatalf("RevokeInspectionPrivateShare failed: %v", err)
+	}
+	_, err = contract.GetInspectionPrivate(mfrCtx, "6A7614", "RGA46870")
+	if err == nil || !strings.Contains(err.Error(), "has not been shared") {
+		t.Errorf("expected the share to be revoked, got %v", err)
+	}
+}
+
+func TestShareCoversOnlyTheSharedInspection(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	mroCtx := newContext(stub, "MROLabMSP")
+
+	_, err := contract.AddInspection(mroCtx, sampleInspectionOn("RGA46870", "before_surfacing", "2025-10-20T08:00:00Z", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	err = contract.ShareInspectionPrivate(mroCtx, "6A7614", "RGA46870", "ManufacturerMSP")
+	if err != nil {
+		t.Fatalf("ShareInspectionPrivate failed: %v", err)
+	}
+
+	// A later inspection has a different inspector, which has not been shared
+	stub.setTransaction("tx2", 1760947300)
+	stub.setInspector("A. Jones")
+	_, err = contract.AddInspection(mroCtx, sampleInspectionOn("RGA46870", "after_surfacing", "2025-10-21T08:00:00Z", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	_, err = contract.GetInspectionPrivate(newContext(stub, "ManufacturerMSP"), "6A7614", "RGA46870")
+	if err == nil || !strings.Contains(err.Error(), "has not been shared") {
+		t.Errorf("expected the later inspection to be unshared, got %v", err)
+	}
+}
+
+func TestVerifyInspectionPrivate(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	_, err := contract.AddInspection(newContext(stub, "MROLabMSP"), sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+
+	// An auditor of the other org verifies claims without reading the private data
+	auditorCtx := newContext(stub, "ManufacturerMSP")
+	claim := `{"submitterSubject":"CN=MROLabMSP,OU=client","inspector":"J. Smith","inspectorSalt":"` + testInspectorSalt +
+		`","submittedBy":"x509::CN=MROLabMSP"}`
+	result, err := contract.VerifyInspectionPrivate(auditorCtx, "6A7614", "RGA46870", "manual", "2025-10-20T08:00:00Z", claim)
+	if err != nil {
+		t.Fatalf("VerifyInspectionPrivate failed: %v", err)
+	}
+	if !result.Match || result.Collection != "inspectionPrivateMROLabCollection" {
+		t.Errorf("expected the claim to match, got %+v", result)
+	}
+
+	claim = `{"inspector":"A. Jones","inspectorSalt":"` + testInspectorSalt +
+		`","submittedBy":"x509::CN=MROLabMSP","submitterSubject":"CN=MROLabMSP,OU=client"}`
+	result, err = contract.VerifyInspectionPrivate(auditorCtx, "6A7614", "RGA46870", "manual", "2025-10-20T08:00:00Z", claim)
+	if err != nil || result.Match {
+		t.Errorf("expected the claim not to match, got %+v (%v)", result, err)
+	}
+
+	_, err = contract.VerifyInspectionPrivate(auditorCtx, "6A7614", "RGA46870", "before_surfacing", "2025-10-20T08:00:00Z", claim)
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected a missing inspection error, got %v", err)
+	}
+}
+
+func TestVerifyInspectionPrivateInspectorOnly(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	_, err := contract.AddInspection(newContext(stub, "MROLabMSP"), sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+
+	// The owner reads the salt and gives it to the auditor, who knows who inspected the blade but not the
+	// submitter's identity
+	private, err := contract.GetInspectionPrivate(newContext(stub, "MROLabMSP"), "6A7614", "RGA46870")
+	if err != nil || private.InspectorSalt != testInspectorSalt {
+		t.Fatalf("expected the owner to read the salt, got %+v (%v)", private, err)
+	}
+	auditorCtx := newContext(stub, "ManufacturerMSP")
+	result, err := contract.VerifyInspectionPrivate(auditorCtx, "6A7614", "RGA46870", "manual", "2025-10-20T08:00:00Z",
+		`{"inspector":"J. Smith","inspectorSalt":"`+private.InspectorSalt+`"}`)
+	if err != nil {
+		t.Fatalf("VerifyInspectionPrivate failed: %v", err)
+	}
+	if !result.Match || result.Collection != "inspectionPrivateMROLabCollection" {
+		t.Errorf("expected the inspector to match, got %+v", result)
+	}
+
+	result, err = contract.VerifyInspectionPrivate(auditorCtx, "6A7614", "RGA46870", "manual", "2025-10-20T08:00:00Z",
+		`{"inspector":"A. Jones","inspectorSalt":"`+private.InspectorSalt+`"}`)
+	if err != nil || result.Match {
+		t.Errorf("expected another inspector not to match, got %+v (%v)", result, err)
+	}
+
+	// Without the salt a guessed inspector cannot be checked, nor with a wrong one
+	_, err = contract.VerifyInspectionPrivate(auditorCtx, "6A7614", "RGA46870", "manual", "2025-10-20T08:00:00Z",
+		`{"inspector":"J. Smith"}`)
+	if err == nil || !strings.Contains(err.Error(), "needs the inspectorSalt") {
+		t.Errorf("expected a candidate without a salt to be rejected, got %v", err)
+	}
+	result, err = contract.VerifyInspectionPrivate(auditorCtx, "6A7614", "RGA46870", "manual", "2025-10-20T08:00:00Z",
+		`{"inspector":"J. Smith","inspectorSalt":"0000000000000000"}`)
+	if err != nil || result.Match {
+		t.Errorf("expected a wrong salt not to match, got %+v (%v)", result, err)
+	}
+}
+
+func TestInspectorClaimRequiresSalt(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+
+	stub.TransientMap = map[string][]byte{common.TransientPrivateKey: []byte(`{"inspector":"J. Smith","inspectorSalt":"short"}`)}
+	_, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err == nil || !strings.Contains(err.Error(), "at least 16 characters") {
+		t.Errorf("expected a short salt to be rejected, got %v", err)
+	}
+
+	// Without a salt no claim is kept, since its hash would give the inspector away
+	stub.TransientMap = map[string][]byte{common.TransientPrivateKey: []byte(`{"inspector":"J. Smith"}`)}
+	inspection, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	claimKey, err := inspectorClaimKey(ctx, inspection)
+	if err != nil {
+		t.Fatalf("inspectorClaimKey failed: %v", err)
+	}
+	if _, ok := stub.PvtState["inspectionPrivateMROLabCollection"][claimKey]; ok {
+		t.Errorf("expected no inspector claim without a salt")
+	}
+}
+
+func TestShareRequiresSharedCollectionMember(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	mroCtx := newContext(stub, "MROLabMSP")
+
+	for _, msp := range []string{"ManufacturerMSP", "MROLabMSP"} {
+		_, err := contract.RegisterOrganization(newAdminContext(stub, msp),
+			"RegulatorMSP", "regulator", "inspectionPrivateRegulatorCollection")
+		if err != nil {
+			t.Fatalf("RegisterOrganization failed: %v", err)
+		}
+	}
+	_, err := contract.AddInspection(mroCtx, sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+
+	// A registered organization outside the shared collection's policy cannot receive shares
+	err = contract.ShareInspectionPrivate(mroCtx, "6A7614", "RGA46870", "RegulatorMSP")
+	if err == nil || !strings.Contains(err.Error(), "RegulatorMSP is not a member of inspectionSharedCollection") {
+		t.Errorf("expected a non-member grantee to be rejected, got %v", err)
+	}
+	_, err = contract.GetInspectionPrivate(newContext(stub, "RegulatorMSP"), "6A7614", "RGA46870")
+	if err == nil || !strings.Contains(err.Error(), "RegulatorMSP is not a member of inspectionSharedCollection") {
+		t.Errorf("expected a non-member reader to be rejected, got %v", err)
+	}
+
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Supported source units and their conversion factors to mm
+const (
+	UnitMillimetre = "mm"
+	UnitInch       = "in"
+)
+
+var unitToMillimetre = map[string]float64{
+	UnitMillimetre: 1,
+	UnitInch:       25.4,
+}
+
+// maxSourcePrecision is the largest number of decimal places accepted in a source value
+const maxSourcePrecision = 6
+
+// normalizedDecimals is the number of decimal places kept in normalized mm values (0.1 µm)
+const normalizedDecimals = 4
+
+// sourceValuePattern matches an unsigned decimal number with an optional unit suffix
+var sourceValuePattern = regexp.MustCompile(`^([0-9]+(?:\.([0-9]+))?)\s*([a-z"]*)$`)
+
+// parseSourceValue parses a single source value, rejecting missing values, unit suffixes that
+// contradict the declared unit and values more precise than the declared source precision
+func parseSourceValue(value, unit string, precision int) (float64, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "n/a" {
+		return 0, fmt.Errorf("value is missing")
+	}
+
+	match := sourceValuePattern.FindStringSubmatch(value)
+	if match == nil {
+		return 0, fmt.Errorf("value %q is not a decimal number", value)
+	}
+
+	if suffix := match[3]; suffix != "" {
+		if suffix == `"` || suffix == "inch" || suffix == "inches" {
+			suffix = UnitInch
+		}
+		if suffix != unit {
+			return 0, fmt.Errorf("value %q is in %s but the measurements are declared in %s", value, suffix, unit)
+		}
+	}
+
+	if len(match[2]) > precision {
+		return 0, fmt.Errorf("value %q has more than %d decimal places", value, precision)
+	}
+
+	parsed, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("value %q is not a decimal number: %v", value, err)
+	}
+	if parsed <= 0 {
+		return 0, fmt.Errorf("value %q must be positive", value)
+	}
+
+	return parsed, nil
+}
+
+// normalizeMeasurements converts the source values to mm and stores them in AR..AB.
+// The conversion is deterministic so every endorsing peer computes identical values.
+func normalizeMeasurements(m *ChordMeasurements) error {
+	factor, ok := unitToMillimetre[m.Unit]
+	if !ok {
+		return fmt.Errorf("unit must be %q or %q, got %q", UnitMillimetre, UnitInch, m.Unit)
+	}
+	if m.SourcePrecision < 0 || m.SourcePrecision > maxSourcePrecision {
+		return fmt.Errorf("sourcePrecision must be between 0 and %d", maxSourcePrecision)
+	}
+
+	scale := math.Pow10(normalizedDecimals)
+	sourceValues := m.Source.values()
+	for i, ref := range m.pointRefs() {
+		value, err := parseSourceValue(sourceValues[i], m.Unit, m.SourcePrecision)
+		if err != nil {
+			return fmt.Errorf("point %s: %v", chordPointNames[i], err)
+		}
+		*ref = math.Round(value*factor*scale) / scale
+	}
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// manualRow is RGA46870 from sample-data/manual.csv (inches, AB column empty)
+var manualRow = ChordSourceValues{
+	AR: "9.581", AP: "9.864", AN: "10.1795", AM: "10.375", AL: "10.4335", AK: "10.4375", AJ: "10.4635",
+	AH: "10.558", AG: "10.6425", AF: "10.7645", AE: "10.9065", AD: "11.021", AC: "11.1675", AB: "",
+}
+
+func TestNormalizeMeasurementsInches(t *testing.T) {
+	source := manualRow
+	source.AB = "10.0825"
+	m := ChordMeasurements{Unit: UnitInch, SourcePrecision: 4, Source: source}
+
+	if err := normalizeMeasurements(&m); err != nil {
+		t.Fatalf("normalizeMeasurements failed: %v", err)
+	}
+	if m.AR != 243.3574 || m.AN != 258.5593 || m.AB != 256.0955 {
+		t.Errorf("unexpected normalized values: AR=%v AN=%v AB=%v", m.AR, m.AN, m.AB)
+	}
+	if m.Source.AR != "9.581" {
+		t.Errorf("expected source value to be preserved, got %q", m.Source.AR)
+	}
+}
+
+func TestNormalizeMeasurementsMillimetres(t *testing.T) {
+	m := inMillimetres(nominalChords)
+	m.Source.AP = "251.02"
+	for _, ref := range m.pointRefs() {
+		*ref = 0
+	}
+
+	if err := normalizeMeasurements(&m); err != nil {
+		t.Fatalf("normalizeMeasurements failed: %v", err)
+	}
+	if m.AR != nominalChords.AR || m.AP != 251.02 || m.AB != nominalChords.AB {
+		t.Errorf("unexpected normalized values: AR=%v AP=%v AB=%v", m.AR, m.AP, m.AB)
+	}
+}
+
+func TestNormalizeMeasurementsRejectsInvalidValues(t *testing.T) {
+	complete := manualRow
+	complete.AB = "10.0825"
+
+	for _, tc := range []struct {
+		name      string
+		unit      string
+		precision int
+		modify    func(*ChordSourceValues)
+		errorText string
+	}{
+		{"missing AB", UnitInch, 4, func(v *ChordSourceValues) { v.AB = "" }, "point AB: value is missing"},
+		{"not available", UnitInch, 4, func(v *ChordSourceValues) { v.AR = "N/A" }, "point AR: value is missing"},
+		{"missing unit", "", 4, func(v *ChordSourceValues) {}, "unit must be"},
+		{"conflicting suffix", UnitInch, 4, func(v *ChordSourceValues) { v.AR = "243.04 mm" }, "declared in in"},
+		{"excess precision", UnitInch, 3, func(v *ChordSourceValues) {}, "more than 3 decimal places"},
+		{"not a number", UnitInch, 4, func(v *ChordSourceValues) { v.AC = "11,1675" }, "not a decimal number"},
+		{"zero", UnitInch, 4, func(v *ChordSourceValues) { v.AC = "0" }, "must be positive"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			source := complete
+			tc.modify(&source)
+			m := ChordMeasurements{Unit: tc.unit, SourcePrecision: tc.precision, Source: source}
+
+			err := normalizeMeasurements(&m)
+			if err == nil || !strings.Contains(err.Error(), tc.errorText) {
+				t.Errorf("expected error containing %q, got %v", tc.errorText, err)
+			}
+		})
+	}
+}
+
+func TestAddInspectionStoresNormalizedMeasurements(t *testing.T) {
+	stub := newMockStub()
+	ctx := newContext(stub, "MROLabMSP")
+	contract := new(SmartContract)
+
+	source := manualRow
+	source.AB = "10.0825"
+	inspectionJSON, _ := json.Marshal(BladeInspection{
+		PartNumber:     "6A7614",
+		SerialNumber:   "RGA46870",
+		OccasionLabel:  "manual",
+		InspectionDate: "2025-10-21T08:00:00Z",
+		Organization:   "MROLabMSP",
+		Measurements:   ChordMeasurements{Unit: UnitInch, SourcePrecision: 4, Source: source, AR: 9.581},
+	})
+	if _, err := contract.AddInspection(ctx, string(inspectionJSON)); err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+
+	inspection, err := contract.GetInspection(ctx, "6A7614", "RGA46870")
+	if err != nil {
+		t.Fatalf("GetInspection failed: %v", err)
+	}
+	if inspection.Measurements.AR != 243.3574 || inspection.Measurements.Source.AR != "9.581" || inspection.Measurements.Unit != UnitInch {
+		t.Errorf("unexpected stored measurements: %+v", inspection.Measurements)
+	}
+
+	// The manual.csv row as exported (empty AB) is rejected instead of being stored as 0.0
+	inspectionJSON, _ = json.Marshal(BladeInspection{
+		PartNumber:     "6A7614",
+		SerialNumber:   "RGA85382",
+		OccasionLabel:  "manual",
+		InspectionDate: "2025-10-21T08:00:00Z",
+		Measurements:   ChordMeasurements{Unit: UnitInch, SourcePrecision: 4, Source: manualRow},
+	})
+	if _, err := contract.AddInspection(ctx, string(inspectionJSON)); err == nil {
+		t.Fatal("expected missing AB value to be rejected")
+	}
+}
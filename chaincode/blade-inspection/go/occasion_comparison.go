@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// ChordPointDelta is the change of a single chord measurement point between two occasions
+type ChordPointDelta struct {
+	Point            string  `json:"point"`
+	From             float64 `json:"from"`
+	To               float64 `json:"to"`
+	Delta            float64 `json:"delta"`   // To - From
+	Removal          float64 `json:"removal"` // From - To (material removed, negative if the chord grew)
+	ExceedsTolerance bool    `json:"exceedsTolerance"`
+}
+
+// OccasionComparison summarizes the chord dimension change of a blade between two occasions
+type OccasionComparison struct {
+	PartNumber   string  `json:"partNumber"`
+	SerialNumber string  `json:"serialNumber"`
+	FromOccasion string  `json:"fromOccasion"`
+	ToOccasion   string  `json:"toOccasion"`
+	FromTxID     string  `json:"fromTxId"`
+	ToTxID       string  `json:"toTxId"`
+	Tolerance    float64 `json:"tolerance"`
+
+	Points                   []ChordPointDelta `json:"points"`
+	MinRemoval               float64           `json:"minRemoval"`
+	MaxRemoval               float64           `json:"maxRemoval"`
+	MeanRemoval              float64           `json:"meanRemoval"`
+	PointsExceedingTolerance []string          `json:"pointsExceedingTolerance"`
+}
+
+// roundMeasurement rounds to 0.0001 to hide floating point noise in differences
+func roundMeasurement(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
+
+// latestForOccasion returns the most recent inspection with the given occasion label from a chronological history
+func latestForOccasion(history []*BladeInspection, occasionLabel string) *BladeInspection {
+	var latest *BladeInspection
+	for _, inspection := range history {
+		if inspection.OccasionLabel == occasionLabel {
+			latest = inspection
+		}
+	}
+	return latest
+}
+
+// CompareOccasions compares the chord measurements of a blade between two occasions (e.g. before_surfacing and
+// after_surfacing), flagging points whose absolute change exceeds tolerance. The latest inspection of each occasion is used.
+func (s *SmartContract) CompareOccasions(ctx contractapi.TransactionContextInterface, partNumber, serialNumber,
+	fromOccasion, toOccasion string, tolerance float64) (*OccasionComparison, error) {
+
+	if tolerance < 0 || math.IsNaN(tolerance) {
+		return nil, fmt.Errorf("tolerance must be a non-negative number")
+	}
+
+	history, err := s.GetBladeHistory(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	from := latestForOccasion(history, fromOccasion)
+	if from == nil {
+		return nil, fmt.Errorf("no %s inspection found for %s", fromOccasion, bladeKey(partNumber, serialNumber))
+	}
+	to := latestForOccasion(history, toOccasion)
+	if to == nil {
+		return nil, fmt.Errorf("no %s inspection found for %s", toOccasion, bladeKey(partNumber, serialNumber))
+	}
+
+	comparison := &OccasionComparison{
+		PartNumber:               partNumber,
+		SerialNumber:             serialNumber,
+		FromOccasion:             fromOccasion,
+		ToOccasion:               toOccasion,
+		FromTxID:                 from.TxID,
+		ToTxID:                   to.TxID,
+		Tolerance:                tolerance,
+		PointsExceedingTolerance: []string{},
+	}
+
+	fromPoints := from.Measurements.points()
+	toPoints := to.Measurements.points()
+	totalRemoval := 0.0
+	for i := range fromPoints {
+		delta := ChordPointDelta{
+			Point:   fromPoints[i].Name,
+			From:    fromPoints[i].Value,
+			To:      toPoints[i].Value,
+			Delta:   roundMeasurement(toPoints[i].Value - fromPoints[i].Value),
+			Removal: roundMeasurement(fromPoints[i].Value - toPoints[i].Value),
+		}
+		delta.ExceedsTolerance = math.Abs(delta.Delta) > tolerance
+		if delta.ExceedsTolerance {
+			comparison.PointsExceedingTolerance = append(comparison.PointsExceedingTolerance, delta.Point)
+		}
+
+		if i == 0 || delta.Removal < comparison.MinRemoval {
+			comparison.MinRemoval = delta.Removal
+		}
+		if i == 0 || delta.Removal > comparison.MaxRemoval {
+			comparison.MaxRemoval = delta.Removal
+		}
+		totalRemoval += delta.Removal
+
+		comparison.Points = append(comparison.Points, delta)
+	}
+	comparison.MeanRemoval = roundMeasurement(totalRemoval / float64(len(comparison.Points)))
+
+	return comparison, nil
+}
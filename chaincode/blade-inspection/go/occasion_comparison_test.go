@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func addMeasuredInspection(t *testing.T, contract *SmartContract, stub *mockStub, occasion, inspectionDate string, measurements ChordMeasurements) {
+	t.Helper()
+
+	inspectionJSON, _ := json.Marshal(BladeInspection{
+		PartNumber:     "6A7614",
+		SerialNumber:   "RGA46870",
+		OccasionLabel:  occasion,
+		InspectionDate: inspectionDate,
+		Organization:   "MROLabMSP",
+		Measurements:   inMillimetres(measurements),
+	})
+	stub.setTransaction("tx-"+occasion, 1760947200)
+	if _, err := contract.AddInspection(newContext(stub, "MROLabMSP"), string(inspectionJSON)); err != nil {
+		t.Fatalf("AddInspection %s failed: %v", occasion, err)
+	}
+}
+
+func TestCompareOccasions(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	// RGA46870 from sample-data/before_surfacing.csv and after_surfacing.csv
+	addMeasuredInspection(t, contract, stub, "before_surfacing", "2025-10-20T08:00:00Z", ChordMeasurements{
+		AR: 243.04, AP: 251.18, AN: 259.19, AM: 263.54, AL: 264.42, AK: 264.63, AJ: 265.11,
+		AH: 267.61, AG: 269.70, AF: 272.86, AE: 276.36, AD: 279.39, AC: 283.06, AB: 256.12,
+	})
+	addMeasuredInspection(t, contract, stub, "after_surfacing", "2025-10-22T08:00:00Z", ChordMeasurements{
+		AR: 242.98, AP: 251.02, AN: 258.83, AM: 263.33, AL: 264.13, AK: 264.37, AJ: 265.10,
+		AH: 267.41, AG: 269.57, AF: 272.51, AE: 276.18, AD: 278.92, AC: 282.47, AB: 254.40,
+	})
+
+	comparison, err := contract.CompareOccasions(newContext(stub, "ManufacturerMSP"),
+		"6A7614", "RGA46870", "before_surfacing", "after_surfacing", 0.5)
+	if err != nil {
+		t.Fatalf("CompareOccasions failed: %v", err)
+	}
+
+	if len(comparison.Points) != 14 {
+		t.Fatalf("expected 14 points, got %d", len(comparison.Points))
+	}
+	if comparison.Points[0].Point != "AR" || comparison.Points[0].Removal != 0.06 || comparison.Points[0].Delta != -0.06 {
+		t.Errorf("unexpected AR delta: %+v", comparison.Points[0])
+	}
+	if comparison.MinRemoval != 0.01 || comparison.MaxRemoval != 1.72 {
+		t.Errorf("expected removal range 0.01..1.72, got %v..%v", comparison.MinRemoval, comparison.MaxRemoval)
+	}
+	if comparison.MeanRemoval != 0.3564 {
+		t.Errorf("expected mean removal 0.3564, got %v", comparison.MeanRemoval)
+	}
+	if len(comparison.PointsExceedingTolerance) != 2 ||
+		comparison.PointsExceedingTolerance[0] != "AC" || comparison.PointsExceedingTolerance[1] != "AB" {
+		t.Errorf("expected AC and AB to exceed tolerance, got %v", comparison.PointsExceedingTolerance)
+	}
+	if comparison.FromTxID != "tx-before_surfacing" || comparison.ToTxID != "tx-after_surfacing" {
+		t.Errorf("unexpected source transactions: %s, %s", comparison.FromTxID, comparison.ToTxID)
+	}
+}
+
+func TestCompareOccasionsMissingOccasion(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	addMeasuredInspection(t, contract, stub, "before_surfacing", "2025-10-20T08:00:00Z", nominalChords)
+
+	_, err := contract.CompareOccasions(newContext(stub, "MROLabMSP"), "6A7614", "RGA46870", "before_surfacing", "after_surfacing", 0.1)
+	if err == nil {
+		t.Fatal("expected missing after_surfacing inspection to fail")
+	}
+
+	_, err = contract.CompareOccasions(newContext(stub, "MROLabMSP"), "6A7614", "RGA46870", "before_surfacing", "before_surfacing", -1)
+	if err == nil {
+		t.Fatal("expected negative tolerance to be rejected")
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRegisterOrganization(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	_, err := contract.RegisterOrganization(newContext(stub, "ManufacturerMSP"),
+		"RegulatorMSP", "regulator", "inspectionPrivateRegulatorCollection")
+	if err == nil || !strings.Contains(err.Error(), "only admins") {
+		t.Errorf("expected a client to be rejected, got %v", err)
+	}
+	_, err = contract.RegisterOrganization(newAdminContext(stub, "RegulatorMSP"),
+		"RegulatorMSP", "regulator", "inspectionPrivateRegulatorCollection")
+	if err == nil || !strings.Contains(err.Error(), "organization RegulatorMSP is not registered") {
+		t.Errorf("expected an unregistered admin to be rejected, got %v", err)
+	}
+
+	// Adding an organization takes the admins of a majority of the active organizations
+	change, err := contract.RegisterOrganization(newAdminContext(stub, "ManufacturerMSP"),
+		"RegulatorMSP", "regulator", "inspectionPrivateRegulatorCollection")
+	if err != nil || change.Applied || change.Required != 2 {
+		t.Fatalf("expected the registration to be pending, got %+v (%v)", change, err)
+	}
+	pending, err := contract.GetPendingOrganizationChanges(newContext(stub, "MROLabMSP"))
+	if err != nil || len(pending) != 1 || pending[0].Approvals[0] != "ManufacturerMSP" {
+		t.Errorf("unexpected pending changes %+v (%v)", pending, err)
+	}
+	_, err = contract.AddInspection(newContext(stub, "RegulatorMSP"), sampleInspection("RGA46870", "manual", "RegulatorMSP"))
+	if err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Errorf("expected the pending organization to be rejected, got %v", err)
+	}
+	change, err = contract.RegisterOrganization(newAdminContext(stub, "MROLabMSP"),
+		"RegulatorMSP", "regulator", "inspectionPrivateRegulatorCollection")
+	if err != nil || !change.Applied {
+		t.Fatalf("expected the registration to be applied, got %+v (%v)", change, err)
+	}
+	org := change.Organization
+	if !org.Active || org.UpdatedBy != "x509::CN=MROLabMSP" || org.TxID != "tx1" || org.UpdatedAt != "2025-10-20T08:00:00Z" {
+		t.Errorf("unexpected organization %+v", org)
+	}
+
+	// The new organization's private fields go to its own collection
+	stub.setTransaction("tx2", 1760947300)
+	stub.setInspector("A. Auditor")
+	_, err = contract.AddInspection(newContext(stub, "RegulatorMSP"), sampleInspection("RGA46870", "manual", "RegulatorMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	private, err := contract.GetInspection(newContext(stub, "RegulatorMSP"), "6A7614", "RGA46870")
+	if err != nil || private.Inspector != "A. Auditor" {
+		t.Errorf("expected the regulator to read its inspector, got %+v (%v)", private, err)
+	}
+
+	orgs, err := contract.GetOrganizations(newContext(stub, "MROLabMSP"))
+	if err != nil || len(orgs) != 3 {
+		t.Errorf("expected 3 organizations, got %d (%v)", len(orgs), err)
+	}
+}
+
+func TestDeactivateOrganization(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	// An organization can deactivate itself alone
+	change, err := contract.DeactivateOrganization(newAdminContext(stub, "MROLabMSP"), "MROLabMSP")
+	if err != nil {
+		t.Fatalf("DeactivateOrganization failed: %v", err)
+	}
+	if !change.Applied || change.Organization.Active {
+		t.Errorf("expected MROLabMSP to be deactivated, got %+v", change)
+	}
+
+	_, err = contract.AddInspection(newContext(stub, "MROLabMSP"), sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err == nil || !strings.Contains(err.Error(), "organization MROLabMSP is deactivated") {
+		t.Errorf("expected the deactivated organization to be rejected, got %v", err)
+	}
+	_, err = contract.RegisterOrganization(newAdminContext(stub, "MROLabMSP"), "MROLabMSP", "mro", "inspectionPrivateMROLabCollection")
+	if err == nil {
+		t.Errorf("expected an admin of a deactivated organization to be rejected")
+	}
+	_, err = contract.DeactivateOrganization(newAdminContext(stub, "ManufacturerMSP"), "ManufacturerMSP")
+	if err == nil || !strings.Contains(err.Error(), "last active organization") {
+		t.Errorf("expected the last active organization to be kept, got %v", err)
+	}
+
+	// Reactivation by the remaining active organization restores access
+	change, err = contract.RegisterOrganization(newAdminContext(stub, "ManufacturerMSP"), "MROLabMSP", "mro", "inspectionPrivateMROLabCollection")
+	if err != nil || !change.Applied {
+		t.Fatalf("expected MROLabMSP to be reactivated, got %+v (%v)", change, err)
+	}
+	_, err = contract.AddInspection(newContext(stub, "MROLabMSP"), sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Errorf("expected the reactivated organization to add inspections, got %v", err)
+	}
+}
+
+func TestOrganizationAdminCannotChangeAnotherOrganization(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	mroAdmin := newAdminContext(stub, "MROLabMSP")
+
+	// Pointing ManufacturerMSP at a collection MROLabMSP can read is held for ManufacturerMSP's approval
+	change, err := contract.RegisterOrganization(mroAdmin, "ManufacturerMSP", "manufacturer", "inspectionPrivateSharedCollection")
+	if err != nil || change.Applied {
+		t.Fatalf("expected the change to be pending, got %+v (%v)", change, err)
+	}
+	if _, err = contract.AddInspection(newContext(stub, "ManufacturerMSP"), sampleInspection("RGA46870", "manual", "ManufacturerMSP")); err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	if len(stub.PvtState["inspectionPrivateSharedCollection"]) != 0 || len(stub.PvtState["inspectionPrivateManufacturerCollection"]) == 0 {
+		t.Errorf("expected ManufacturerMSP's private fields in its own collection")
+	}
+
+	// Neither can it deactivate another organization or change its own role alone
+	change, err = contract.DeactivateOrganization(mroAdmin, "ManufacturerMSP")
+	if err != nil || change.Applied {
+		t.Errorf("expected the deactivation to be pending, got %+v (%v)", change, err)
+	}
+	change, err = contract.RegisterOrganization(mroAdmin, "MROLabMSP", "manufacturer", "inspectionPrivateMROLabCollection")
+	if err != nil || change.Applied {
+		t.Errorf("expected the role change to be pending, got %+v (%v)", change, err)
+	}
+	orgs, _ := contract.GetOrganizations(newContext(stub, "MROLabMSP"))
+	if !orgs[0].Active || orgs[0].Role != "mro" || !orgs[1].Active || orgs[1].PrivateCollection != "inspectionPrivateManufacturerCollection" {
+		t.Errorf("expected the registry to be unchanged, got %+v, %+v", orgs[0], orgs[1])
+	}
+
+	// Its own collection cannot be pointed at a collection every organization reads, even with approval
+	_, err = contract.RegisterOrganization(mroAdmin, "MROLabMSP", "mro", inspectionPublicCollection)
+	if err == nil || !strings.Contains(err.Error(), "is shared by the organizations") {
+		t.Errorf("expected the public collection to be rejected, got %v", err)
+	}
+
+	// and moving its own private data to another collection needs the other organizations' approval
+	change, err = contract.RegisterOrganization(mroAdmin, "MROLabMSP", "mro", "inspectionPrivateMROLabCollection2")
+	if err != nil || change.Applied {
+		t.Errorf("expected the collection change to be pending, got %+v (%v)", change, err)
+	}
+	change, err = contract.RegisterOrganization(newAdminContext(stub, "ManufacturerMSP"), "MROLabMSP", "mro", "inspectionPrivateMROLabCollection2")
+	if err != nil || !change.Applied || change.Organization.PrivateCollection != "inspectionPrivateMROLabCollection2" {
+		t.Errorf("expected the collection change to be applied, got %+v (%v)", change, err)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// maxPageSize limits the number of records returned by a single page query
+const maxPageSize = 100
+
+// InspectionPage is one page of current blade inspections. Bookmark is passed to the next call and is
+// empty on the last page.
+type InspectionPage struct {
+	Records      []*BladeInspection `json:"records"`
+	Bookmark     string             `json:"bookmark"`
+	FetchedCount int32              `json:"fetchedCount"`
+}
+
+// validatePageSize checks that a requested page size is between 1 and maxPageSize
+func validatePageSize(pageSize int32) error {
+	if pageSize < 1 || pageSize > maxPageSize {
+		return fmt.Errorf("page size must be between 1 and %d", maxPageSize)
+	}
+	return nil
+}
+
+// GetInspectionsPage returns up to pageSize current blade inspections, starting at bookmark ("" for the
+// first page). Fabric has no paginated private data queries, so the bookmark is the key of the next
+// current record in the public collection and the range iterator stops after pageSize+1 keys.
+func (s *SmartContract) GetInspectionsPage(ctx contractapi.TransactionContextInterface, pageSize int32, bookmark string) (*InspectionPage, error) {
+	err := validatePageSize(pageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	privateCollectionName, err := getPrivateCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty start key excludes the composite keys of inspection events
+	resultsIterator, err := ctx.GetStub().GetPrivateDataByRange(inspectionPublicCollection, bookmark, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public data: %v", err)
+	}
+	defer resultsIterator.Close()
+
+	page := &InspectionPage{Records: []*BladeInspection{}}
+	for resultsIterator.HasNext() {
+		queryResponse, err := resultsIterator.Next()
+		if err != nil {
+			return nil, err
+		}
+		if page.FetchedCount == pageSize {
+			page.Bookmark = queryResponse.Key
+			break
+		}
+
+		var inspection BladeInspection
+		err = json.Unmarshal(queryResponse.Value, &inspection)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unmarshal public data: %v", err)
+		}
+
+		err = readInspectionPrivate(ctx, privateCollectionName, &inspection)
+		if err != nil {
+			return nil, err
+		}
+
+		page.Records = append(page.Records, &inspection)
+		page.FetchedCount++
+	}
+
+	return page, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+func addBlades(t *testing.T, contract *SmartContract, stub *mockStub, count int) {
+	t.Helper()
+	ctx := newContext(stub, "MROLabMSP")
+	for i := 0; i < count; i++ {
+		registerPart(stub, fmt.Sprintf("RGA%05d", i), common.PartInService)
+		_, err := contract.AddInspection(ctx, sampleInspection(fmt.Sprintf("RGA%05d", i), "manual", "MROLabMSP"))
+		if err != nil {
+			t.Fatalf("AddInspection failed: %v", err)
+		}
+	}
+}
+
+func TestGetInspectionsPage(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	addBlades(t, contract, stub, 5)
+	ctx := newContext(stub, "MROLabMSP")
+
+	var serials []string
+	var counts []int32
+	bookmark := ""
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("pagination did not terminate")
+		}
+		page, err := contract.GetInspectionsPage(ctx, 2, bookmark)
+		if err != nil {
+			t.Fatalf("GetInspectionsPage failed: %v", err)
+		}
+		if int(page.FetchedCount) != len(page.Records) {
+			t.Errorf("fetched count %d does not match %d records", page.FetchedCount, len(page.Records))
+		}
+		counts = append(counts, page.FetchedCount)
+		for _, inspection := range page.Records {
+			serials = append(serials, inspection.SerialNumber)
+			if inspection.Inspector != "J. Smith" {
+				t.Errorf("expected inspector for %s, got %q", inspection.SerialNumber, inspection.Inspector)
+			}
+		}
+
+		if page.Bookmark == "" {
+			break
+		}
+		bookmark = page.Bookmark
+	}
+
+	if fmt.Sprint(counts) != "[2 2 1]" {
+		t.Errorf("expected pages of [2 2 1], got %v", counts)
+	}
+	if fmt.Sprint(serials) != "[RGA00000 RGA00001 RGA00002 RGA00003 RGA00004]" {
+		t.Errorf("unexpected serial numbers %v", serials)
+	}
+
+	// An exact final page has no bookmark
+	page, err := contract.GetInspectionsPage(ctx, 5, "")
+	if err != nil || page.FetchedCount != 5 || page.Bookmark != "" {
+		t.Errorf("expected a single page of 5 without bookmark, got %d %q (%v)", page.FetchedCount, page.Bookmark, err)
+	}
+
+	for _, pageSize := range []int32{0, -1, maxPageSize + 1} {
+		if _, err := contract.GetInspectionsPage(ctx, pageSize, ""); err == nil {
+			t.Errorf("expected page size %d to be rejected", pageSize)
+		}
+	}
+}
+
+func TestGetAllInspectionsReadsEveryPage(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	addBlades(t, contract, stub, maxPageSize*2+3)
+
+	all, err := contract.GetAllInspections(newContext(stub, "ManufacturerMSP"))
+	if err != nil {
+		t.Fatalf("GetAllInspections failed: %v", err)
+	}
+	if len(all) != maxPageSize*2+3 {
+		t.Errorf("expected %d inspections, got %d", maxPageSize*2+3, len(all))
+	}
+}
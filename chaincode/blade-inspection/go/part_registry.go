@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// partObjectType prefixes the world state keys of registered parts: part~PartNumber~SerialNumber
+const partObjectType = "part"
+
+// aiDefectChaincode is the default name of the AI defect inspection chaincode on the same channel, which
+// GetPartRecord queries for the AI inspections of a part (see LinkChaincode)
+const aiDefectChaincode = "aidefectinspection"
+
+// PartRecord is a registered part with every inspection recorded for it
+type PartRecord struct {
+	Part             *common.Part             `json:"part"`
+	BladeInspections []*BladeInspection       `json:"bladeInspections"`
+	AIInspections    []map[string]interface{} `json:"aiInspections"` // as returned by the AI defect inspection chaincode
+}
+
+// partKey returns the world state key of a registered part
+func partKey(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (string, error) {
+	return common.CompositeKey(ctx.GetStub(), partObjectType, partNumber, serialNumber)
+}
+
+// readPart reads a part from the registry (nil if it is not registered)
+func readPart(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (*common.Part, error) {
+	key, err := partKey(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	partBytes, err := ctx.GetStub().GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read part: %v", err)
+	}
+	if partBytes == nil {
+		return nil, nil
+	}
+
+	var part common.Part
+	err = json.Unmarshal(partBytes, &part)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal part: %v", err)
+	}
+	return &part, nil
+}
+
+// checkPartInspectable rejects inspections of parts that are not registered or have been scrapped
+func checkPartInspectable(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) error {
+	part, err := readPart(ctx, partNumber, serialNumber)
+	if err != nil {
+		return err
+	}
+	return common.CheckInspectable(part, partNumber, serialNumber)
+}
+
+// putPart stamps a part with the caller and the current transaction and writes it to the registry
+func putPart(ctx contractapi.TransactionContextInterface, part *common.Part) error {
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client ID: %v", err)
+	}
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	part.UpdatedBy = clientID
+	part.UpdatedAt = time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).UTC().Format(time.RFC3339)
+	part.TxID = ctx.GetStub().GetTxID()
+
+	key, err := partKey(ctx, part.PartNumber, part.SerialNumber)
+	if err != nil {
+		return err
+	}
+	partBytes, err := json.Marshal(part)
+	if err != nil {
+		return fmt.Errorf("failed to marshal part: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, partBytes)
+	if err != nil {
+		return fmt.Errorf("failed to write part: %v", err)
+	}
+	return nil
+}
+
+// callerOrganization returns the caller's organization from the org registry
+func callerOrganization(ctx contractapi.TransactionContextInterface) (*common.Organization, error) {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	registry, err := common.LoadRegistry[registryDefaults](ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.Lookup(clientMSPID)
+}
+
+// RegisterPart adds a serialized part to the registry, in service unless its status is "quarantined".
+// Inspections can only be recorded for registered parts. The input holds the part number, serial number,
+// manufacturer, material, optional lot and manufacture date (YYYY-MM-DD); invalid fields are reported as
+// VALIDATION_FAILED. Only members of manufacturer organizations may register parts.
+func (s *SmartContract) RegisterPart(ctx contractapi.TransactionContextInterface, partJSON string) (*common.Part, error) {
+	org, err := callerOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if org.Role != common.RoleManufacturer {
+		return nil, fmt.Errorf("only manufacturer organizations can register parts, %s is a %s organization", org.MSPID, org.Role)
+	}
+
+	var part common.Part
+	err = json.Unmarshal([]byte(partJSON), &part)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal part: %v", err)
+	}
+	if err := common.ValidatePart(&part).Err(); err != nil {
+		return nil, err
+	}
+
+	existing, err := readPart(ctx, part.PartNumber, part.SerialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("part %s %s is already registered", part.PartNumber, part.SerialNumber)
+	}
+
+	if part.Status == "" {
+		part.Status = common.PartInService
+	}
+	part.RegisteredBy = org.MSPID
+	err = putPart(ctx, &part)
+	if err != nil {
+		return nil, err
+	}
+	return &part, nil
+}
+
+// SetPartStatus moves a part to another lifecycle status for the given reason: in-service parts may be
+// quarantined, quarantined parts released back to service, and either scrapped. Scrapped parts cannot
+// change and no longer accept inspections. Only supervisors of manufacturer and MRO organizations may
+// change the status of parts.
+func (s *SmartContract) SetPartStatus(ctx contractapi.TransactionContextInterface,
+	partNumber, serialNumber, status, reason string) (*common.Part, error) {
+
+	err := common.CheckSupervisor(ctx.GetClientIdentity())
+	if err != nil {
+		return nil, err
+	}
+	org, err := callerOrganization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if org.Role != common.RoleManufacturer && org.Role != common.RoleMRO {
+		return nil, fmt.Errorf("only manufacturer and MRO organizations can change the status of parts, %s is a %s organization",
+			org.MSPID, org.Role)
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("a reason is required to change the status of a part")
+	}
+
+	part, err := readPart(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if part == nil {
+		return nil, fmt.Errorf("part %s %s is not registered", partNumber, serialNumber)
+	}
+	err = common.CheckPartTransition(part.Status, status)
+	if err != nil {
+		return nil, fmt.Errorf("part %s %s: %v", partNumber, serialNumber, err)
+	}
+
+	part.Status = status
+	part.StatusReason = reason
+	err = putPart(ctx, part)
+	if err != nil {
+		return nil, err
+	}
+	return part, nil
+}
+
+// GetPart retrieves a registered part
+func (s *SmartContract) GetPart(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (*common.Part, error) {
+	part, err := readPart(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if part == nil {
+		return nil, fmt.Errorf("part %s %s is not registered", partNumber, serialNumber)
+	}
+	return part, nil
+}
+
+// GetPartRecord retrieves a registered part with its blade inspections, oldest first as in GetBladeHistory,
+// and its AI defect inspections from the AI defect inspection chaincode on the same channel (linked as
+// "aiDefect", aidefectinspection by default). Private fields are those of the caller's organization.
+func (s *SmartContract) GetPartRecord(ctx contractapi.TransactionContextInterface, partNumber, serialNumber string) (*PartRecord, error) {
+	part, err := s.GetPart(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	bladeInspections, err := s.GetBladeHistory(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	// AI inspections are kept by serial number, so those of another part number are left out
+	chaincodeName, err := common.LinkedChaincodeName[registryDefaults](ctx, common.LinkAIDefect)
+	if err != nil {
+		return nil, err
+	}
+	response := ctx.GetStub().InvokeChaincode(chaincodeName,
+		[][]byte{[]byte("GetDefectInspectionHistory"), []byte(serialNumber)}, "")
+	if response.Status != shim.OK {
+		return nil, fmt.Errorf("failed to query AI inspections from %s: %s", chaincodeName, response.Message)
+	}
+	var history []map[string]interface{}
+	err = json.Unmarshal(response.Payload, &history)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal AI inspections: %v", err)
+	}
+	aiInspections := []map[string]interface{}{}
+	for _, inspection := range history {
+		if inspection["partNumber"] == partNumber {
+			aiInspections = append(aiInspections, inspection)
+		}
+	}
+
+	return &PartRecord{Part: part, BladeInspections: bladeInspections, AIInspections: aiInspections}, nil
+}
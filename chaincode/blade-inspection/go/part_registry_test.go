@@ -0,0 +1,177 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-chaincode-go/shimtest"
+	"github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// mockAIChaincode answers GetDefectInspectionHistory like the AI defect inspection chaincode
+type mockAIChaincode struct {
+	history map[string]string // JSON history by serial number
+	failure string            // error returned for every call, as by a chaincode that cannot be launched
+}
+
+func (cc *mockAIChaincode) Init(stub shim.ChaincodeStubInterface) peer.Response {
+	return shim.Success(nil)
+}
+
+func (cc *mockAIChaincode) Invoke(stub shim.ChaincodeStubInterface) peer.Response {
+	if cc.failure != "" {
+		return shim.Error(cc.failure)
+	}
+	function, args := stub.GetFunctionAndParameters()
+	if function != "GetDefectInspectionHistory" || len(args) != 1 {
+		return shim.Error("unexpected call " + function)
+	}
+	history, ok := cc.history[args[0]]
+	if !ok {
+		history = "[]"
+	}
+	return shim.Success([]byte(history))
+}
+
+func TestRegisterPart(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	manufacturer := newContext(stub, "ManufacturerMSP")
+	partJSON := `{"partNumber":"6A7614","serialNumber":"RGA90001","manufacturer":"Rolls-Royce","material":"CMSX-4",` +
+		`"lot":"H2291","manufactureDate":"2024-05-02"}`
+
+	_, err := contract.RegisterPart(newContext(stub, "MROLabMSP"), partJSON)
+	if err == nil || !strings.Contains(err.Error(), "only manufacturer organizations") {
+		t.Errorf("expected an MRO to be rejected, got %v", err)
+	}
+
+	part, err := contract.RegisterPart(manufacturer, partJSON)
+	if err != nil {
+		t.Fatalf("RegisterPart failed: %v", err)
+	}
+	if part.Status != common.PartInService || part.RegisteredBy != "ManufacturerMSP" || part.TxID != "tx1" || part.Lot != "H2291" {
+		t.Errorf("unexpected part %+v", part)
+	}
+	stored, err := contract.GetPart(newContext(stub, "MROLabMSP"), "6A7614", "RGA90001")
+	if err != nil || stored.Manufacturer != "Rolls-Royce" {
+		t.Errorf("unexpected stored part %+v (%v)", stored, err)
+	}
+
+	_, err = contract.RegisterPart(manufacturer, partJSON)
+	if err == nil || !strings.Contains(err.Error(), "already registered") {
+		t.Errorf("expected a second registration to fail, got %v", err)
+	}
+	_, err = contract.RegisterPart(manufacturer, `{"partNumber":"6A7614","serialNumber":"RGA90002","manufactureDate":"2024-13-02"}`)
+	codes := fieldCodes(t, err)
+	if len(codes) != 3 || codes["manufacturer"] != common.CodeRequired || codes["manufactureDate"] != common.CodeInvalidTimestamp {
+		t.Errorf("unexpected field errors %v", codes)
+	}
+	if _, err = contract.GetPart(manufacturer, "6A7614", "RGA90002"); err == nil {
+		t.Error("expected an unregistered part to be missing")
+	}
+}
+
+func TestInspectionsRequireInspectablePart(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	inspector := newContext(stub, "MROLabMSP")
+	supervisor := newNamedContext(stub, "MROLabMSP", "supervisor1", certifiedSupervisor)
+
+	_, err := contract.AddInspection(inspector, sampleInspection("RGA99999", "manual", "MROLabMSP"))
+	if err == nil || !strings.Contains(err.Error(), "part 6A7614 RGA99999 is not registered") {
+		t.Errorf("expected an unregistered part to be rejected, got %v", err)
+	}
+
+	// Quarantined parts are inspected to decide their fate
+	if _, err = contract.SetPartStatus(inspector, "6A7614", "RGA46870", common.PartQuarantined, "bird strike"); err == nil {
+		t.Error("expected an inspector to be rejected")
+	}
+	if _, err = contract.SetPartStatus(supervisor, "6A7614", "RGA46870", common.PartQuarantined, ""); err == nil {
+		t.Error("expected a status change without a reason to fail")
+	}
+	part, err := contract.SetPartStatus(supervisor, "6A7614", "RGA46870", common.PartQuarantined, "bird strike")
+	if err != nil || part.Status != common.PartQuarantined || part.StatusReason != "bird strike" {
+		t.Fatalf("unexpected quarantine %+v (%v)", part, err)
+	}
+	if _, err = contract.AddInspection(inspector, sampleInspection("RGA46870", "manual", "MROLabMSP")); err != nil {
+		t.Errorf("expected a quarantined part to be inspectable, got %v", err)
+	}
+
+	// Scrapped parts take no new inspections and stay scrapped
+	stub.setTransaction("tx2", 1760947260)
+	if _, err = contract.SetPartStatus(supervisor, "6A7614", "RGA46870", common.PartScrapped, "tip curl beyond repair"); err != nil {
+		t.Fatalf("SetPartStatus failed: %v", err)
+	}
+	_, err = contract.AddInspection(inspector, sampleInspectionOn("RGA46870", "manual", "2025-10-21T08:00:00Z", "MROLabMSP"))
+	if err == nil || !strings.Contains(err.Error(), "is scrapped") {
+		t.Errorf("expected a scrapped part to be rejected, got %v", err)
+	}
+	_, err = contract.SetPartStatus(supervisor, "6A7614", "RGA46870", common.PartInService, "mistake")
+	if err == nil || !strings.Contains(err.Error(), "scrapped") {
+		t.Errorf("expected a scrapped part to be final, got %v", err)
+	}
+
+	// The inspection recorded before the part was scrapped can still be corrected
+	stub.setTransaction("tx3", 1760947320)
+	if _, err = contract.AmendInspection(inspector, correctedInspection("RGA46870", "243.10 mm"), 1, ReasonTranscriptionError, "typo"); err != nil {
+		t.Errorf("AmendInspection failed: %v", err)
+	}
+}
+
+func TestGetPartRecord(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+
+	ai := &mockAIChaincode{history: map[string]string{"RGA46870": `[` +
+		`{"partNumber":"6A7614","serialNumber":"RGA46870","txId":"ai1","defectDetected":true,"inspector":"Dr. Smith"},` +
+		`{"partNumber":"7B1234","serialNumber":"RGA46870","txId":"ai2","defectDetected":false}]`}}
+	stub.MockPeerChaincode(aiDefectChaincode, shimtest.NewMockStub(aiDefectChaincode, ai), "")
+
+	if _, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP")); err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	record, err := contract.GetPartRecord(ctx, "6A7614", "RGA46870")
+	if err != nil {
+		t.Fatalf("GetPartRecord failed: %v", err)
+	}
+	if record.Part.SerialNumber != "RGA46870" || len(record.BladeInspections) != 1 || record.BladeInspections[0].Inspector != "J. Smith" {
+		t.Errorf("unexpected part record %+v", record)
+	}
+	if len(record.AIInspections) != 1 || record.AIInspections[0]["txId"] != "ai1" || record.AIInspections[0]["inspector"] != "Dr. Smith" {
+		t.Errorf("expected the AI inspection of the part only, got %v", record.AIInspections)
+	}
+
+	if _, err = contract.GetPartRecord(ctx, "6A7614", "RGA99999"); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Errorf("expected an unregistered part to be rejected, got %v", err)
+	}
+}
+
+func TestGetPartRecordUsesLinkedAIChaincode(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+	stub.MockPeerChaincode("aidefects", shimtest.NewMockStub("aidefects", &mockAIChaincode{history: map[string]string{
+		"RGA46870": `[{"partNumber":"6A7614","serialNumber":"RGA46870","txId":"ai1"}]`}}), "")
+
+	change, err := contract.LinkChaincode(newAdminContext(stub, "MROLabMSP"), common.LinkAIDefect, "aidefects")
+	if err != nil || change.Applied {
+		t.Fatalf("expected the link to wait for a second organization, got %+v (%v)", change, err)
+	}
+	if change, err = contract.LinkChaincode(newAdminContext(stub, "ManufacturerMSP"), common.LinkAIDefect, "aidefects"); err != nil || !change.Applied {
+		t.Fatalf("expected the link to be applied, got %+v (%v)", change, err)
+	}
+	record, err := contract.GetPartRecord(ctx, "6A7614", "RGA46870")
+	if err != nil || len(record.AIInspections) != 1 {
+		t.Errorf("expected the AI inspection from the linked chaincode, got %+v (%v)", record, err)
+	}
+
+	// A chaincode that fails is reported rather than read as no inspections
+	stub.MockPeerChaincode("aidefects", shimtest.NewMockStub("aidefects", &mockAIChaincode{failure: "chaincode aidefects not found"}), "")
+	_, err = contract.GetPartRecord(ctx, "6A7614", "RGA46870")
+	if err == nil || err.Error() != "failed to query AI inspections from aidefects: chaincode aidefects not found" {
+		t.Errorf("expected a failed AI query to be reported, got %v", err)
+	}
+}
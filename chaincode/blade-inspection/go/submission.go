@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// deriveSubmissionID identifies a submission by the inspection event it records and, for an amendment, the
+// version it creates, so resubmitting returns the record when the content is identical and conflicts when
+// it is not
+func deriveSubmissionID(inspection *BladeInspection) string {
+	parts := []string{inspection.PartNumber, inspection.SerialNumber, inspection.OccasionLabel, inspection.InspectionDate}
+	if inspection.Version > 1 {
+		parts = append(parts, strconv.Itoa(inspection.Version))
+	}
+	return common.DeriveSubmissionID(parts...)
+}
+
+// submissionContentHash hashes the public content of a submission after its measurements have been
+// normalized. Fields set by the chaincode, the requested status and the submission time, which a client
+// stamps on every attempt, are left out, as are the private fields, since every org can read the hash.
+func submissionContentHash(inspection *BladeInspection) (string, error) {
+	content := *inspection
+	content.Organization, content.SubmittedAt, content.TxID, content.BlockchainTimestamp = "", "", "", ""
+	content.Disposition, content.OutOfLimitPoints, content.ToleranceSpecVersion = "", nil, 0
+	content.Status, content.StatusHistory, content.SupersededBy = "", nil, ""
+	if content.Amendment != nil {
+		amendment := *content.Amendment
+		amendment.SupersedesTxID = ""
+		content.Amendment = &amendment
+	}
+	contentJSON, err := common.MarshalPublic(&content)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal submission content: %v", err)
+	}
+	return common.ContentHash(contentJSON), nil
+}
+
+// checkSubmission looks up the submission ID of an inspection stamped with its submitter, deriving the ID
+// if the inspection has none. It returns the inspection created by an identical earlier submission, or the
+// submission to record for a new ID, and fails with a *common.SubmissionConflictError if the ID was used
+// for different content, including earlier in the same transaction.
+func checkSubmission(ctx contractapi.TransactionContextInterface, privateCollectionName string, inspection *BladeInspection,
+	writes *txWrites) (*common.Submission, *BladeInspection, error) {
+
+	if inspection.SubmissionID == "" {
+		inspection.SubmissionID = deriveSubmissionID(inspection)
+	}
+	contentHash, err := submissionContentHash(inspection)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	submissionKey, err := common.SubmissionKey(ctx.GetStub(), inspection.Organization, inspection.SubmissionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	recordJSON, err := ctx.GetStub().GetPrivateData(inspectionPublicCollection, submissionKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read submission: %v", err)
+	}
+	if pending, ok := writes.submissions[submissionKey]; ok {
+		recordJSON, _ = json.Marshal(pending)
+	}
+	recorded, err := common.MatchSubmission(recordJSON, inspection.SubmissionID, contentHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if recorded == nil || recorded.TxID == ctx.GetStub().GetTxID() {
+		// New, or repeated within this transaction, which the event key check rejects
+		return &common.Submission{SubmissionID: inspection.SubmissionID, ContentHash: contentHash}, nil, nil
+	}
+
+	publicDataBytes, err := ctx.GetStub().GetPrivateData(inspectionPublicCollection, recorded.RecordKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read public data: %v", err)
+	}
+	if publicDataBytes == nil {
+		return nil, nil, fmt.Errorf("inspection of submission %s does not exist", inspection.SubmissionID)
+	}
+	var existing BladeInspection
+	err = json.Unmarshal(publicDataBytes, &existing)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal public data: %v", err)
+	}
+	err = readInspectionPrivate(ctx, privateCollectionName, &existing)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, &existing, nil
+}
+
+// putSubmission records a new submission as having created the inspection event stored under recordKey
+func putSubmission(ctx contractapi.TransactionContextInterface, inspection *BladeInspection, submission *common.Submission,
+	recordKey string, writes *txWrites) error {
+
+	submissionKey, err := common.SubmissionKey(ctx.GetStub(), inspection.Organization, submission.SubmissionID)
+	if err != nil {
+		return err
+	}
+
+	submission.RecordKey = recordKey
+	submission.TxID = ctx.GetStub().GetTxID()
+	submissionJSON, err := json.Marshal(submission)
+	if err != nil {
+		return fmt.Errorf("failed to marshal submission: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData(inspectionPublicCollection, submissionKey, submissionJSON)
+	if err != nil {
+		return fmt.Errorf("failed to write submission: %v", err)
+	}
+	writes.submissions[submissionKey] = submission
+	return nil
+}
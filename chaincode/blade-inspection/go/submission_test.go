@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+func TestAddInspectionIsIdempotent(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+
+	first, err := contract.AddInspection(ctx, sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	if len(first.SubmissionID) != 64 || first.TxID != "tx1" {
+		t.Errorf("expected a derived submission ID, got %+v", first)
+	}
+	keys := len(stub.PvtState[inspectionPublicCollection])
+
+	// A retry stamps a new submission time, but submits the same content
+	stub.setTransaction("tx2", 1760947260)
+	retry := strings.Replace(sampleInspection("RGA46870", "manual", "MROLabMSP"),
+		`"submittedAt":"2025-10-20T08:05:00Z"`, `"submittedAt":"2025-10-20T08:06:00Z"`, 1)
+	again, err := contract.AddInspection(ctx, retry)
+	if err != nil {
+		t.Fatalf("resubmission failed: %v", err)
+	}
+	if again.TxID != "tx1" || again.Inspector != "J. Smith" || again.SubmittedAt != first.SubmittedAt {
+		t.Errorf("expected the existing inspection, got %+v", again)
+	}
+	if len(stub.PvtState[inspectionPublicCollection]) != keys {
+		t.Errorf("expected the resubmission to write nothing")
+	}
+
+	// Different measurements for the same inspection event conflict
+	var changed BladeInspection
+	_ = json.Unmarshal([]byte(sampleInspection("RGA46870", "manual", "MROLabMSP")), &changed)
+	changed.Measurements.Source.AR = "243.05 mm"
+	changedJSON, _ := json.Marshal(changed)
+	_, err = contract.AddInspection(ctx, string(changedJSON))
+	var conflict *common.SubmissionConflictError
+	if !errors.As(err, &conflict) || conflict.ExistingTxID != "tx1" {
+		t.Errorf("expected a submission conflict, got %v", err)
+	}
+}
+
+func TestAddInspectionsBatchReturnsDuplicates(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+	first := sampleInspection("RGA46870", "manual", "MROLabMSP")
+	second := sampleInspection("RGA85382", "manual", "MROLabMSP")
+
+	_, err := contract.AddInspectionsBatch(ctx, batchJSON(t, first, second))
+	if err != nil {
+		t.Fatalf("AddInspectionsBatch failed: %v", err)
+	}
+
+	// Resubmitting a partially imported file writes only the missing inspection
+	stub.setTransaction("tx2", 1760947260)
+	batch, err := contract.AddInspectionsBatch(ctx, batchJSON(t, first, second,
+		sampleInspection("RGA85742", "manual", "MROLabMSP")))
+	if err != nil {
+		t.Fatalf("resubmission failed: %v", err)
+	}
+	if !batch.Items[0].Duplicate || !batch.Items[1].Duplicate || batch.Items[2].Duplicate {
+		t.Errorf("expected the first two items to be duplicates, got %+v", batch.Items)
+	}
+	if batch.BlockchainTimestamp != "2025-10-20T08:01:00Z" {
+		t.Errorf("expected the batch transaction's timestamp, got %s", batch.BlockchainTimestamp)
+	}
+
+	// A batch of duplicates only is not recorded again
+	stub.setTransaction("tx3", 1760947320)
+	batch, err = contract.AddInspectionsBatch(ctx, batchJSON(t, first, second))
+	if err != nil || !batch.Items[0].Duplicate || !batch.Items[1].Duplicate {
+		t.Fatalf("expected duplicates, got %+v (%v)", batch, err)
+	}
+	batches, err := contract.GetInspectionBatches(ctx, batch.CSVHash)
+	if err != nil || len(batches) != 2 {
+		t.Errorf("expected 2 recorded batches, got %d (%v)", len(batches), err)
+	}
+}
+
+func TestAddInspectionsBatchRejectsReusedSubmissionIDs(t *testing.T) {
+	items := make([]string, 2)
+	for i, serialNumber := range []string{"RGA46870", "RGA85382"} {
+		var inspection BladeInspection
+		_ = json.Unmarshal([]byte(sampleInspection(serialNumber, "manual", "MROLabMSP")), &inspection)
+		inspection.SubmissionID = "row-1"
+		b, _ := json.Marshal(inspection)
+		items[i] = string(b)
+	}
+
+	_, err := new(SmartContract).AddInspectionsBatch(newContext(newMockStub(), "MROLabMSP"), batchJSON(t, items...))
+	var conflict *common.SubmissionConflictError
+	if !errors.As(err, &conflict) || conflict.SubmissionID != "row-1" {
+		t.Errorf("expected a submission conflict, got %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// Dispositions computed by AddInspection from the part's tolerance specification
+const (
+	DispositionServiceable = "serviceable" // every point within serviceable limits
+	DispositionRepair      = "repair"      // a point outside serviceable limits but within repair limits
+	DispositionScrap       = "scrap"       // a point outside repair limits
+	DispositionUnassessed  = "unassessed"  // no tolerance specification for the part number
+)
+
+// toleranceSpecObjectType prefixes the composite keys of tolerance specifications:
+// toleranceSpec~PartNumber~Version (version zero-padded so keys sort numerically)
+const toleranceSpecObjectType = "toleranceSpec"
+
+// ChordLimit defines the engineering limits of a single chord measurement point (mm).
+// RepairMin/RepairMax are optional; when omitted any point outside Min..Max is scrap.
+type ChordLimit struct {
+	Nominal   float64 `json:"nominal"`
+	Min       float64 `json:"min"`
+	Max       float64 `json:"max"`
+	RepairMin float64 `json:"repairMin,omitempty"`
+	RepairMax float64 `json:"repairMax,omitempty"`
+}
+
+// ToleranceSpec is a versioned set of chord limits for a part number, keyed by point name (AR..AB)
+type ToleranceSpec struct {
+	PartNumber string                `json:"partNumber"`
+	Version    int                   `json:"version"`
+	Limits     map[string]ChordLimit `json:"limits"`
+	CreatedBy  string                `json:"createdBy"`
+	CreatedAt  string                `json:"createdAt"` // ISO 8601 format
+	TxID       string                `json:"txId"`
+}
+
+// isManufacturerAdmin reports whether the caller is an admin (NodeOU "admin") of an active
+// manufacturer organization in the registry
+func isManufacturerAdmin(ctx contractapi.TransactionContextInterface) (bool, error) {
+	org, err := callerOrganization(ctx)
+	if err != nil {
+		return false, err
+	}
+	if org.Role != common.RoleManufacturer {
+		return false, nil
+	}
+
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return false, fmt.Errorf("failed to get client certificate: %v", err)
+	}
+	return common.IsAdmin(cert), nil
+}
+
+// validateLimits checks that every chord point has consistent limits
+func validateLimits(limits map[string]ChordLimit) error {
+	for _, point := range (ChordMeasurements{}).points() {
+		limit, ok := limits[point.Name]
+		if !ok {
+			return fmt.Errorf("missing limits for point %s", point.Name)
+		}
+		if limit.Min > limit.Nominal || limit.Nominal > limit.Max {
+			return fmt.Errorf("limits for point %s must satisfy min <= nominal <= max", point.Name)
+		}
+		if limit.RepairMin != 0 && limit.RepairMin > limit.Min {
+			return fmt.Errorf("repairMin for point %s must not exceed min", point.Name)
+		}
+		if limit.RepairMax != 0 && limit.RepairMax < limit.Max {
+			return fmt.Errorf("repairMax for point %s must not be below max", point.Name)
+		}
+	}
+	if len(limits) != len((ChordMeasurements{}).points()) {
+		return fmt.Errorf("limits contain unknown points")
+	}
+	return nil
+}
+
+// SetToleranceSpec stores a new version of the tolerance specification for a part number.
+// Only admins of manufacturer organizations may define tolerances; previous versions remain on the ledger.
+func (s *SmartContract) SetToleranceSpec(ctx contractapi.TransactionContextInterface, partNumber string, limitsJSON string) (*ToleranceSpec, error) {
+	isAdmin, err := isManufacturerAdmin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !isAdmin {
+		return nil, fmt.Errorf("only admins of manufacturer organizations can set tolerance specifications")
+	}
+
+	if partNumber == "" {
+		return nil, fmt.Errorf("partNumber is required")
+	}
+
+	var limits map[string]ChordLimit
+	err = json.Unmarshal([]byte(limitsJSON), &limits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal limits: %v", err)
+	}
+	if err := validateLimits(limits); err != nil {
+		return nil, err
+	}
+
+	current, err := s.readToleranceSpec(ctx, partNumber, 0)
+	if err != nil {
+		return nil, err
+	}
+	version := 1
+	if current != nil {
+		version = current.Version + 1
+	}
+
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client ID: %v", err)
+	}
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+
+	spec := &ToleranceSpec{
+		PartNumber: partNumber,
+		Version:    version,
+		Limits:     limits,
+		CreatedBy:  clientID,
+		CreatedAt:  time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).UTC().Format(time.RFC3339),
+		TxID:       ctx.GetStub().GetTxID(),
+	}
+
+	specBytes, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tolerance spec: %v", err)
+	}
+
+	key, err := ctx.GetStub().CreateCompositeKey(toleranceSpecObjectType, []string{partNumber, fmt.Sprintf("%06d", version)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tolerance spec key: %v", err)
+	}
+	err = ctx.GetStub().PutState(key, specBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write tolerance spec: %v", err)
+	}
+
+	return spec, nil
+}
+
+// readToleranceSpec reads a specific version of a part's tolerance spec, or the latest when version is 0.
+// Returns nil if no matching spec exists.
+func (s *SmartContract) readToleranceSpec(ctx contractapi.TransactionContextInterface, partNumber string, version int) (*ToleranceSpec, error) {
+	var specBytes []byte
+
+	if version > 0 {
+		key, err := ctx.GetStub().CreateCompositeKey(toleranceSpecObjectType, []string{partNumber, fmt.Sprintf("%06d", version)})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tolerance spec key: %v", err)
+		}
+		specBytes, err = ctx.GetStub().GetState(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tolerance spec: %v", err)
+		}
+	} else {
+		resultsIterator, err := ctx.GetStub().GetStateByPartialCompositeKey(toleranceSpecObjectType, []string{partNumber})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tolerance specs: %v", err)
+		}
+		defer resultsIterator.Close()
+
+		// Versions are zero-padded, so the last key is the latest version
+		for resultsIterator.HasNext() {
+			queryResponse, err := resultsIterator.Next()
+			if err != nil {
+				return nil, err
+			}
+			specBytes = queryResponse.Value
+		}
+	}
+
+	if specBytes == nil {
+		return nil, nil
+	}
+
+	var spec ToleranceSpec
+	err := json.Unmarshal(specBytes, &spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tolerance spec: %v", err)
+	}
+
+	return &spec, nil
+}
+
+// GetToleranceSpec retrieves the latest tolerance specification for a part number
+func (s *SmartContract) GetToleranceSpec(ctx contractapi.TransactionContextInterface, partNumber string) (*ToleranceSpec, error) {
+	return s.GetToleranceSpecVersion(ctx, partNumber, 0)
+}
+
+// GetToleranceSpecVersion retrieves a specific version of the tolerance specification for a part number
+func (s *SmartContract) GetToleranceSpecVersion(ctx contractapi.TransactionContextInterface, partNumber string, version int) (*ToleranceSpec, error) {
+	spec, err := s.readToleranceSpec(ctx, partNumber, version)
+	if err != nil {
+		return nil, err
+	}
+	if spec == nil {
+		return nil, fmt.Errorf("tolerance spec for %s does not exist", partNumber)
+	}
+
+	return spec, nil
+}
+
+// assessDisposition compares measurements against a tolerance spec and returns the disposition
+// with the list of points outside serviceable limits
+func assessDisposition(spec *ToleranceSpec, measurements ChordMeasurements) (string, []string) {
+	if spec == nil {
+		return DispositionUnassessed, nil
+	}
+
+	disposition := DispositionServiceable
+	outOfLimit := []string{}
+	for _, point := range measurements.points() {
+		limit := spec.Limits[point.Name]
+		if point.Value >= limit.Min && point.Value <= limit.Max {
+			continue
+		}
+		outOfLimit = append(outOfLimit, point.Name)
+
+		repairMin, repairMax := limit.Min, limit.Max
+		if limit.RepairMin != 0 {
+			repairMin = limit.RepairMin
+		}
+		if limit.RepairMax != 0 {
+			repairMax = limit.RepairMax
+		}
+		if point.Value >= repairMin && point.Value <= repairMax {
+			if disposition == DispositionServiceable {
+				disposition = DispositionRepair
+			}
+		} else {
+			disposition = DispositionScrap
+		}
+	}
+
+	return disposition, outOfLimit
+}
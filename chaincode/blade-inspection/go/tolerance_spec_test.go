@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// sampleLimits builds limits of nominal ± 0.5 mm (repair band ± 1.5 mm) around the given measurements
+func sampleLimits(nominal ChordMeasurements) string {
+	limits := map[string]ChordLimit{}
+	for _, point := range nominal.points() {
+		limits[point.Name] = ChordLimit{
+			Nominal:   point.Value,
+			Min:       point.Value - 0.5,
+			Max:       point.Value + 0.5,
+			RepairMin: point.Value - 1.5,
+			RepairMax: point.Value + 1.5,
+		}
+	}
+	limitsJSON, _ := json.Marshal(limits)
+	return string(limitsJSON)
+}
+
+var nominalChords = ChordMeasurements{
+	AR: 243.0, AP: 251.2, AN: 259.2, AM: 263.5, AL: 264.4, AK: 264.6, AJ: 265.1,
+	AH: 267.6, AG: 269.7, AF: 272.9, AE: 276.4, AD: 279.4, AC: 283.1, AB: 256.1,
+}
+
+func TestSetToleranceSpecRequiresManufacturerAdmin(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	for _, ctx := range []struct {
+		name  string
+		mspID string
+		ou    string
+	}{
+		{"manufacturer client", "ManufacturerMSP", "client"},
+		{"MRO lab admin", "MROLabMSP", "admin"},
+		{"unregistered admin", "AirframerMSP", "admin"},
+	} {
+		_, err := contract.SetToleranceSpec(newContextWithOU(stub, ctx.mspID, ctx.ou), "6A7614", sampleLimits(nominalChords))
+		if err == nil {
+			t.Errorf("expected %s to be rejected", ctx.name)
+		}
+	}
+
+	spec, err := contract.SetToleranceSpec(newAdminContext(stub, "ManufacturerMSP"), "6A7614", sampleLimits(nominalChords))
+	if err != nil {
+		t.Fatalf("SetToleranceSpec failed: %v", err)
+	}
+	if spec.Version != 1 {
+		t.Errorf("expected version 1, got %d", spec.Version)
+	}
+}
+
+func TestSetToleranceSpecByRegisteredManufacturer(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	for _, mspID := range []string{"ManufacturerMSP", "MROLabMSP"} {
+		_, err := contract.RegisterOrganization(newAdminContext(stub, mspID), "AirframerMSP", "manufacturer", "inspectionPrivateAirframerCollection")
+		if err != nil {
+			t.Fatalf("RegisterOrganization failed: %v", err)
+		}
+	}
+
+	spec, err := contract.SetToleranceSpec(newAdminContext(stub, "AirframerMSP"), "7B1234", sampleLimits(nominalChords))
+	if err != nil || spec.CreatedBy != "x509::CN=AirframerMSP" {
+		t.Fatalf("expected a registered manufacturer to set tolerances, got %+v (%v)", spec, err)
+	}
+
+	// Deactivated manufacturers lose the right
+	if _, err = contract.DeactivateOrganization(newAdminContext(stub, "AirframerMSP"), "AirframerMSP"); err != nil {
+		t.Fatalf("DeactivateOrganization failed: %v", err)
+	}
+	_, err = contract.SetToleranceSpec(newAdminContext(stub, "AirframerMSP"), "7B1234", sampleLimits(nominalChords))
+	if err == nil || !strings.Contains(err.Error(), "deactivated") {
+		t.Errorf("expected a deactivated manufacturer to be rejected, got %v", err)
+	}
+}
+
+func TestToleranceSpecVersions(t *testing.T) {
+	stub := newMockStub()
+	adminCtx := newAdminContext(stub, "ManufacturerMSP")
+	contract := new(SmartContract)
+
+	if _, err := contract.SetToleranceSpec(adminCtx, "6A7614", sampleLimits(nominalChords)); err != nil {
+		t.Fatalf("SetToleranceSpec v1 failed: %v", err)
+	}
+	revised := nominalChords
+	revised.AR = 244.0
+	if _, err := contract.SetToleranceSpec(adminCtx, "6A7614", sampleLimits(revised)); err != nil {
+		t.Fatalf("SetToleranceSpec v2 failed: %v", err)
+	}
+
+	ctx := newContext(stub, "MROLabMSP")
+	latest, err := contract.GetToleranceSpec(ctx, "6A7614")
+	if err != nil || latest.Version != 2 || latest.Limits["AR"].Nominal != 244.0 {
+		t.Fatalf("unexpected latest spec: %+v (%v)", latest, err)
+	}
+	first, err := contract.GetToleranceSpecVersion(ctx, "6A7614", 1)
+	if err != nil || first.Limits["AR"].Nominal != 243.0 {
+		t.Fatalf("unexpected first spec: %+v (%v)", first, err)
+	}
+	if _, err := contract.GetToleranceSpec(ctx, "6A0000"); err == nil {
+		t.Error("expected missing spec to fail")
+	}
+}
+
+func TestSetToleranceSpecRejectsInvalidLimits(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	limits := map[string]ChordLimit{"AR": {Nominal: 243, Min: 244, Max: 245}}
+	limitsJSON, _ := json.Marshal(limits)
+	if _, err := contract.SetToleranceSpec(newAdminContext(stub, "ManufacturerMSP"), "6A7614", string(limitsJSON)); err == nil {
+		t.Fatal("expected incomplete and inconsistent limits to be rejected")
+	}
+}
+
+func TestAddInspectionDisposition(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	if _, err := contract.SetToleranceSpec(newAdminContext(stub, "ManufacturerMSP"), "6A7614", sampleLimits(nominalChords)); err != nil {
+		t.Fatalf("SetToleranceSpec failed: %v", err)
+	}
+
+	repair := nominalChords
+	repair.AB = nominalChords.AB - 1.0
+	scrap := nominalChords
+	scrap.AC = nominalChords.AC - 2.0
+	scrap.AD = nominalChords.AD + 1.0
+
+	addMeasuredInspection(t, contract, stub, "before_surfacing", "2025-10-20T08:00:00Z", nominalChords)
+	addMeasuredInspection(t, contract, stub, "manual", "2025-10-21T08:00:00Z", repair)
+	addMeasuredInspection(t, contract, stub, "after_surfacing", "2025-10-22T08:00:00Z", scrap)
+
+	history, err := contract.GetBladeHistory(newContext(stub, "ManufacturerMSP"), "6A7614", "RGA46870")
+	if err != nil {
+		t.Fatalf("GetBladeHistory failed: %v", err)
+	}
+
+	expected := map[string]struct {
+		disposition string
+		outOfLimit  []string
+	}{
+		"before_surfacing": {DispositionServiceable, nil},
+		"manual":           {DispositionRepair, []string{"AB"}},
+		"after_surfacing":  {DispositionScrap, []string{"AD", "AC"}},
+	}
+	for _, inspection := range history {
+		want := expected[inspection.OccasionLabel]
+		if inspection.Disposition != want.disposition || inspection.ToleranceSpecVersion != 1 {
+			t.Errorf("%s: expected %s (spec v1), got %s (spec v%d)", inspection.OccasionLabel,
+				want.disposition, inspection.Disposition, inspection.ToleranceSpecVersion)
+		}
+		if len(inspection.OutOfLimitPoints) != len(want.outOfLimit) {
+			t.Errorf("%s: expected out-of-limit points %v, got %v", inspection.OccasionLabel, want.outOfLimit, inspection.OutOfLimitPoints)
+			continue
+		}
+		for i := range want.outOfLimit {
+			if inspection.OutOfLimitPoints[i] != want.outOfLimit[i] {
+				t.Errorf("%s: expected out-of-limit points %v, got %v", inspection.OccasionLabel, want.outOfLimit, inspection.OutOfLimitPoints)
+			}
+		}
+	}
+}
+
+func TestAddInspectionWithoutSpecIsUnassessed(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+
+	addMeasuredInspection(t, contract, stub, "manual", "2025-10-20T08:00:00Z", nominalChords)
+
+	inspection, err := contract.GetInspection(newContext(stub, "MROLabMSP"), "6A7614", "RGA46870")
+	if err != nil {
+		t.Fatalf("GetInspection failed: %v", err)
+	}
+	if inspection.Disposition != DispositionUnassessed {
+		t.Errorf("expected unassessed disposition, got %q", inspection.Disposition)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// inspectionSchemaJSON is the JSON Schema of an inspection submitted to AddInspection or
+// AddInspectionsBatch, published for clients
+//
+//go:embed schemas/blade_inspection.schema.json
+var inspectionSchemaJSON []byte
+
+var inspectionSchema = common.MustCompileSchema(inspectionSchemaJSON)
+
+// validateInspection checks an inspection's JSON against the schema and then the rules the schema
+// cannot express: no private fields, RFC3339 dates, the CSV hash format and the source measurement
+// values. It returns the parsed inspection, or nil and every field error.
+func validateInspection(inspectionJSON []byte) (*BladeInspection, common.FieldErrors) {
+	var errs common.FieldErrors
+	errs.CheckNoPrivateFields(inspectionJSON, &BladeInspection{})
+	errs = append(errs, inspectionSchema.Validate(inspectionJSON)...)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var inspection BladeInspection
+	err := json.Unmarshal(inspectionJSON, &inspection)
+	if err != nil {
+		errs.Add("", "invalid_json", fmt.Sprintf("failed to unmarshal inspection: %v", err))
+		return nil, errs
+	}
+
+	errs.CheckRFC3339("inspectionDate", inspection.InspectionDate)
+	if inspection.SubmittedAt != "" {
+		errs.CheckRFC3339("submittedAt", inspection.SubmittedAt)
+	}
+	if inspection.CSVHash != "" {
+		errs.CheckSHA256("csvHash", inspection.CSVHash)
+	}
+
+	// The schema has checked the unit and precision, so each source value can be parsed on its own
+	m := inspection.Measurements
+	for i, value := range m.Source.values() {
+		if _, err := parseSourceValue(value, m.Unit, m.SourcePrecision); err != nil {
+			errs.Add("measurements.source."+strings.ToLower(chordPointNames[i]), common.CodeInvalidValue, err.Error())
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	// Versions are assigned by AmendInspection, never by the input
+	inspection.Version, inspection.Amendment, inspection.SupersededBy = 0, nil, ""
+	return &inspection, nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// fieldCodes returns the field errors of a validation error as field -> code
+func fieldCodes(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var validationErr *common.ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a validation error, got %v", err)
+	}
+	codes := map[string]string{}
+	for _, fieldError := range validationErr.Errors {
+		codes[fieldError.Field] = fieldError.Code
+	}
+	return codes
+}
+
+func TestAddInspectionValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		edit     func(fields map[string]interface{})
+		expected map[string]string
+	}{
+		{"schema", func(fields map[string]interface{}) {
+			delete(fields, "serialNumber")
+			fields["occasionLabel"] = ""
+			fields["grade"] = "A"
+			measurements := fields["measurements"].(map[string]interface{})
+			measurements["unit"] = "cm"
+			measurements["sourcePrecision"] = 7
+			delete(measurements["source"].(map[string]interface{}), "ar")
+		}, map[string]string{
+			"serialNumber":                 "required",
+			"occasionLabel":                "string_gte",
+			"grade":                        "additional_property_not_allowed",
+			"measurements.unit":            "enum",
+			"measurements.sourcePrecision": "number_lte",
+			"measurements.source.ar":       "required",
+		}},
+		{"semantic", func(fields map[string]interface{}) {
+			fields["inspectionDate"] = "20/10/2025"
+			fields["submittedAt"] = "2025-10-20 08:05"
+			fields["csvHash"] = "d2a84f4b"
+			source := fields["measurements"].(map[string]interface{})["source"].(map[string]interface{})
+			source["ar"] = "9.581 in"
+			source["ap"] = "251.1800 mm"
+		}, map[string]string{
+			"inspectionDate":         common.CodeInvalidTimestamp,
+			"submittedAt":            common.CodeInvalidTimestamp,
+			"csvHash":                common.CodeInvalidHash,
+			"measurements.source.ar": common.CodeInvalidValue,
+			"measurements.source.ap": common.CodeInvalidValue,
+		}},
+	}
+
+	for _, test := range tests {
+		var fields map[string]interface{}
+		_ = json.Unmarshal([]byte(sampleInspection("RGA46870", "manual", "MROLabMSP")), &fields)
+		test.edit(fields)
+		inspectionJSON, _ := json.Marshal(fields)
+
+		stub := newMockStub()
+		_, err := new(SmartContract).AddInspection(newContext(stub, "MROLabMSP"), string(inspectionJSON))
+		codes := fieldCodes(t, err)
+		if len(codes) != len(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, codes)
+		}
+		for field, code := range test.expected {
+			if codes[field] != code {
+				t.Errorf("%s: expected %s on %s, got %v", test.name, code, field, codes)
+			}
+		}
+		if len(stub.PvtState) != 0 {
+			t.Errorf("%s: expected nothing written", test.name)
+		}
+	}
+}
+
+func TestAddInspectionsBatchValidatesEveryItem(t *testing.T) {
+	valid := sampleInspection("RGA46870", "manual", "MROLabMSP")
+	badDate := strings.Replace(sampleInspection("RGA85382", "manual", "MROLabMSP"),
+		`"inspectionDate":"2025-10-20T08:00:00Z"`, `"inspectionDate":"2025-10-20"`, 1)
+	noSerial := strings.Replace(sampleInspection("RGA85742", "manual", "MROLabMSP"),
+		`"serialNumber":"RGA85742",`, "", 1)
+
+	stub := newMockStub()
+	_, err := new(SmartContract).AddInspectionsBatch(newContext(stub, "MROLabMSP"), batchJSON(t, valid, badDate, noSerial))
+	codes := fieldCodes(t, err)
+	expected := map[string]string{"1.inspectionDate": common.CodeInvalidTimestamp, "2.serialNumber": "required"}
+	if len(codes) != len(expected) || codes["1.inspectionDate"] != expected["1.inspectionDate"] ||
+		codes["2.serialNumber"] != expected["2.serialNumber"] {
+		t.Errorf("expected %v, got %v", expected, codes)
+	}
+	if len(stub.PvtState) != 0 {
+		t.Errorf("expected nothing written")
+	}
+}
+
+func TestAddInspectionTakesPrivateFieldsFromTransient(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	ctx := newContext(stub, "MROLabMSP")
+
+	// Private fields would be written to the block as arguments
+	var fields map[string]interface{}
+	_ = json.Unmarshal([]byte(sampleInspection("RGA46870", "manual", "MROLabMSP")), &fields)
+	fields["inspector"] = "J. Smith"
+	fields["submittedBy"] = "x509::CN=someone"
+	withPrivate, _ := json.Marshal(fields)
+	_, err := contract.AddInspection(ctx, string(withPrivate))
+	codes := fieldCodes(t, err)
+	if len(codes) != 2 || codes["inspector"] != common.CodePrivateField || codes["submittedBy"] != common.CodePrivateField {
+		t.Errorf("expected private field errors, got %v", codes)
+	}
+	_, err = contract.AddInspectionsBatch(ctx, batchJSON(t, sampleInspection("RGA85382", "manual", "MROLabMSP"), string(withPrivate)))
+	if codes := fieldCodes(t, err); codes["1.inspector"] != common.CodePrivateField {
+		t.Errorf("expected a private field error on item 1, got %v", codes)
+	}
+	if len(stub.PvtState) != 0 {
+		t.Errorf("expected nothing written")
+	}
+
+	// A batch takes one object per item
+	stub.TransientMap[common.TransientPrivateKey] = []byte(`[{"inspector":"J. Smith"},{"inspector":"A. Jones"}]`)
+	_, err = contract.AddInspectionsBatch(ctx, batchJSON(t, sampleInspection("RGA46870", "manual", "MROLabMSP"),
+		sampleInspection("RGA85382", "manual", "MROLabMSP")))
+	if err != nil {
+		t.Fatalf("AddInspectionsBatch failed: %v", err)
+	}
+	inspection, err := contract.GetInspection(ctx, "6A7614", "RGA85382")
+	if err != nil || inspection.Inspector != "A. Jones" {
+		t.Errorf("expected the item's inspector, got %+v (%v)", inspection, err)
+	}
+
+	stub.setTransaction("tx2", 1760947260)
+	_, err = contract.AddInspectionsBatch(ctx, batchJSON(t, sampleInspection("RGA85742", "manual", "MROLabMSP")))
+	if err == nil || !strings.Contains(err.Error(), "holds 2 items for 1 inspections") {
+		t.Errorf("expected a transient item count mismatch, got %v", err)
+	}
+	stub.TransientMap[common.TransientPrivateKey] = []byte(`{"inspector":"J. Smith","serialNumber":"RGA00000"}`)
+	_, err = contract.AddInspection(ctx, sampleInspection("RGA85742", "manual", "MROLabMSP"))
+	if err == nil || !strings.Contains(err.Error(), "invalid transient") {
+		t.Errorf("expected public fields in the transient map to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// WorkflowStatus returns the inspection's status and the organization that submitted it
+func (inspection *BladeInspection) WorkflowStatus() (string, string) {
+	return inspection.Status, inspection.Organization
+}
+
+// AppendStatusChange moves the inspection to the status of change and records it in the status history
+func (inspection *BladeInspection) AppendStatusChange(change common.StatusChange) {
+	inspection.Status = change.Status
+	inspection.StatusHistory = append(inspection.StatusHistory, change)
+}
+
+// StatusActorAttributes identifies an inspection version in the keys of its transition actors. Amended
+// versions go through the workflow again, so their keys include the version.
+func (inspection *BladeInspection) StatusActorAttributes() []string {
+	attributes := []string{inspection.PartNumber, inspection.SerialNumber, inspection.OccasionLabel, inspection.InspectionDate}
+	if versionOf(inspection) > 1 {
+		attributes = append(attributes, strconv.Itoa(inspection.Version))
+	}
+	return attributes
+}
+
+// changeInspectionStatus moves an inspection event of the caller's org to status (see common.ChangeStatus)
+// and updates the blade's current inspection if it is that event
+func (s *SmartContract) changeInspectionStatus(ctx contractapi.TransactionContextInterface,
+	partNumber, serialNumber, occasionLabel, inspectionDate, status, comment string) (*BladeInspection, error) {
+
+	err := common.CheckStatusChange(ctx, status, comment, inspectionMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := inspectionKey(ctx, &BladeInspection{PartNumber: partNumber, SerialNumber: serialNumber,
+		OccasionLabel: occasionLabel, InspectionDate: inspectionDate})
+	if err != nil {
+		return nil, err
+	}
+	publicDataBytes, err := ctx.GetStub().GetPrivateData(inspectionPublicCollection, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read public data: %v", err)
+	}
+	if publicDataBytes == nil {
+		return nil, fmt.Errorf("inspection %s %s (%s, %s) does not exist", partNumber, serialNumber, occasionLabel, inspectionDate)
+	}
+	var inspection BladeInspection
+	err = json.Unmarshal(publicDataBytes, &inspection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal public data: %v", err)
+	}
+
+	privateCollectionName, err := getPrivateCollectionName(ctx)
+	if err != nil {
+		return nil, err
+	}
+	err = common.ChangeStatus(ctx, &inspection, bladeKey(partNumber, serialNumber), privateCollectionName, status, comment)
+	if err != nil {
+		return nil, err
+	}
+
+	publicDataBytes, err = common.MarshalPublic(&inspection)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public data: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData(inspectionPublicCollection, key, publicDataBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to write public data: %v", err)
+	}
+
+	// The blade's current inspection is a copy, kept in step while it is this event
+	current, err := readCurrentInspection(ctx, partNumber, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.OccasionLabel == occasionLabel && current.InspectionDate == inspectionDate {
+		err = ctx.GetStub().PutPrivateData(inspectionPublicCollection, bladeKey(partNumber, serialNumber), publicDataBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write current inspection: %v", err)
+		}
+	}
+
+	err = readInspectionPrivate(ctx, privateCollectionName, &inspection)
+	if err != nil {
+		return nil, err
+	}
+	return &inspection, nil
+}
+
+// SubmitInspection submits a draft inspection for review. Only certified inspectors of the submitting
+// organization may submit it.
+func (s *SmartContract) SubmitInspection(ctx contractapi.TransactionContextInterface,
+	partNumber, serialNumber, occasionLabel, inspectionDate string) (*BladeInspection, error) {
+	return s.changeInspectionStatus(ctx, partNumber, serialNumber, occasionLabel, inspectionDate, common.StatusSubmitted, "")
+}
+
+// ReviewInspection records the Level 3 review of a submitted inspection. Only Level 3 supervisors certified
+// in dimensional inspection may review, and not inspections they recorded themselves.
+func (s *SmartContract) ReviewInspection(ctx contractapi.TransactionContextInterface,
+	partNumber, serialNumber, occasionLabel, inspectionDate, comment string) (*BladeInspection, error) {
+	return s.changeInspectionStatus(ctx, partNumber, serialNumber, occasionLabel, inspectionDate, common.StatusReviewed, comment)
+}
+
+// ReleaseInspection releases a reviewed inspection, after which it can no longer be changed. Only
+// supervisors may release inspections.
+func (s *SmartContract) ReleaseInspection(ctx contractapi.TransactionContextInterface,
+	partNumber, serialNumber, occasionLabel, inspectionDate, comment string) (*BladeInspection, error) {
+	return s.changeInspectionStatus(ctx, partNumber, serialNumber, occasionLabel, inspectionDate, common.StatusReleased, comment)
+}
+
+// RejectInspection rejects a submitted or reviewed inspection for the given reason. Only supervisors may
+// reject inspections.
+func (s *SmartContract) RejectInspection(ctx contractapi.TransactionContextInterface,
+	partNumber, serialNumber, occasionLabel, inspectionDate, reason string) (*BladeInspection, error) {
+	return s.changeInspectionStatus(ctx, partNumber, serialNumber, occasionLabel, inspectionDate, common.StatusRejected, reason)
+}
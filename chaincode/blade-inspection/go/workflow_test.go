@@ -0,0 +1,162 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mahmoudhafez3/thermotrace/chaincode/common"
+)
+
+// certifiedSupervisor holds the certificate attributes of a Level 3 dimensional supervisor
+var certifiedSupervisor = map[string]string{"role": "supervisor", "ndt.level": "3", "ndt.method": "dimensional"}
+
+// draftInspection returns a sample inspection requested as a draft
+func draftInspection(serialNumber string) string {
+	var inspection BladeInspection
+	_ = json.Unmarshal([]byte(sampleInspection(serialNumber, "manual", "MROLabMSP")), &inspection)
+	inspection.Status = common.StatusDraft
+	inspectionJSON, _ := json.Marshal(inspection)
+	return string(inspectionJSON)
+}
+
+func TestInspectionApprovalWorkflow(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	inspector := newContext(stub, "MROLabMSP")
+	supervisor := newNamedContext(stub, "MROLabMSP", "supervisor1", certifiedSupervisor)
+	date := "2025-10-20T08:00:00Z"
+
+	recorded, err := contract.AddInspection(inspector, draftInspection("RGA46870"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	if recorded.Status != common.StatusDraft || len(recorded.StatusHistory) != 1 || recorded.StatusHistory[0].Role != "inspector" {
+		t.Errorf("expected a draft, got %s %+v", recorded.Status, recorded.StatusHistory)
+	}
+
+	// A draft must be submitted before it is reviewed
+	stub.setTransaction("tx2", 1760947260)
+	_, err = contract.ReviewInspection(supervisor, "6A7614", "RGA46870", "manual", date, "")
+	if err == nil || !strings.Contains(err.Error(), "cannot change status from draft to reviewed") {
+		t.Errorf("expected the review of a draft to fail, got %v", err)
+	}
+	if _, err = contract.SubmitInspection(inspector, "6A7614", "RGA46870", "manual", date); err != nil {
+		t.Fatalf("SubmitInspection failed: %v", err)
+	}
+
+	// Inspectors cannot review, and supervisors cannot review their own results
+	stub.setTransaction("tx3", 1760947320)
+	if _, err = contract.ReviewInspection(inspector, "6A7614", "RGA46870", "manual", date, ""); err == nil {
+		t.Error("expected an inspector to be rejected as reviewer")
+	}
+	_, err = contract.ReviewInspection(newContextWithAttrs(stub, "MROLabMSP", "client", certifiedSupervisor), "6A7614", "RGA46870", "manual", date, "")
+	if err == nil || !strings.Contains(err.Error(), "someone other than the inspector") {
+		t.Errorf("expected the submitter to be rejected as reviewer, got %v", err)
+	}
+	_, err = contract.ReviewInspection(newNamedContext(stub, "ManufacturerMSP", "qs1", certifiedSupervisor), "6A7614", "RGA46870", "manual", date, "")
+	if err == nil || !strings.Contains(err.Error(), "only the owning organization MROLabMSP") {
+		t.Errorf("expected another org to be rejected, got %v", err)
+	}
+	if _, err = contract.ReviewInspection(supervisor, "6A7614", "RGA46870", "manual", date, "chords verified"); err != nil {
+		t.Fatalf("ReviewInspection failed: %v", err)
+	}
+
+	stub.setTransaction("tx4", 1760947380)
+	released, err := contract.ReleaseInspection(supervisor, "6A7614", "RGA46870", "manual", date, "")
+	if err != nil {
+		t.Fatalf("ReleaseInspection failed: %v", err)
+	}
+	if released.Inspector != "J. Smith" {
+		t.Errorf("expected the private fields of the caller's org, got %q", released.Inspector)
+	}
+
+	// The current inspection follows the event, with who did what and when
+	current, err := contract.GetInspectionPublic(inspector, "6A7614", "RGA46870")
+	if err != nil {
+		t.Fatalf("GetInspectionPublic failed: %v", err)
+	}
+	if current.Status != common.StatusReleased || len(current.StatusHistory) != 4 {
+		t.Fatalf("expected a released inspection with 4 status changes, got %s %+v", current.Status, current.StatusHistory)
+	}
+	review := current.StatusHistory[2]
+	if review.Status != common.StatusReviewed || review.Role != "supervisor" || review.Comment != "chords verified" ||
+		review.TxID != "tx3" || review.Timestamp != "2025-10-20T08:02:00Z" || review.Organization != "MROLabMSP" {
+		t.Errorf("unexpected review %+v", review)
+	}
+	actor, err := common.ReadStatusActor(inspector, "inspectionPrivateMROLabCollection", current, common.StatusReviewed)
+	if err != nil || actor == nil || actor.ID != "x509::CN=supervisor1" || actor.TxID != "tx3" {
+		t.Errorf("unexpected reviewer %+v (%v)", actor, err)
+	}
+	for key, value := range stub.PvtState[inspectionPublicCollection] {
+		if strings.Contains(string(value), "supervisor1") {
+			t.Errorf("public record %q contains the reviewer: %s", key, value)
+		}
+	}
+
+	// Released inspections are immutable
+	stub.setTransaction("tx5", 1760947440)
+	_, err = contract.RejectInspection(supervisor, "6A7614", "RGA46870", "manual", date, "late finding")
+	if err == nil || !strings.Contains(err.Error(), "released") {
+		t.Errorf("expected a released inspection to be final, got %v", err)
+	}
+}
+
+func TestRejectInspection(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	supervisor := newNamedContext(stub, "MROLabMSP", "supervisor1", certifiedSupervisor)
+	date := "2025-10-20T08:00:00Z"
+
+	recorded, err := contract.AddInspection(newContext(stub, "MROLabMSP"), sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+	if recorded.Status != common.StatusSubmitted {
+		t.Errorf("expected a submitted inspection, got %q", recorded.Status)
+	}
+
+	stub.setTransaction("tx2", 1760947260)
+	if _, err = contract.RejectInspection(supervisor, "6A7614", "RGA46870", "manual", date, ""); err == nil {
+		t.Error("expected a rejection without a reason to fail")
+	}
+	rejected, err := contract.RejectInspection(supervisor, "6A7614", "RGA46870", "manual", date, "probe out of calibration")
+	if err != nil {
+		t.Fatalf("RejectInspection failed: %v", err)
+	}
+	if rejected.Status != common.StatusRejected || rejected.StatusHistory[1].Comment != "probe out of calibration" {
+		t.Errorf("unexpected rejection %s %+v", rejected.Status, rejected.StatusHistory)
+	}
+
+	stub.setTransaction("tx3", 1760947320)
+	_, err = contract.ReviewInspection(supervisor, "6A7614", "RGA46870", "manual", date, "")
+	if err == nil || !strings.Contains(err.Error(), "cannot change status from rejected") {
+		t.Errorf("expected a rejected inspection to be final, got %v", err)
+	}
+	_, err = contract.SubmitInspection(newContext(stub, "MROLabMSP"), "6A7614", "RGA46870", "manual", "2025-10-21T08:00:00Z")
+	if err == nil || !strings.Contains(err.Error(), "does not exist") {
+		t.Errorf("expected an unknown inspection to fail, got %v", err)
+	}
+}
+
+func TestReviewInspectionRecordedBeforeWorkflow(t *testing.T) {
+	stub := newMockStub()
+	contract := new(SmartContract)
+	recorded, err := contract.AddInspection(newContext(stub, "MROLabMSP"), sampleInspection("RGA46870", "manual", "MROLabMSP"))
+	if err != nil {
+		t.Fatalf("AddInspection failed: %v", err)
+	}
+
+	// Inspections committed before the approval workflow have no status
+	recorded.Status, recorded.StatusHistory = "", nil
+	key, _ := inspectionKey(newContext(stub, "MROLabMSP"), recorded)
+	publicDataBytes, _ := common.MarshalPublic(recorded)
+	stub.PvtState[inspectionPublicCollection][key] = publicDataBytes
+
+	stub.setTransaction("tx2", 1760947260)
+	_, err = contract.ReviewInspection(newNamedContext(stub, "MROLabMSP", "supervisor1", certifiedSupervisor),
+		"6A7614", "RGA46870", "manual", "2025-10-20T08:00:00Z", "")
+	if err == nil || !strings.Contains(err.Error(), "committed before the approval workflow") {
+		t.Errorf("expected the review of an inspection without status to fail, got %v", err)
+	}
+}
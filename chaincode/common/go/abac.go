@@ -0,0 +1,123 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Certificate attributes issued by the organizations' CAs (fabric-ca-client register --id.attrs ...:ecert)
+const (
+	AttrRole      = "role"       // UserRoleInspector or UserRoleSupervisor
+	AttrNDTLevel  = "ndt.level"  // NDT certification level (EN 4179 / NAS 410), 1 to 3
+	AttrNDTMethod = "ndt.method" // comma-separated NDT methods the holder is certified in
+)
+
+// Values of the role attribute
+const (
+	UserRoleInspector  = "inspector"
+	UserRoleSupervisor = "supervisor"
+)
+
+// MinNDTLevel is the lowest certification level allowed to sign off inspection results
+const MinNDTLevel = 2
+
+// ReviewNDTLevel is the certification level required to review inspection results before release
+const ReviewNDTLevel = 3
+
+// AttributeReader reads the attributes of the client certificate (satisfied by cid.ClientIdentity)
+type AttributeReader interface {
+	GetAttributeValue(attrName string) (value string, found bool, err error)
+}
+
+// attribute returns a certificate attribute, or an error naming the missing attribute
+func attribute(id AttributeReader, name string) (string, error) {
+	value, found, err := id.GetAttributeValue(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s attribute: %v", name, err)
+	}
+	if !found {
+		return "", fmt.Errorf("certificate has no %s attribute", name)
+	}
+	return value, nil
+}
+
+// CheckInspector returns an error unless the caller is an inspector or supervisor certified in an
+// NDT method at MinNDTLevel or above
+func CheckInspector(id AttributeReader, method string) error {
+	role, err := attribute(id, AttrRole)
+	if err == nil && role != UserRoleInspector && role != UserRoleSupervisor {
+		err = fmt.Errorf("role %q is not allowed to submit results", role)
+	}
+	if err != nil {
+		return fmt.Errorf("only certified inspectors can submit results: %v", err)
+	}
+
+	levelValue, err := attribute(id, AttrNDTLevel)
+	if err != nil {
+		return fmt.Errorf("only certified inspectors can submit results: %v", err)
+	}
+	level, err := strconv.Atoi(levelValue)
+	if err != nil || level < MinNDTLevel {
+		return fmt.Errorf("only inspectors certified at NDT level %d or above can submit results, got level %q",
+			MinNDTLevel, levelValue)
+	}
+
+	methods, err := attribute(id, AttrNDTMethod)
+	if err != nil {
+		return fmt.Errorf("only certified inspectors can submit results: %v", err)
+	}
+	if !certifiedIn(methods, method) {
+		return fmt.Errorf("only inspectors certified in %s can submit these results, got %q", method, methods)
+	}
+	return nil
+}
+
+// certifiedIn reports whether a comma-separated ndt.method value includes method
+func certifiedIn(methods, method string) bool {
+	for _, m := range strings.Split(methods, ",") {
+		if strings.TrimSpace(m) == method {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckSupervisor returns an error unless the caller holds the supervisor role
+func CheckSupervisor(id AttributeReader) error {
+	role, err := attribute(id, AttrRole)
+	if err != nil {
+		return fmt.Errorf("only supervisors can approve results: %v", err)
+	}
+	if role != UserRoleSupervisor {
+		return fmt.Errorf("only supervisors can approve results, caller has role %q", role)
+	}
+	return nil
+}
+
+// CheckReviewer returns an error unless the caller is a supervisor certified in an NDT method at
+// ReviewNDTLevel (Level 3)
+func CheckReviewer(id AttributeReader, method string) error {
+	err := CheckSupervisor(id)
+	if err != nil {
+		return err
+	}
+
+	levelValue, err := attribute(id, AttrNDTLevel)
+	if err != nil {
+		return fmt.Errorf("only Level %d supervisors can review results: %v", ReviewNDTLevel, err)
+	}
+	level, err := strconv.Atoi(levelValue)
+	if err != nil || level < ReviewNDTLevel {
+		return fmt.Errorf("only Level %d supervisors can review results, got level %q", ReviewNDTLevel, levelValue)
+	}
+
+	methods, err := attribute(id, AttrNDTMethod)
+	if err != nil {
+		return fmt.Errorf("only Level %d supervisors can review results: %v", ReviewNDTLevel, err)
+	}
+	if !certifiedIn(methods, method) {
+		return fmt.Errorf("only supervisors certified in %s can review these results, got %q", method, methods)
+	}
+	return nil
+}
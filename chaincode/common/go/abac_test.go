@@ -0,0 +1,77 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+// attributes is a client identity's certificate attributes
+type attributes map[string]string
+
+func (a attributes) GetAttributeValue(name string) (string, bool, error) {
+	value, found := a[name]
+	return value, found, nil
+}
+
+func TestCheckInspector(t *testing.T) {
+	certified := attributes{AttrRole: "inspector", AttrNDTLevel: "2", AttrNDTMethod: "dimensional, thermography"}
+	if err := CheckInspector(certified, "thermography"); err != nil {
+		t.Errorf("expected a certified inspector, got %v", err)
+	}
+	supervisor := attributes{AttrRole: "supervisor", AttrNDTLevel: "3", AttrNDTMethod: "thermography"}
+	if err := CheckInspector(supervisor, "thermography"); err != nil {
+		t.Errorf("expected a supervisor to submit, got %v", err)
+	}
+
+	tests := []struct {
+		attributes attributes
+		message    string
+	}{
+		{attributes{}, "certificate has no role attribute"},
+		{attributes{AttrRole: "viewer", AttrNDTLevel: "2", AttrNDTMethod: "thermography"}, `role "viewer" is not allowed`},
+		{attributes{AttrRole: "inspector", AttrNDTMethod: "thermography"}, "certificate has no ndt.level attribute"},
+		{attributes{AttrRole: "inspector", AttrNDTLevel: "1", AttrNDTMethod: "thermography"}, `NDT level 2 or above can submit results, got level "1"`},
+		{attributes{AttrRole: "inspector", AttrNDTLevel: "two", AttrNDTMethod: "thermography"}, `got level "two"`},
+		{attributes{AttrRole: "inspector", AttrNDTLevel: "2", AttrNDTMethod: "ultrasonic"}, `certified in thermography can submit these results, got "ultrasonic"`},
+	}
+	for _, test := range tests {
+		err := CheckInspector(test.attributes, "thermography")
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("%v: expected %q, got %v", test.attributes, test.message, err)
+		}
+	}
+}
+
+func TestCheckSupervisor(t *testing.T) {
+	if err := CheckSupervisor(attributes{AttrRole: "supervisor"}); err != nil {
+		t.Errorf("expected a supervisor, got %v", err)
+	}
+	if err := CheckSupervisor(attributes{AttrRole: "inspector", AttrNDTLevel: "3"}); err == nil {
+		t.Errorf("expected an inspector to be rejected")
+	}
+	if err := CheckSupervisor(attributes{}); err == nil {
+		t.Errorf("expected a missing role to be rejected")
+	}
+}
+
+func TestCheckReviewer(t *testing.T) {
+	if err := CheckReviewer(attributes{AttrRole: "supervisor", AttrNDTLevel: "3", AttrNDTMethod: "dimensional,thermography"}, "dimensional"); err != nil {
+		t.Errorf("expected a Level 3 supervisor, got %v", err)
+	}
+
+	tests := []struct {
+		attributes attributes
+		message    string
+	}{
+		{attributes{AttrRole: "inspector", AttrNDTLevel: "3", AttrNDTMethod: "dimensional"}, "only supervisors can approve results"},
+		{attributes{AttrRole: "supervisor", AttrNDTMethod: "dimensional"}, "certificate has no ndt.level attribute"},
+		{attributes{AttrRole: "supervisor", AttrNDTLevel: "2", AttrNDTMethod: "dimensional"}, `only Level 3 supervisors can review results, got level "2"`},
+		{attributes{AttrRole: "supervisor", AttrNDTLevel: "3", AttrNDTMethod: "thermography"}, `certified in dimensional can review these results, got "thermography"`},
+	}
+	for _, test := range tests {
+		err := CheckReviewer(test.attributes, "dimensional")
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("%v: expected %q, got %v", test.attributes, test.message, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package common
+
+import (
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58Decode decodes a base58btc string (the Bitcoin alphabet used by IPFS)
+func base58Decode(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		digit := strings.IndexRune(base58Alphabet, c)
+		if digit < 0 {
+			return nil, fmt.Errorf("invalid base58 character %q", c)
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(digit)))
+	}
+
+	// Leading '1's encode leading zero bytes
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
+
+// uvarint reads an unsigned varint, returning it and the remaining bytes
+func uvarint(b []byte) (uint64, []byte, error) {
+	value, n := binary.Uvarint(b)
+	if n <= 0 {
+		return 0, nil, fmt.Errorf("invalid varint")
+	}
+	return value, b[n:], nil
+}
+
+// checkMultihash checks that b is a single multihash whose digest has the declared length
+func checkMultihash(b []byte) error {
+	_, rest, err := uvarint(b)
+	if err != nil {
+		return fmt.Errorf("invalid multihash code: %v", err)
+	}
+	length, digest, err := uvarint(rest)
+	if err != nil {
+		return fmt.Errorf("invalid multihash length: %v", err)
+	}
+	if uint64(len(digest)) != length {
+		return fmt.Errorf("multihash digest is %d bytes, expected %d", len(digest), length)
+	}
+	return nil
+}
+
+// ParseCID checks that s is an IPFS content identifier: a CIDv0 (base58btc SHA-256 multihash, "Qm...")
+// or a CIDv1 in base32 ("b..."), base58btc ("z...") or base16 ("f...") multibase encoding
+func ParseCID(s string) error {
+	if len(s) == 46 && strings.HasPrefix(s, "Qm") {
+		b, err := base58Decode(s)
+		if err != nil {
+			return err
+		}
+		if len(b) != 34 || b[0] != 0x12 || b[1] != 0x20 {
+			return fmt.Errorf("CIDv0 is not a SHA-256 multihash")
+		}
+		return nil
+	}
+	if s == "" {
+		return fmt.Errorf("CID is empty")
+	}
+
+	var b []byte
+	var err error
+	switch s[0] {
+	case 'b':
+		b, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(s[1:]))
+	case 'z':
+		b, err = base58Decode(s[1:])
+	case 'f':
+		b, err = hex.DecodeString(s[1:])
+	default:
+		return fmt.Errorf("unsupported multibase prefix %q", s[0])
+	}
+	if err != nil {
+		return fmt.Errorf("invalid multibase encoding: %v", err)
+	}
+
+	version, rest, err := uvarint(b)
+	if err != nil {
+		return fmt.Errorf("invalid CID version: %v", err)
+	}
+	if version != 1 {
+		return fmt.Errorf("unsupported CID version %d", version)
+	}
+	_, rest, err = uvarint(rest)
+	if err != nil {
+		return fmt.Errorf("invalid CID codec: %v", err)
+	}
+	return checkMultihash(rest)
+}
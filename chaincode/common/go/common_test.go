@@ -0,0 +1,121 @@
+package common
+
+import (
+	"crypto/sha256"
+	"errors"
+	"strings"
+	"testing"
+)
+
+type record struct {
+	SerialNumber string   `json:"serialNumber"`
+	Inspector    string   `json:"inspector" ledger:"private"`
+	Confidence   float64  `json:"confidence"`
+	Notes        []string `json:"notes,omitempty" ledger:"private"`
+	TxID         string   `json:"txId,omitempty"`
+	internal     string
+}
+
+func TestMarshalPublicAndPrivate(t *testing.T) {
+	r := &record{SerialNumber: "SN-1", Inspector: "J. Smith", Confidence: 0.9, Notes: []string{"edge"}, internal: "x"}
+
+	public, err := MarshalPublic(r)
+	if err != nil {
+		t.Fatalf("MarshalPublic failed: %v", err)
+	}
+	if string(public) != `{"serialNumber":"SN-1","confidence":0.9}` {
+		t.Errorf("unexpected public JSON %s", public)
+	}
+
+	private, err := MarshalPrivate(*r)
+	if err != nil {
+		t.Fatalf("MarshalPrivate failed: %v", err)
+	}
+	if string(private) != `{"inspector":"J. Smith","notes":["edge"]}` {
+		t.Errorf("unexpected private JSON %s", private)
+	}
+}
+
+func TestUnmarshalPrivate(t *testing.T) {
+	r := record{SerialNumber: "SN-1", Confidence: 0.9}
+
+	err := UnmarshalPrivate([]byte(`{"inspector":"J. Smith","serialNumber":"other","notes":["edge"]}`), &r)
+	if err != nil {
+		t.Fatalf("UnmarshalPrivate failed: %v", err)
+	}
+	if r.Inspector != "J. Smith" || len(r.Notes) != 1 {
+		t.Errorf("private fields not set: %+v", r)
+	}
+	if r.SerialNumber != "SN-1" || r.Confidence != 0.9 {
+		t.Errorf("public fields changed: %+v", r)
+	}
+
+	if err := UnmarshalPrivate([]byte(`{}`), r); err == nil {
+		t.Errorf("expected an error for a non-pointer")
+	}
+	if err := UnmarshalPrivate([]byte(`{"inspector":1}`), &r); err == nil {
+		t.Errorf("expected an error for a mistyped field")
+	}
+}
+
+func TestMarshalRejectsNonStructs(t *testing.T) {
+	var nilRecord *record
+	for _, v := range []interface{}{"text", nilRecord, map[string]string{}} {
+		if _, err := MarshalPublic(v); err == nil {
+			t.Errorf("expected an error for %T", v)
+		}
+	}
+}
+
+// keyCreator builds composite keys in the shim's format
+type keyCreator struct{}
+
+func (keyCreator) CreateCompositeKey(objectType string, attributes []string) (string, error) {
+	for _, attribute := range attributes {
+		if strings.ContainsRune(attribute, 0) {
+			return "", errors.New("attribute contains U+0000")
+		}
+	}
+	return "\x00" + objectType + "\x00" + strings.Join(append(attributes, ""), "\x00"), nil
+}
+
+func TestKeys(t *testing.T) {
+	if key := SimpleKey("6A7614", "RGA46870"); key != "6A7614_RGA46870" {
+		t.Errorf("unexpected simple key %q", key)
+	}
+
+	key, err := CompositeKey(keyCreator{}, "inspection", "6A7614", "RGA46870")
+	if err != nil || key != "\x00inspection\x006A7614\x00RGA46870\x00" {
+		t.Errorf("unexpected composite key %q (%v)", key, err)
+	}
+	_, err = CompositeKey(keyCreator{}, "inspection", "bad\x00attribute")
+	if err == nil || !strings.HasPrefix(err.Error(), "failed to create inspection key") {
+		t.Errorf("expected a key error, got %v", err)
+	}
+}
+
+// hashes holds private data hashes by collection and key
+type hashes map[string]map[string][]byte
+
+func (h hashes) GetPrivateDataHash(collection, key string) ([]byte, error) {
+	return h[collection][key], nil
+}
+
+func TestVerifyPrivateData(t *testing.T) {
+	original := []byte(`{"inspector":"J. Smith"}`)
+	hash := sha256.Sum256(original)
+	stub := hashes{"mroLabCollection": {"key1": hash[:]}}
+
+	result, err := VerifyPrivateData(stub, "mroLabCollection", "key1", original)
+	if err != nil || !result.Match || result.Collection != "mroLabCollection" {
+		t.Errorf("expected a match, got %+v (%v)", result, err)
+	}
+	result, err = VerifyPrivateData(stub, "mroLabCollection", "key1", []byte(`{"inspector":"A. Jones"}`))
+	if err != nil || result.Match {
+		t.Errorf("expected no match, got %+v (%v)", result, err)
+	}
+	_, err = VerifyPrivateData(stub, "mroLabCollection", "key2", original)
+	if err == nil {
+		t.Errorf("expected an error for a missing key")
+	}
+}
@@ -0,0 +1,8 @@
+// Package common holds the ledger helpers shared by the ThermoTrace inspection chaincodes:
+// splitting records into public and private data with struct tags, keeping the on-ledger
+// registry that routes organizations to their private data collections along with the
+// transactions its admins manage it with, building state keys, validating transaction inputs
+// against JSON Schemas with machine-readable field errors, checking the approval workflow of
+// inspection records and the lifecycle of registered parts, and emitting the chaincode events
+// that off-chain listeners subscribe to.
+package common
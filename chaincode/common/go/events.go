@@ -0,0 +1,35 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Chaincode event names. Fabric delivers only the last event a transaction sets, so a transaction emits
+// one event named after its most significant outcome: an inspection that detected a defect or is out of
+// tolerance was also added or amended, and its payload carries the same fields as InspectionAdded.
+const (
+	EventInspectionAdded   = "InspectionAdded"
+	EventInspectionAmended = "InspectionAmended"
+	EventDefectDetected    = "DefectDetected"
+	EventOutOfTolerance    = "OutOfTolerance"
+)
+
+// EventSetter sets the chaincode event of a transaction (satisfied by shim.ChaincodeStubInterface)
+type EventSetter interface {
+	SetEvent(name string, payload []byte) error
+}
+
+// SetEvent marshals payload to JSON and sets it as the transaction's chaincode event. Event payloads
+// are visible to every client of the channel, so they must hold public fields only.
+func SetEvent(stub EventSetter, name string, payload interface{}) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %v", name, err)
+	}
+	err = stub.SetEvent(name, payloadJSON)
+	if err != nil {
+		return fmt.Errorf("failed to set %s event: %v", name, err)
+	}
+	return nil
+}
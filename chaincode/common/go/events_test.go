@@ -0,0 +1,34 @@
+package common
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type eventRecorder struct {
+	name    string
+	payload []byte
+	err     error
+}
+
+func (r *eventRecorder) SetEvent(name string, payload []byte) error {
+	r.name, r.payload = name, payload
+	return r.err
+}
+
+func TestSetEvent(t *testing.T) {
+	recorder := &eventRecorder{}
+	err := SetEvent(recorder, EventInspectionAdded, struct {
+		SerialNumber string `json:"serialNumber"`
+	}{"SN-1"})
+	if err != nil || recorder.name != EventInspectionAdded || string(recorder.payload) != `{"serialNumber":"SN-1"}` {
+		t.Errorf("unexpected event %s %s (%v)", recorder.name, recorder.payload, err)
+	}
+
+	recorder.err = errors.New("stub failure")
+	err = SetEvent(recorder, EventDefectDetected, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed to set DefectDetected event") {
+		t.Errorf("expected the stub error, got %v", err)
+	}
+}
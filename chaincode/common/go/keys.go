@@ -0,0 +1,25 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CompositeKeyCreator creates composite keys (satisfied by shim.ChaincodeStubInterface)
+type CompositeKeyCreator interface {
+	CreateCompositeKey(objectType string, attributes []string) (string, error)
+}
+
+// SimpleKey joins key parts with underscores, e.g. "6A7614_RGA46870"
+func SimpleKey(parts ...string) string {
+	return strings.Join(parts, "_")
+}
+
+// CompositeKey creates the composite key objectType~attributes...
+func CompositeKey(stub CompositeKeyCreator, objectType string, attributes ...string) (string, error) {
+	key, err := stub.CreateCompositeKey(objectType, attributes)
+	if err != nil {
+		return "", fmt.Errorf("failed to create %s key: %v", objectType, err)
+	}
+	return key, nil
+}
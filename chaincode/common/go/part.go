@@ -0,0 +1,90 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Lifecycle statuses of a registered part. A part enters service when it is registered, may be
+// quarantined pending investigation and released back to service, and is scrapped for good.
+const (
+	PartInService   = "in-service"
+	PartQuarantined = "quarantined"
+	PartScrapped    = "scrapped"
+)
+
+// nextPartStatuses lists the statuses a part may move to from each status
+var nextPartStatuses = map[string][]string{
+	PartInService:   {PartQuarantined, PartScrapped},
+	PartQuarantined: {PartInService, PartScrapped},
+}
+
+// PartDateLayout is the layout of a part's manufacture date
+const PartDateLayout = "2006-01-02"
+
+// Part is a serialized part in the part registry. Inspections may only be recorded for registered
+// parts that have not been scrapped.
+type Part struct {
+	PartNumber      string `json:"partNumber"`
+	SerialNumber    string `json:"serialNumber"`
+	Manufacturer    string `json:"manufacturer"`
+	Material        string `json:"material"`
+	Lot             string `json:"lot,omitempty"`   // material or production lot
+	ManufactureDate string `json:"manufactureDate"` // YYYY-MM-DD
+	Status          string `json:"status"`
+	StatusReason    string `json:"statusReason,omitempty"` // why the part was last quarantined, released or scrapped
+	RegisteredBy    string `json:"registeredBy"`           // MSP ID of the registering organization
+	UpdatedBy       string `json:"updatedBy"`              // client ID of the last change
+	UpdatedAt       string `json:"updatedAt"`              // transaction timestamp of the last change, RFC3339
+	TxID            string `json:"txId"`                   // transaction of the last change
+}
+
+// ValidatePart checks the fields of a part to be registered and returns its field errors. A part is
+// registered in service unless it is registered in quarantine.
+func ValidatePart(part *Part) FieldErrors {
+	var errs FieldErrors
+	for field, value := range map[string]string{
+		"partNumber": part.PartNumber, "serialNumber": part.SerialNumber,
+		"manufacturer": part.Manufacturer, "material": part.Material,
+	} {
+		if strings.TrimSpace(value) == "" {
+			errs.Add(field, CodeRequired, "is required")
+		}
+	}
+	if part.ManufactureDate == "" {
+		errs.Add("manufactureDate", CodeRequired, "is required")
+	} else if _, err := time.Parse(PartDateLayout, part.ManufactureDate); err != nil {
+		errs.Add("manufactureDate", CodeInvalidTimestamp, "must be a date in YYYY-MM-DD format")
+	}
+	if part.Status != "" && part.Status != PartInService && part.Status != PartQuarantined {
+		errs.Add("status", CodeInvalidValue, fmt.Sprintf("new parts must be %s or %s", PartInService, PartQuarantined))
+	}
+	return errs
+}
+
+// CheckPartTransition returns an error unless a part may move from status from to status to.
+// Scrapped parts are final.
+func CheckPartTransition(from, to string) error {
+	if from == PartScrapped {
+		return fmt.Errorf("part is scrapped and can no longer change status")
+	}
+	for _, next := range nextPartStatuses[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot change part status from %s to %q", from, to)
+}
+
+// CheckInspectable returns an error unless inspections may be recorded for a part: it must be
+// registered and not scrapped. Quarantined parts are inspected to decide their fate.
+func CheckInspectable(part *Part, partNumber, serialNumber string) error {
+	if part == nil {
+		return fmt.Errorf("part %s %s is not registered", partNumber, serialNumber)
+	}
+	if part.Status == PartScrapped {
+		return fmt.Errorf("part %s %s is scrapped", partNumber, serialNumber)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidatePart(t *testing.T) {
+	part := &Part{PartNumber: "6A7614", SerialNumber: "RGA46870", Manufacturer: "ManufacturerMSP", Material: "CMSX-4",
+		ManufactureDate: "2019-03-14"}
+	if err := ValidatePart(part).Err(); err != nil {
+		t.Errorf("expected a valid part, got %v", err)
+	}
+
+	errs := ValidatePart(&Part{PartNumber: "6A7614", ManufactureDate: "14/03/2019", Status: PartScrapped})
+	codes := map[string]string{}
+	for _, fieldError := range errs {
+		codes[fieldError.Field] = fieldError.Code
+	}
+	expected := map[string]string{"serialNumber": CodeRequired, "manufacturer": CodeRequired, "material": CodeRequired,
+		"manufactureDate": CodeInvalidTimestamp, "status": CodeInvalidValue}
+	if len(codes) != len(expected) {
+		t.Errorf("expected %v, got %v", expected, codes)
+	}
+	for field, code := range expected {
+		if codes[field] != code {
+			t.Errorf("%s: expected %s, got %q", field, code, codes[field])
+		}
+	}
+}
+
+func TestCheckPartTransition(t *testing.T) {
+	allowed := [][2]string{
+		{PartInService, PartQuarantined},
+		{PartInService, PartScrapped},
+		{PartQuarantined, PartInService},
+		{PartQuarantined, PartScrapped},
+	}
+	for _, transition := range allowed {
+		if err := CheckPartTransition(transition[0], transition[1]); err != nil {
+			t.Errorf("%s -> %s: %v", transition[0], transition[1], err)
+		}
+	}
+
+	if err := CheckPartTransition(PartInService, PartInService); err == nil {
+		t.Error("expected a part to stay in its status")
+	}
+	if err := CheckPartTransition(PartScrapped, PartInService); err == nil || !strings.Contains(err.Error(), "scrapped") {
+		t.Errorf("expected scrapped parts to be final, got %v", err)
+	}
+}
+
+func TestCheckInspectable(t *testing.T) {
+	if err := CheckInspectable(&Part{Status: PartQuarantined}, "6A7614", "RGA46870"); err != nil {
+		t.Errorf("expected a quarantined part to be inspectable, got %v", err)
+	}
+	if err := CheckInspectable(nil, "6A7614", "RGA46870"); err == nil || !strings.Contains(err.Error(), "6A7614 RGA46870 is not registered") {
+		t.Errorf("expected an unregistered part to be rejected, got %v", err)
+	}
+	if err := CheckInspectable(&Part{Status: PartScrapped}, "6A7614", "RGA46870"); err == nil || !strings.Contains(err.Error(), "is scrapped") {
+		t.Errorf("expected a scrapped part to be rejected, got %v", err)
+	}
+}
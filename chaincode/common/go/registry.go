@@ -0,0 +1,415 @@
+package common
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// Organization roles
+const (
+	RoleManufacturer = "manufacturer"
+	RoleMRO          = "mro"
+	RoleOperator     = "operator"
+	RoleRegulator    = "regulator"
+)
+
+var roles = map[string]bool{RoleManufacturer: true, RoleMRO: true, RoleOperator: true, RoleRegulator: true}
+
+// orgRegistryObjectType is the composite key holding the organization registry. A composite key
+// keeps the registry out of range queries over simple keys.
+const orgRegistryObjectType = "orgRegistry"
+
+// Organization is an organization of the channel in the registry
+type Organization struct {
+	MSPID             string `json:"mspId"`
+	Role              string `json:"role"`
+	PrivateCollection string `json:"privateCollection"`
+	Active            bool   `json:"active"`
+	UpdatedBy         string `json:"updatedBy,omitempty"`
+	UpdatedAt         string `json:"updatedAt,omitempty"` // ISO 8601 format
+	TxID              string `json:"txId,omitempty"`
+}
+
+// StateStore reads and writes world state (satisfied by shim.ChaincodeStubInterface)
+type StateStore interface {
+	CompositeKeyCreator
+	GetState(key string) ([]byte, error)
+	PutState(key string, value []byte) error
+}
+
+// Registry change actions
+const (
+	OrgActionRegister   = "register"
+	OrgActionDeactivate = "deactivate"
+	OrgActionLink       = "link"
+)
+
+// Purposes of the chaincodes an inspection chaincode calls on the same channel
+const (
+	LinkPartRegistry = "partRegistry" // keeps the part registry
+	LinkAIDefect     = "aiDefect"     // keeps the AI defect inspections
+)
+
+var linkPurposes = map[string]bool{LinkPartRegistry: true, LinkAIDefect: true}
+
+// ChaincodeLink names the chaincode that is called for a purpose
+type ChaincodeLink struct {
+	Purpose   string `json:"purpose"`
+	Chaincode string `json:"chaincode"`
+}
+
+// OrgChange is a change to an organization or a chaincode link in the registry and the organizations
+// that approved it
+type OrgChange struct {
+	Action       string         `json:"action"`       // OrgActionRegister, OrgActionDeactivate or OrgActionLink
+	Organization Organization   `json:"organization"` // for OrgActionRegister and OrgActionDeactivate
+	Link         *ChaincodeLink `json:"link,omitempty"`
+	Approvals    []string       `json:"approvals"` // MSP IDs of the organizations whose admins approved the change
+	Required     int            `json:"required"`  // approvals needed to apply the change
+	Applied      bool           `json:"applied"`
+}
+
+// OrgRegistry maps the MSP IDs of the channel's organizations to their role and private data
+// collection, and names the other chaincodes called on the channel. It is stored on the ledger and
+// managed by organization admins, so organizations can be added and chaincodes renamed without
+// redeploying the chaincode. Changes awaiting approval are kept with it.
+type OrgRegistry struct {
+	Organizations map[string]*Organization `json:"organizations"`
+	Links         map[string]string        `json:"links,omitempty"`   // chaincode names by purpose
+	Pending       map[string]*OrgChange    `json:"pending,omitempty"` // by changeKey
+
+	// SharedCollections are the chaincode's collections that several organizations read, such as its
+	// public collection. They come with the chaincode rather than the ledger and are never assigned to
+	// an organization as its private collection.
+	SharedCollections []string `json:"-"`
+}
+
+// NewOrgRegistry returns a registry of active organizations
+func NewOrgRegistry(orgs ...Organization) *OrgRegistry {
+	r := &OrgRegistry{Organizations: map[string]*Organization{}, Pending: map[string]*OrgChange{}}
+	for _, org := range orgs {
+		org := org
+		org.Active = true
+		r.Organizations[org.MSPID] = &org
+	}
+	return r
+}
+
+// WithSharedCollections sets the collections that cannot be assigned to an organization and returns
+// the registry
+func (r *OrgRegistry) WithSharedCollections(collections ...string) *OrgRegistry {
+	r.SharedCollections = collections
+	return r
+}
+
+// LoadOrgRegistry reads the registry from the ledger. Until an admin first changes it, the
+// registry holds the defaults the chaincode was deployed with.
+func LoadOrgRegistry(stub StateStore, defaults *OrgRegistry) (*OrgRegistry, error) {
+	key, err := CompositeKey(stub, orgRegistryObjectType)
+	if err != nil {
+		return nil, err
+	}
+	registryJSON, err := stub.GetState(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read organization registry: %v", err)
+	}
+	if registryJSON == nil {
+		// Copy the defaults so that changes are not shared between transactions
+		registryJSON, err = json.Marshal(defaults)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal organization registry: %v", err)
+		}
+	}
+
+	var registry OrgRegistry
+	err = json.Unmarshal(registryJSON, &registry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal organization registry: %v", err)
+	}
+	if registry.Organizations == nil {
+		registry.Organizations = map[string]*Organization{}
+	}
+	if registry.Pending == nil {
+		registry.Pending = map[string]*OrgChange{}
+	}
+	registry.SharedCollections = defaults.SharedCollections
+	return &registry, nil
+}
+
+// Save writes the registry to the ledger
+func (r *OrgRegistry) Save(stub StateStore) error {
+	key, err := CompositeKey(stub, orgRegistryObjectType)
+	if err != nil {
+		return err
+	}
+	registryJSON, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("failed to marshal organization registry: %v", err)
+	}
+	err = stub.PutState(key, registryJSON)
+	if err != nil {
+		return fmt.Errorf("failed to write organization registry: %v", err)
+	}
+	return nil
+}
+
+// Lookup returns an active organization
+func (r *OrgRegistry) Lookup(mspID string) (*Organization, error) {
+	org, ok := r.Organizations[mspID]
+	if !ok {
+		return nil, fmt.Errorf("organization %s is not registered", mspID)
+	}
+	if !org.Active {
+		return nil, fmt.Errorf("organization %s is deactivated", mspID)
+	}
+	return org, nil
+}
+
+// PrivateCollection returns the private data collection of an active organization
+func (r *OrgRegistry) PrivateCollection(mspID string) (string, error) {
+	org, err := r.Lookup(mspID)
+	if err != nil {
+		return "", err
+	}
+	return org.PrivateCollection, nil
+}
+
+// List returns every organization, active or not, ordered by MSP ID
+func (r *OrgRegistry) List() []*Organization {
+	orgs := make([]*Organization, 0, len(r.Organizations))
+	for _, org := range r.Organizations {
+		orgs = append(orgs, org)
+	}
+	sort.Slice(orgs, func(i, j int) bool { return orgs[i].MSPID < orgs[j].MSPID })
+	return orgs
+}
+
+// checkRegister returns an error if an organization cannot be registered. A private data
+// collection belongs to a single organization, so neither another organization's collection nor
+// a shared collection can be assigned.
+func (r *OrgRegistry) checkRegister(org Organization) error {
+	if org.MSPID == "" {
+		return fmt.Errorf("mspId is required")
+	}
+	if !roles[org.Role] {
+		return fmt.Errorf("invalid role %q: must be one of %s, %s, %s or %s",
+			org.Role, RoleManufacturer, RoleMRO, RoleOperator, RoleRegulator)
+	}
+	if org.PrivateCollection == "" {
+		return fmt.Errorf("privateCollection is required")
+	}
+	for _, shared := range r.SharedCollections {
+		if org.PrivateCollection == shared {
+			return fmt.Errorf("collection %s is shared by the organizations and cannot be a private collection", shared)
+		}
+	}
+	for _, other := range r.Organizations {
+		if other.MSPID != org.MSPID && other.PrivateCollection == org.PrivateCollection {
+			return fmt.Errorf("collection %s is already assigned to %s", org.PrivateCollection, other.MSPID)
+		}
+	}
+	return nil
+}
+
+// Register adds an organization, or updates and reactivates a registered one. A private data
+// collection belongs to a single organization.
+func (r *OrgRegistry) Register(org Organization) (*Organization, error) {
+	if err := r.checkRegister(org); err != nil {
+		return nil, err
+	}
+
+	org.Active = true
+	r.Organizations[org.MSPID] = &org
+	return &org, nil
+}
+
+// Deactivate marks an organization as deactivated. Its data stays on the ledger but its members
+// can no longer use the chaincode. The last active organization cannot be deactivated, since
+// nobody could manage the registry afterwards.
+func (r *OrgRegistry) Deactivate(mspID string) (*Organization, error) {
+	org, err := r.Lookup(mspID)
+	if err != nil {
+		return nil, err
+	}
+	if r.activeCount() == 1 {
+		return nil, fmt.Errorf("cannot deactivate %s, the last active organization", mspID)
+	}
+
+	org.Active = false
+	return org, nil
+}
+
+// activeCount returns the number of active organizations
+func (r *OrgRegistry) activeCount() int {
+	active := 0
+	for _, org := range r.Organizations {
+		if org.Active {
+			active++
+		}
+	}
+	return active
+}
+
+// LinkedChaincode returns the name of the chaincode called for a purpose, or defaultName until the
+// organizations link another one
+func (r *OrgRegistry) LinkedChaincode(purpose, defaultName string) string {
+	if name := r.Links[purpose]; name != "" {
+		return name
+	}
+	return defaultName
+}
+
+// changeKey identifies the organization or link a change applies to, so that one change per
+// organization or link is pending at a time
+func changeKey(change OrgChange) string {
+	if change.Action == OrgActionLink {
+		return "link:" + change.Link.Purpose
+	}
+	return change.Organization.MSPID
+}
+
+// sameChange reports whether two changes have the same arguments
+func sameChange(a, b OrgChange) bool {
+	if a.Action != b.Action {
+		return false
+	}
+	if a.Action == OrgActionLink {
+		return *a.Link == *b.Link
+	}
+	return a.Organization.MSPID == b.Organization.MSPID && a.Organization.Role == b.Organization.Role &&
+		a.Organization.PrivateCollection == b.Organization.PrivateCollection
+}
+
+// requiredApprovals returns the number of organizations that must approve a change proposed by an
+// admin of mspID. An organization may deactivate itself alone; any other change, including to its
+// own role or collection, needs a majority of the active organizations, so that no single organization
+// can take over another's private data or access, redirect where its private data is written, or
+// route calls to a chaincode of its choosing.
+func (r *OrgRegistry) requiredApprovals(mspID string, change OrgChange) int {
+	if change.Action == OrgActionDeactivate && change.Organization.MSPID == mspID {
+		return 1
+	}
+	return r.activeCount()/2 + 1
+}
+
+// ApproveChange records the approval of a change by an admin of mspID and applies it once enough
+// active organizations approved it (see requiredApprovals); until then it is pending. A different
+// change to the same organization or link replaces the pending one along with its approvals.
+func (r *OrgRegistry) ApproveChange(mspID string, cert *x509.Certificate, change OrgChange) (*OrgChange, error) {
+	err := r.CheckAdmin(mspID, cert)
+	if err != nil {
+		return nil, err
+	}
+	switch change.Action {
+	case OrgActionRegister:
+		err = r.checkRegister(change.Organization)
+		change = OrgChange{Action: change.Action, Organization: Organization{MSPID: change.Organization.MSPID,
+			Role: change.Organization.Role, PrivateCollection: change.Organization.PrivateCollection}}
+	case OrgActionDeactivate:
+		_, err = r.Lookup(change.Organization.MSPID)
+		change = OrgChange{Action: change.Action, Organization: Organization{MSPID: change.Organization.MSPID}}
+	case OrgActionLink:
+		if change.Link == nil || !linkPurposes[change.Link.Purpose] {
+			err = fmt.Errorf("invalid link: purpose must be %s or %s", LinkPartRegistry, LinkAIDefect)
+		} else if change.Link.Chaincode == "" {
+			err = fmt.Errorf("chaincode is required")
+		}
+		if err == nil {
+			change = OrgChange{Action: change.Action, Link: &ChaincodeLink{Purpose: change.Link.Purpose, Chaincode: change.Link.Chaincode}}
+		}
+	default:
+		err = fmt.Errorf("invalid action %q: must be %s, %s or %s", change.Action, OrgActionRegister, OrgActionDeactivate, OrgActionLink)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := changeKey(change)
+	if pending, ok := r.Pending[key]; ok && sameChange(*pending, change) {
+		change.Approvals = pending.Approvals
+	}
+	approved := false
+	for _, approval := range change.Approvals {
+		approved = approved || approval == mspID
+	}
+	if !approved {
+		change.Approvals = append(change.Approvals, mspID)
+	}
+	sort.Strings(change.Approvals)
+
+	// Approvals of organizations deactivated since no longer count
+	change.Required = r.requiredApprovals(mspID, change)
+	approvals := 0
+	for _, approval := range change.Approvals {
+		if org, ok := r.Organizations[approval]; ok && org.Active {
+			approvals++
+		}
+	}
+	if approvals < change.Required {
+		r.Pending[key] = &change
+		return &change, nil
+	}
+
+	switch change.Action {
+	case OrgActionRegister:
+		var org *Organization
+		org, err = r.Register(change.Organization)
+		if err == nil {
+			change.Organization = *org
+		}
+	case OrgActionDeactivate:
+		var org *Organization
+		org, err = r.Deactivate(change.Organization.MSPID)
+		if err == nil {
+			change.Organization = *org
+		}
+	case OrgActionLink:
+		if r.Links == nil {
+			r.Links = map[string]string{}
+		}
+		r.Links[change.Link.Purpose] = change.Link.Chaincode
+	}
+	if err != nil {
+		return nil, err
+	}
+	delete(r.Pending, key)
+	change.Applied = true
+	return &change, nil
+}
+
+// PendingChanges returns the changes awaiting approval, ordered by the MSP ID of the changed
+// organization, then by the purpose of the changed link
+func (r *OrgRegistry) PendingChanges() []*OrgChange {
+	changes := make([]*OrgChange, 0, len(r.Pending))
+	for _, change := range r.Pending {
+		changes = append(changes, change)
+	}
+	sort.Slice(changes, func(i, j int) bool { return changeKey(*changes[i]) < changeKey(*changes[j]) })
+	return changes
+}
+
+// CheckAdmin returns an error unless the caller is an admin (NodeOU "admin") of an active organization
+func (r *OrgRegistry) CheckAdmin(mspID string, cert *x509.Certificate) error {
+	if _, err := r.Lookup(mspID); err != nil {
+		return fmt.Errorf("only admins of active organizations can manage the registry: %v", err)
+	}
+	if !IsAdmin(cert) {
+		return fmt.Errorf("only admins of active organizations can manage the registry")
+	}
+	return nil
+}
+
+// IsAdmin reports whether a certificate carries the admin NodeOU
+func IsAdmin(cert *x509.Certificate) bool {
+	if cert == nil {
+		return false
+	}
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if ou == "admin" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package common
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// RegistryDefaults supplies the registry an inspection chaincode was deployed with. Its zero value is
+// used, so implementations return package-level defaults.
+type RegistryDefaults interface {
+	// Organizations returns the registry that applies until an admin first changes it
+	Organizations() *OrgRegistry
+	// Links returns the chaincode names by purpose that apply until the organizations link others
+	Links() map[string]string
+}
+
+// RegistryContract holds the organization registry transactions shared by the inspection chaincodes.
+// A chaincode embeds it in its smart contract with its own defaults D, so every chaincode manages its
+// registry the same way.
+type RegistryContract[D RegistryDefaults] struct{}
+
+// LoadRegistry reads the organization registry of a chaincode deployed with defaults D
+func LoadRegistry[D RegistryDefaults](ctx contractapi.TransactionContextInterface) (*OrgRegistry, error) {
+	var defaults D
+	return LoadOrgRegistry(ctx.GetStub(), defaults.Organizations())
+}
+
+// LinkedChaincodeName returns the name of the chaincode called on the channel for a purpose by a
+// chaincode deployed with defaults D
+func LinkedChaincodeName[D RegistryDefaults](ctx contractapi.TransactionContextInterface, purpose string) (string, error) {
+	registry, err := LoadRegistry[D](ctx)
+	if err != nil {
+		return "", err
+	}
+	var defaults D
+	return registry.LinkedChaincode(purpose, defaults.Links()[purpose]), nil
+}
+
+// updateOrgRegistry records the approval of a change to the registry by an admin of an active
+// organization, stamps the organization if the change is applied and saves the registry
+func updateOrgRegistry[D RegistryDefaults](ctx contractapi.TransactionContextInterface, change OrgChange) (*OrgChange, error) {
+	registry, err := LoadRegistry[D](ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get client certificate: %v", err)
+	}
+	approved, err := registry.ApproveChange(clientMSPID, cert, change)
+	if err != nil {
+		return nil, err
+	}
+	if approved.Applied && approved.Action != OrgActionLink {
+		err = stampOrganization(ctx, registry.Organizations[approved.Organization.MSPID])
+		if err != nil {
+			return nil, err
+		}
+		approved.Organization = *registry.Organizations[approved.Organization.MSPID]
+	}
+
+	err = registry.Save(ctx.GetStub())
+	if err != nil {
+		return nil, err
+	}
+	return approved, nil
+}
+
+// stampOrganization records the caller and the current transaction on a changed organization
+func stampOrganization(ctx contractapi.TransactionContextInterface, org *Organization) error {
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return fmt.Errorf("failed to get client ID: %v", err)
+	}
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+	org.UpdatedBy = clientID
+	org.UpdatedAt = time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).UTC().Format(time.RFC3339)
+	org.TxID = ctx.GetStub().GetTxID()
+	return nil
+}
+
+// RegisterOrganization approves adding an organization to the registry, or updating and reactivating a
+// registered one. The private collection must be defined in the chaincode's collections config and
+// read by that organization only. Only admins of active organizations may approve changes, and a change
+// is pending until admins of a majority of the active organizations approved it with the same arguments,
+// even for the approving organization's own collection. The returned change tells whether it was applied.
+func (c *RegistryContract[D]) RegisterOrganization(ctx contractapi.TransactionContextInterface,
+	mspID string, role string, privateCollection string) (*OrgChange, error) {
+
+	return updateOrgRegistry[D](ctx, OrgChange{Action: OrgActionRegister,
+		Organization: Organization{MSPID: mspID, Role: role, PrivateCollection: privateCollection}})
+}
+
+// DeactivateOrganization approves stopping an organization's members from using the chaincode. Their
+// records remain on the ledger. An admin may deactivate their own organization alone; other
+// organizations are deactivated once admins of a majority of the active organizations approved it.
+func (c *RegistryContract[D]) DeactivateOrganization(ctx contractapi.TransactionContextInterface,
+	mspID string) (*OrgChange, error) {
+
+	return updateOrgRegistry[D](ctx, OrgChange{Action: OrgActionDeactivate, Organization: Organization{MSPID: mspID}})
+}
+
+// GetOrganizations returns every organization in the registry, ordered by MSP ID
+func (c *RegistryContract[D]) GetOrganizations(ctx contractapi.TransactionContextInterface) ([]*Organization, error) {
+	registry, err := LoadRegistry[D](ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.List(), nil
+}
+
+// LinkChaincode approves calling chaincodeName on the channel for a purpose: "partRegistry" for the part
+// registry the AI defect inspection chaincode checks parts against, or "aiDefect" for the AI inspections
+// GetPartRecord reads. Links take effect once admins of a majority of the active organizations approved
+// them with the same arguments, so that no organization alone can route calls to a chaincode of its choosing.
+func (c *RegistryContract[D]) LinkChaincode(ctx contractapi.TransactionContextInterface,
+	purpose string, chaincodeName string) (*OrgChange, error) {
+
+	return updateOrgRegistry[D](ctx, OrgChange{Action: OrgActionLink,
+		Link: &ChaincodeLink{Purpose: purpose, Chaincode: chaincodeName}})
+}
+
+// GetLinkedChaincode returns the name of the chaincode called for a purpose
+func (c *RegistryContract[D]) GetLinkedChaincode(ctx contractapi.TransactionContextInterface, purpose string) (string, error) {
+	return LinkedChaincodeName[D](ctx, purpose)
+}
+
+// GetPendingOrganizationChanges returns the registry changes awaiting approval, ordered by MSP ID
+func (c *RegistryContract[D]) GetPendingOrganizationChanges(ctx contractapi.TransactionContextInterface) ([]*OrgChange, error) {
+	registry, err := LoadRegistry[D](ctx)
+	if err != nil {
+		return nil, err
+	}
+	return registry.PendingChanges(), nil
+}
@@ -0,0 +1,245 @@
+package common
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"strings"
+	"testing"
+)
+
+// memoryState is a world state held in memory
+type memoryState struct {
+	keyCreator
+	state map[string][]byte
+}
+
+func (m *memoryState) GetState(key string) ([]byte, error) {
+	return m.state[key], nil
+}
+
+func (m *memoryState) PutState(key string, value []byte) error {
+	m.state[key] = value
+	return nil
+}
+
+var defaultOrgs = NewOrgRegistry(
+	Organization{MSPID: "ManufacturerMSP", Role: RoleManufacturer, PrivateCollection: "manufacturerCollection"},
+	Organization{MSPID: "MROLabMSP", Role: RoleMRO, PrivateCollection: "mroLabCollection"},
+)
+
+func TestOrgRegistryDefaults(t *testing.T) {
+	stub := &memoryState{state: map[string][]byte{}}
+	registry, err := LoadOrgRegistry(stub, defaultOrgs)
+	if err != nil {
+		t.Fatalf("LoadOrgRegistry failed: %v", err)
+	}
+
+	collection, err := registry.PrivateCollection("MROLabMSP")
+	if err != nil || collection != "mroLabCollection" {
+		t.Errorf("expected mroLabCollection, got %q (%v)", collection, err)
+	}
+	_, err = registry.PrivateCollection("RegulatorMSP")
+	if err == nil || err.Error() != "organization RegulatorMSP is not registered" {
+		t.Errorf("expected an unregistered organization error, got %v", err)
+	}
+
+	// Changes to a loaded registry do not leak into the defaults
+	registry.Organizations["MROLabMSP"].Active = false
+	if !defaultOrgs.Organizations["MROLabMSP"].Active {
+		t.Errorf("defaults were modified")
+	}
+}
+
+func TestOrgRegistryRegisterAndDeactivate(t *testing.T) {
+	stub := &memoryState{state: map[string][]byte{}}
+	registry, _ := LoadOrgRegistry(stub, defaultOrgs)
+
+	_, err := registry.Register(Organization{MSPID: "RegulatorMSP", Role: RoleRegulator, PrivateCollection: "regulatorCollection"})
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	_, err = registry.Register(Organization{MSPID: "AirlineMSP", Role: RoleOperator, PrivateCollection: "mroLabCollection"})
+	if err == nil || !strings.Contains(err.Error(), "already assigned to MROLabMSP") {
+		t.Errorf("expected a collection conflict, got %v", err)
+	}
+	_, err = registry.Register(Organization{MSPID: "AirlineMSP", Role: "airline", PrivateCollection: "airlineCollection"})
+	if err == nil || !strings.HasPrefix(err.Error(), `invalid role "airline"`) {
+		t.Errorf("expected an invalid role error, got %v", err)
+	}
+	if _, err := registry.Deactivate("MROLabMSP"); err != nil {
+		t.Fatalf("Deactivate failed: %v", err)
+	}
+	if err := registry.Save(stub); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	registry, err = LoadOrgRegistry(stub, defaultOrgs)
+	if err != nil {
+		t.Fatalf("LoadOrgRegistry failed: %v", err)
+	}
+	if collection, _ := registry.PrivateCollection("RegulatorMSP"); collection != "regulatorCollection" {
+		t.Errorf("expected regulatorCollection, got %q", collection)
+	}
+	_, err = registry.PrivateCollection("MROLabMSP")
+	if err == nil || err.Error() != "organization MROLabMSP is deactivated" {
+		t.Errorf("expected a deactivated organization error, got %v", err)
+	}
+
+	orgs := registry.List()
+	if len(orgs) != 3 || orgs[0].MSPID != "MROLabMSP" || orgs[0].Active || orgs[1].MSPID != "ManufacturerMSP" {
+		t.Errorf("unexpected organizations %+v", orgs)
+	}
+
+	// Registering a deactivated organization again reactivates it
+	if _, err := registry.Register(*orgs[0]); err != nil || !registry.Organizations["MROLabMSP"].Active {
+		t.Errorf("expected MROLabMSP to be reactivated (%v)", err)
+	}
+}
+
+func TestOrgRegistryKeepsOneActiveOrganization(t *testing.T) {
+	registry := NewOrgRegistry(Organization{MSPID: "ManufacturerMSP", Role: RoleManufacturer, PrivateCollection: "c"})
+	_, err := registry.Deactivate("ManufacturerMSP")
+	if err == nil || !strings.Contains(err.Error(), "last active organization") {
+		t.Errorf("expected the last active organization to be kept, got %v", err)
+	}
+}
+
+func TestCheckAdmin(t *testing.T) {
+	admin := &x509.Certificate{Subject: pkix.Name{OrganizationalUnit: []string{"admin"}}}
+	client := &x509.Certificate{Subject: pkix.Name{OrganizationalUnit: []string{"client"}}}
+
+	if err := defaultOrgs.CheckAdmin("ManufacturerMSP", admin); err != nil {
+		t.Errorf("expected an admin, got %v", err)
+	}
+	if err := defaultOrgs.CheckAdmin("ManufacturerMSP", client); err == nil {
+		t.Errorf("expected a client to be rejected")
+	}
+	if err := defaultOrgs.CheckAdmin("ManufacturerMSP", nil); err == nil {
+		t.Errorf("expected a missing certificate to be rejected")
+	}
+	if err := defaultOrgs.CheckAdmin("RegulatorMSP", admin); err == nil {
+		t.Errorf("expected an admin of an unregistered organization to be rejected")
+	}
+}
+
+func TestOrgRegistryApproveChange(t *testing.T) {
+	admin := &x509.Certificate{Subject: pkix.Name{OrganizationalUnit: []string{"admin"}}}
+	registry, _ := LoadOrgRegistry(&memoryState{state: map[string][]byte{}}, defaultOrgs)
+
+	// An organization cannot change its own collection alone, since that redirects its private data
+	change, err := registry.ApproveChange("MROLabMSP", admin, OrgChange{Action: OrgActionRegister,
+		Organization: Organization{MSPID: "MROLabMSP", Role: RoleMRO, PrivateCollection: "mroLabCollection2"}})
+	if err != nil || change.Applied || change.Required != 2 || registry.Organizations["MROLabMSP"].PrivateCollection != "mroLabCollection" {
+		t.Fatalf("expected the change to be pending, got %+v (%v)", change, err)
+	}
+
+	// nor another organization's, nor its own role
+	hijack := OrgChange{Action: OrgActionRegister,
+		Organization: Organization{MSPID: "ManufacturerMSP", Role: RoleManufacturer, PrivateCollection: "mroLabCollection3"}}
+	change, err = registry.ApproveChange("MROLabMSP", admin, hijack)
+	if err != nil || change.Applied || change.Required != 2 || len(registry.Pending) != 2 {
+		t.Fatalf("expected the change to be pending, got %+v (%v)", change, err)
+	}
+	if collection, _ := registry.PrivateCollection("ManufacturerMSP"); collection != "manufacturerCollection" {
+		t.Errorf("expected a pending change to leave the registry unchanged, got %s", collection)
+	}
+	change, _ = registry.ApproveChange("MROLabMSP", admin, OrgChange{Action: OrgActionRegister,
+		Organization: Organization{MSPID: "MROLabMSP", Role: RoleManufacturer, PrivateCollection: "mroLabCollection2"}})
+	if change.Applied || registry.Organizations["MROLabMSP"].Role != RoleMRO {
+		t.Errorf("expected a role change to be pending, got %+v", change)
+	}
+	change, _ = registry.ApproveChange("MROLabMSP", admin, OrgChange{Action: OrgActionDeactivate,
+		Organization: Organization{MSPID: "ManufacturerMSP"}})
+	if change.Applied || !registry.Organizations["ManufacturerMSP"].Active {
+		t.Errorf("expected a deactivation of another organization to be pending, got %+v", change)
+	}
+
+	// A change applies once a majority of the active organizations approved it
+	newOrg := OrgChange{Action: OrgActionRegister,
+		Organization: Organization{MSPID: "RegulatorMSP", Role: RoleRegulator, PrivateCollection: "regulatorCollection"}}
+	if change, _ = registry.ApproveChange("ManufacturerMSP", admin, newOrg); change.Applied {
+		t.Errorf("expected one approval of two to be pending")
+	}
+	if change, _ = registry.ApproveChange("ManufacturerMSP", admin, newOrg); change.Applied || len(change.Approvals) != 1 {
+		t.Errorf("expected a repeated approval to count once, got %+v", change)
+	}
+	change, err = registry.ApproveChange("MROLabMSP", admin, newOrg)
+	if err != nil || !change.Applied || change.Organization.PrivateCollection != "regulatorCollection" ||
+		strings.Join(change.Approvals, ",") != "MROLabMSP,ManufacturerMSP" {
+		t.Fatalf("expected the change to be applied, got %+v (%v)", change, err)
+	}
+	if _, ok := registry.Pending["RegulatorMSP"]; ok {
+		t.Errorf("expected the applied change to leave the pending changes")
+	}
+
+	// A different change to the same organization replaces the pending one
+	registry.ApproveChange("RegulatorMSP", admin, hijack)
+	registry.ApproveChange("ManufacturerMSP", admin, OrgChange{Action: OrgActionDeactivate, Organization: Organization{MSPID: "ManufacturerMSP"}})
+	if _, ok := registry.Pending["ManufacturerMSP"]; ok || registry.Organizations["ManufacturerMSP"].Active {
+		t.Errorf("expected ManufacturerMSP to deactivate itself, got %+v", registry.Organizations["ManufacturerMSP"])
+	}
+
+	if _, err = registry.ApproveChange("AirlineMSP", admin, newOrg); err == nil {
+		t.Errorf("expected an admin of an unregistered organization to be rejected")
+	}
+	if _, err = registry.ApproveChange("MROLabMSP", admin, OrgChange{Action: "rename", Organization: Organization{MSPID: "MROLabMSP"}}); err == nil {
+		t.Errorf("expected an invalid action to be rejected")
+	}
+}
+
+func TestOrgRegistryRejectsSharedCollections(t *testing.T) {
+	admin := &x509.Certificate{Subject: pkix.Name{OrganizationalUnit: []string{"admin"}}}
+	defaults := NewOrgRegistry(*defaultOrgs.Organizations["ManufacturerMSP"], *defaultOrgs.Organizations["MROLabMSP"]).
+		WithSharedCollections("publicCollection")
+	registry, _ := LoadOrgRegistry(&memoryState{state: map[string][]byte{}}, defaults)
+
+	// Private data written to a shared collection would be readable by every organization
+	_, err := registry.ApproveChange("MROLabMSP", admin, OrgChange{Action: OrgActionRegister,
+		Organization: Organization{MSPID: "MROLabMSP", Role: RoleMRO, PrivateCollection: "publicCollection"}})
+	if err == nil || !strings.Contains(err.Error(), "collection publicCollection is shared") {
+		t.Errorf("expected a shared collection to be rejected, got %v", err)
+	}
+	_, err = registry.Register(Organization{MSPID: "RegulatorMSP", Role: RoleRegulator, PrivateCollection: "publicCollection"})
+	if err == nil {
+		t.Errorf("expected a shared collection to be rejected")
+	}
+
+	// A new collection for an organization applies once a majority approved it
+	move := OrgChange{Action: OrgActionRegister,
+		Organization: Organization{MSPID: "MROLabMSP", Role: RoleMRO, PrivateCollection: "mroLabCollection2"}}
+	registry.ApproveChange("MROLabMSP", admin, move)
+	change, err := registry.ApproveChange("ManufacturerMSP", admin, move)
+	if err != nil || !change.Applied || registry.Organizations["MROLabMSP"].PrivateCollection != "mroLabCollection2" {
+		t.Errorf("expected the collection change to be applied, got %+v (%v)", change, err)
+	}
+}
+
+func TestOrgRegistryLinkedChaincode(t *testing.T) {
+	admin := &x509.Certificate{Subject: pkix.Name{OrganizationalUnit: []string{"admin"}}}
+	registry, _ := LoadOrgRegistry(&memoryState{state: map[string][]byte{}}, defaultOrgs)
+	if name := registry.LinkedChaincode(LinkPartRegistry, "bladeinspection"); name != "bladeinspection" {
+		t.Errorf("expected the default chaincode, got %s", name)
+	}
+
+	link := OrgChange{Action: OrgActionLink, Link: &ChaincodeLink{Purpose: LinkPartRegistry, Chaincode: "bladeinspection-v2"}}
+	change, err := registry.ApproveChange("ManufacturerMSP", admin, link)
+	if err != nil || change.Applied || registry.LinkedChaincode(LinkPartRegistry, "bladeinspection") != "bladeinspection" {
+		t.Fatalf("expected the link to be pending, got %+v (%v)", change, err)
+	}
+	if pending := registry.PendingChanges(); len(pending) != 1 || pending[0].Link.Chaincode != "bladeinspection-v2" {
+		t.Errorf("unexpected pending changes %+v", pending)
+	}
+	change, err = registry.ApproveChange("MROLabMSP", admin, link)
+	if err != nil || !change.Applied || registry.LinkedChaincode(LinkPartRegistry, "bladeinspection") != "bladeinspection-v2" {
+		t.Fatalf("expected the link to be applied, got %+v (%v)", change, err)
+	}
+
+	_, err = registry.ApproveChange("MROLabMSP", admin, OrgChange{Action: OrgActionLink, Link: &ChaincodeLink{Purpose: "ledger", Chaincode: "x"}})
+	if err == nil || !strings.HasPrefix(err.Error(), "invalid link") {
+		t.Errorf("expected an unknown purpose to be rejected, got %v", err)
+	}
+	_, err = registry.ApproveChange("MROLabMSP", admin, OrgChange{Action: OrgActionLink})
+	if err == nil {
+		t.Errorf("expected a link change without a link to be rejected")
+	}
+}
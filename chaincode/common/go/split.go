@@ -0,0 +1,110 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// A struct field tagged `ledger:"private"` is stored in the submitting organization's private data
+// collection; every other exported field is public. For example:
+//
+//	type Inspection struct {
+//		SerialNumber string `json:"serialNumber"`
+//		Inspector    string `json:"inspector" ledger:"private"`
+//	}
+const (
+	tagName    = "ledger"
+	tagPrivate = "private"
+)
+
+// isPrivate reports whether a struct field is tagged as private
+func isPrivate(field reflect.StructField) bool {
+	for _, option := range strings.Split(field.Tag.Get(tagName), ",") {
+		if option == tagPrivate {
+			return true
+		}
+	}
+	return false
+}
+
+// structValue returns the struct that v points to (or is)
+func structValue(v interface{}) (reflect.Value, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return reflect.Value{}, fmt.Errorf("nil %s", rv.Type())
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("%s is not a struct", rv.Type())
+	}
+	return rv, nil
+}
+
+// projection returns a struct holding the private (or public) fields of rv, in declaration order and
+// with their tags, together with the indexes of those fields in rv
+func projection(rv reflect.Value, private bool) (reflect.Value, []int) {
+	t := rv.Type()
+	var fields []reflect.StructField
+	var indexes []int
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || isPrivate(field) != private {
+			continue
+		}
+		fields = append(fields, reflect.StructField{Name: field.Name, Type: field.Type, Tag: field.Tag})
+		indexes = append(indexes, i)
+	}
+	return reflect.New(reflect.StructOf(fields)).Elem(), indexes
+}
+
+// marshalProjection marshals the public or private fields of v
+func marshalProjection(v interface{}, private bool) ([]byte, error) {
+	rv, err := structValue(v)
+	if err != nil {
+		return nil, err
+	}
+
+	out, indexes := projection(rv, private)
+	for i, index := range indexes {
+		out.Field(i).Set(rv.Field(index))
+	}
+	return json.Marshal(out.Interface())
+}
+
+// MarshalPublic returns the JSON encoding of the public fields of v, which must be a struct or a
+// pointer to one. Fields keep their declaration order, so the encoding matches a hand-written
+// struct holding the same fields.
+func MarshalPublic(v interface{}) ([]byte, error) {
+	return marshalProjection(v, false)
+}
+
+// MarshalPrivate returns the JSON encoding of the fields of v tagged `ledger:"private"`
+func MarshalPrivate(v interface{}) ([]byte, error) {
+	return marshalProjection(v, true)
+}
+
+// UnmarshalPrivate sets the private fields of the struct v points to from data, leaving its public
+// fields unchanged. Public fields present in data are ignored.
+func UnmarshalPrivate(data []byte, v interface{}) error {
+	if reflect.ValueOf(v).Kind() != reflect.Ptr {
+		return fmt.Errorf("UnmarshalPrivate requires a pointer, got %T", v)
+	}
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	in, indexes := projection(rv, true)
+	err = json.Unmarshal(data, in.Addr().Interface())
+	if err != nil {
+		return err
+	}
+	for i, index := range indexes {
+		rv.Field(index).Set(in.Field(i))
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// submissionObjectType prefixes the keys of submission records: submission~MSPID~SubmissionID
+const submissionObjectType = "submission"
+
+// CodeSubmissionConflict is the code of a SubmissionConflictError
+const CodeSubmissionConflict = "SUBMISSION_CONFLICT"
+
+// Submission records which record a submission ID created and the hash of the submitted content,
+// so that a client can safely resubmit after a timeout or crash
+type Submission struct {
+	SubmissionID string `json:"submissionId"`
+	ContentHash  string `json:"contentHash"` // hex SHA-256 of the submitted public content
+	RecordKey    string `json:"recordKey"`   // state key of the record the submission created
+	TxID         string `json:"txId"`
+}
+
+// SubmissionConflictError is returned when a submission ID is reused for different content. Its
+// message is JSON with code SUBMISSION_CONFLICT, so clients can tell it apart from other failures.
+type SubmissionConflictError struct {
+	Code         string `json:"code"`
+	SubmissionID string `json:"submissionId"`
+	ExistingTxID string `json:"existingTxId"`
+	Message      string `json:"message"`
+}
+
+// Error returns the error as JSON
+func (e *SubmissionConflictError) Error() string {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Sprintf("%s: %s", CodeSubmissionConflict, e.Message)
+	}
+	return string(b)
+}
+
+// SubmissionKey returns the key of an organization's submission record. Submission IDs are scoped to
+// the submitting organization, so organizations cannot collide with or probe each other's IDs.
+func SubmissionKey(stub CompositeKeyCreator, mspID, submissionID string) (string, error) {
+	return CompositeKey(stub, submissionObjectType, mspID, submissionID)
+}
+
+// DeriveSubmissionID derives a submission ID from the values that identify a submission's content,
+// for clients that do not supply one
+func DeriveSubmissionID(parts ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(hash[:])
+}
+
+// ContentHash returns the hex SHA-256 of content
+func ContentHash(content []byte) string {
+	hash := sha256.Sum256(content)
+	return hex.EncodeToString(hash[:])
+}
+
+// MatchSubmission compares a submission's content hash with the submission record stored under its ID
+// (nil if the ID is new). It returns the recorded submission if the content is identical, nil if the
+// ID is new, and a *SubmissionConflictError if the ID was used for different content.
+func MatchSubmission(recordJSON []byte, submissionID, contentHash string) (*Submission, error) {
+	if recordJSON == nil {
+		return nil, nil
+	}
+
+	var recorded Submission
+	err := json.Unmarshal(recordJSON, &recorded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal submission record: %v", err)
+	}
+	if recorded.ContentHash != contentHash {
+		return nil, &SubmissionConflictError{
+			Code:         CodeSubmissionConflict,
+			SubmissionID: submissionID,
+			ExistingTxID: recorded.TxID,
+			Message:      fmt.Sprintf("submission %s was already used in transaction %s for different content", submissionID, recorded.TxID),
+		}
+	}
+	return &recorded, nil
+}
@@ -0,0 +1,46 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSubmissionKeyAndID(t *testing.T) {
+	key, err := SubmissionKey(keyCreator{}, "MROLabMSP", "run-42")
+	if err != nil || key != "\x00submission\x00MROLabMSP\x00run-42\x00" {
+		t.Errorf("unexpected submission key %q (%v)", key, err)
+	}
+
+	id := DeriveSubmissionID("SN-1", "aa", "bb")
+	if len(id) != 64 || id != DeriveSubmissionID("SN-1", "aa", "bb") {
+		t.Errorf("expected a stable 64 hex character ID, got %q", id)
+	}
+	// Parts are separated, so moving a boundary changes the ID
+	if id == DeriveSubmissionID("SN-1a", "a", "bb") {
+		t.Errorf("expected different parts to derive different IDs")
+	}
+}
+
+func TestMatchSubmission(t *testing.T) {
+	recorded, err := MatchSubmission(nil, "run-42", ContentHash([]byte("a")))
+	if recorded != nil || err != nil {
+		t.Errorf("expected a new submission ID, got %+v (%v)", recorded, err)
+	}
+
+	recordJSON, _ := json.Marshal(Submission{SubmissionID: "run-42", ContentHash: ContentHash([]byte("a")), RecordKey: "k", TxID: "tx1"})
+	recorded, err = MatchSubmission(recordJSON, "run-42", ContentHash([]byte("a")))
+	if err != nil || recorded == nil || recorded.RecordKey != "k" {
+		t.Errorf("expected the recorded submission, got %+v (%v)", recorded, err)
+	}
+
+	_, err = MatchSubmission(recordJSON, "run-42", ContentHash([]byte("b")))
+	var conflict *SubmissionConflictError
+	if !errors.As(err, &conflict) || conflict.ExistingTxID != "tx1" {
+		t.Fatalf("expected a submission conflict, got %v", err)
+	}
+	var decoded SubmissionConflictError
+	if err := json.Unmarshal([]byte(conflict.Error()), &decoded); err != nil || decoded.Code != CodeSubmissionConflict {
+		t.Errorf("expected a JSON error with code %s, got %s", CodeSubmissionConflict, conflict.Error())
+	}
+}
@@ -0,0 +1,87 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// TransientPrivateKey is the transient map entry carrying the private fields of a submitted record as
+// JSON, e.g. {"inspector":"J. Smith"}. Transaction arguments are written to the block and readable by
+// every channel member; transient data reaches the endorsing peers only.
+const TransientPrivateKey = "private"
+
+// CodePrivateField is the field error code of a private field sent as a transaction argument
+const CodePrivateField = "private_field"
+
+// TransientGetter reads the transient map of a proposal (satisfied by shim.ChaincodeStubInterface)
+type TransientGetter interface {
+	GetTransient() (map[string][]byte, error)
+}
+
+// TransientPrivate returns the private fields sent under TransientPrivateKey, or nil if there are none
+func TransientPrivate(stub TransientGetter) ([]byte, error) {
+	transient, err := stub.GetTransient()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transient data: %v", err)
+	}
+	return transient[TransientPrivateKey], nil
+}
+
+// UnmarshalTransientPrivate sets the private fields of the struct v points to from transient data.
+// Unlike UnmarshalPrivate it rejects public and unknown fields, which belong in the arguments.
+func UnmarshalTransientPrivate(data []byte, v interface{}) error {
+	if reflect.ValueOf(v).Kind() != reflect.Ptr {
+		return fmt.Errorf("UnmarshalTransientPrivate requires a pointer, got %T", v)
+	}
+	rv, err := structValue(v)
+	if err != nil {
+		return err
+	}
+
+	in, indexes := projection(rv, true)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(in.Addr().Interface())
+	if err != nil {
+		return fmt.Errorf("invalid transient %q: %v", TransientPrivateKey, err)
+	}
+	for i, index := range indexes {
+		rv.Field(index).Set(in.Field(i))
+	}
+	return nil
+}
+
+// CheckNoPrivateFields records an error for every private field of v's type that is set in a JSON
+// document sent as a transaction argument. Empty strings and nulls are allowed, since they disclose
+// nothing. A document that is not a JSON object is left to the schema validation.
+func (e *FieldErrors) CheckNoPrivateFields(document []byte, v interface{}) {
+	rv, err := structValue(v)
+	if err != nil {
+		return
+	}
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(document, &fields) != nil {
+		return
+	}
+
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || !isPrivate(field) {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" {
+			name = field.Name
+		}
+		// encoding/json matches field names case-insensitively, so "Inspector" would set the field too
+		for key, value := range fields {
+			if strings.EqualFold(key, name) && string(value) != "null" && string(value) != `""` {
+				e.Add(key, CodePrivateField, fmt.Sprintf("is private: send it in the transient map under %q", TransientPrivateKey))
+			}
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package common
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type transientMap map[string][]byte
+
+func (m transientMap) GetTransient() (map[string][]byte, error) {
+	if m == nil {
+		return nil, errors.New("no proposal")
+	}
+	return m, nil
+}
+
+func TestTransientPrivate(t *testing.T) {
+	data, err := TransientPrivate(transientMap{TransientPrivateKey: []byte(`{"inspector":"J. Smith"}`)})
+	if err != nil || string(data) != `{"inspector":"J. Smith"}` {
+		t.Errorf("unexpected transient data %s (%v)", data, err)
+	}
+	data, err = TransientPrivate(transientMap{})
+	if err != nil || data != nil {
+		t.Errorf("expected no transient data, got %s (%v)", data, err)
+	}
+	if _, err = TransientPrivate(transientMap(nil)); err == nil {
+		t.Error("expected the stub error")
+	}
+}
+
+func TestUnmarshalTransientPrivate(t *testing.T) {
+	r := record{SerialNumber: "SN-1"}
+	err := UnmarshalTransientPrivate([]byte(`{"inspector":"J. Smith","notes":["edge"]}`), &r)
+	if err != nil || r.Inspector != "J. Smith" || len(r.Notes) != 1 || r.SerialNumber != "SN-1" {
+		t.Errorf("unexpected record %+v (%v)", r, err)
+	}
+
+	for _, data := range []string{`{"serialNumber":"SN-2"}`, `{"grade":"A"}`, `"J. Smith"`} {
+		err = UnmarshalTransientPrivate([]byte(data), &r)
+		if err == nil || !strings.Contains(err.Error(), `invalid transient "private"`) {
+			t.Errorf("%s: expected an error, got %v", data, err)
+		}
+	}
+	if r.SerialNumber != "SN-1" {
+		t.Errorf("expected public fields to be unchanged, got %q", r.SerialNumber)
+	}
+}
+
+func TestCheckNoPrivateFields(t *testing.T) {
+	var errs FieldErrors
+	errs.CheckNoPrivateFields([]byte(`{"serialNumber":"SN-1","Inspector":"J. Smith","notes":["edge"]}`), &record{})
+	if len(errs) != 2 || errs.Err().(*ValidationError).Errors[0].Field != "Inspector" || errs[0].Code != CodePrivateField {
+		t.Errorf("expected two private field errors, got %+v", errs)
+	}
+
+	errs = nil
+	errs.CheckNoPrivateFields([]byte(`{"serialNumber":"SN-1","inspector":"","notes":null}`), &record{})
+	errs.CheckNoPrivateFields([]byte(`not json`), &record{})
+	if len(errs) != 0 {
+		t.Errorf("expected empty private fields and non-objects to pass, got %+v", errs)
+	}
+}
@@ -0,0 +1,150 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// CodeValidationFailed is the code of a ValidationError
+const CodeValidationFailed = "VALIDATION_FAILED"
+
+// Field error codes of the semantic checks. Schema violations use the gojsonschema error type as the
+// code ("required", "invalid_type", "number_gte", "additional_property_not_allowed", ...).
+const (
+	CodeInvalidHash      = "invalid_hash"
+	CodeInvalidCID       = "invalid_cid"
+	CodeInvalidTimestamp = "invalid_timestamp"
+	CodeInvalidValue     = "invalid_value"
+	CodeOrder            = "order"
+	CodeOutOfBounds      = "out_of_bounds"
+	CodeRequired         = "required"
+)
+
+// FieldError is a machine-readable validation failure of one input field
+type FieldError struct {
+	Field   string `json:"field"` // dotted path of the field, e.g. "measurements.source.ar"; empty for the whole document
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationError reports every field error of an input. Its message is JSON, so clients can parse the
+// field errors from the error returned by the transaction.
+type ValidationError struct {
+	Code   string       `json:"code"`
+	Errors []FieldError `json:"errors"`
+}
+
+// Error returns the error as JSON
+func (e *ValidationError) Error() string {
+	b, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Sprintf("%s: %v", CodeValidationFailed, e.Errors)
+	}
+	return string(b)
+}
+
+// FieldErrors collects the field errors of an input
+type FieldErrors []FieldError
+
+// Add records a field error
+func (e *FieldErrors) Add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Prefixed returns the errors with their fields nested under prefix, e.g. the index of a batch item
+func (e FieldErrors) Prefixed(prefix string) FieldErrors {
+	prefixed := make(FieldErrors, len(e))
+	for i, fieldError := range e {
+		fieldError.Field = joinField(prefix, fieldError.Field)
+		prefixed[i] = fieldError
+	}
+	return prefixed
+}
+
+// Err returns the errors as a *ValidationError sorted by field, or nil if there are none
+func (e FieldErrors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	sorted := append(FieldErrors(nil), e...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Field < sorted[j].Field })
+	return &ValidationError{Code: CodeValidationFailed, Errors: sorted}
+}
+
+var sha256HexPattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// CheckSHA256 records an error unless value is a SHA-256 digest as 64 hex characters
+func (e *FieldErrors) CheckSHA256(field, value string) {
+	if !sha256HexPattern.MatchString(value) {
+		e.Add(field, CodeInvalidHash, "must be a SHA-256 hash of 64 hex characters")
+	}
+}
+
+// CheckCID records an error unless value parses as an IPFS content identifier
+func (e *FieldErrors) CheckCID(field, value string) {
+	if err := ParseCID(value); err != nil {
+		e.Add(field, CodeInvalidCID, fmt.Sprintf("must be an IPFS CID: %v", err))
+	}
+}
+
+// CheckRFC3339 records an error unless value is an RFC3339 timestamp
+func (e *FieldErrors) CheckRFC3339(field, value string) {
+	if _, err := time.Parse(time.RFC3339, value); err != nil {
+		e.Add(field, CodeInvalidTimestamp, "must be an RFC3339 timestamp")
+	}
+}
+
+// Schema is a compiled JSON Schema for validating transaction inputs
+type Schema struct {
+	schema *gojsonschema.Schema
+}
+
+// MustCompileSchema compiles a JSON Schema, panicking if it is invalid. Use it for schemas embedded in
+// the chaincode, where an invalid schema is a programming error.
+func MustCompileSchema(schemaJSON []byte) *Schema {
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schemaJSON))
+	if err != nil {
+		panic(fmt.Sprintf("invalid JSON schema: %v", err))
+	}
+	return &Schema{schema: schema}
+}
+
+// Validate checks a JSON document against the schema and returns its field errors. A document that
+// is not JSON is reported as a single error on the whole document.
+func (s *Schema) Validate(document []byte) FieldErrors {
+	var errs FieldErrors
+	result, err := s.schema.Validate(gojsonschema.NewBytesLoader(document))
+	if err != nil {
+		errs.Add("", "invalid_json", fmt.Sprintf("must be a JSON document: %v", err))
+		return errs
+	}
+	for _, resultError := range result.Errors() {
+		field := resultError.Field()
+		if field == gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+			field = ""
+		}
+		// Errors about a missing or unknown property are reported against the property itself
+		if property, ok := resultError.Details()["property"].(string); ok {
+			field = joinField(field, property)
+		}
+		errs.Add(field, resultError.Type(), resultError.Description())
+	}
+	return errs
+}
+
+// joinField joins a parent field path and a child field name
+func joinField(parent, child string) string {
+	switch {
+	case parent == "":
+		return child
+	case child == "":
+		return parent
+	}
+	return strings.Join([]string{parent, child}, ".")
+}
@@ -0,0 +1,118 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseCID(t *testing.T) {
+	valid := []string{
+		"QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG",
+		"QmT78zSuBmuS4z925WZfrqQ1qHaJ56DQaTfyMUF7F8ff5o",
+		"bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbzdi",
+		"zdj7WWeQ43G6JJvLWQWZpyHuAMq6uYWRjkBXFad11vE2LHhQ7",
+	}
+	for _, cid := range valid {
+		if err := ParseCID(cid); err != nil {
+			t.Errorf("%s: expected a valid CID, got %v", cid, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"ipfs://QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbdG",
+		"QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPbd0",
+		"QmYwAPJzv5CZsnA625s3Xf2nemtYgPpHdWEz79ojWnPb",
+		"bafybeigdyrzt5sfp7udm7hu76uh7y26nf3efuylqabf3oclgtqy55fbz",
+		"placeholder",
+	}
+	for _, cid := range invalid {
+		if err := ParseCID(cid); err == nil {
+			t.Errorf("%q: expected an invalid CID", cid)
+		}
+	}
+}
+
+func TestFieldErrorChecks(t *testing.T) {
+	var errs FieldErrors
+	errs.CheckSHA256("good", "b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9")
+	errs.CheckSHA256("prefixed", "sha256:b94d27b9934d3e08a52e52d7da7dabfac484efe37a5380ee9088f7ace2efcde9")
+	errs.CheckRFC3339("date", "2025-10-20")
+	errs.CheckRFC3339("timestamp", "2025-10-20T08:00:00Z")
+	errs.CheckCID("cid", "QmNotACid")
+
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %+v", errs)
+	}
+
+	var validationErr *ValidationError
+	if !errors.As(errs.Prefixed("items.0").Err(), &validationErr) {
+		t.Fatalf("expected a *ValidationError")
+	}
+	want := []FieldError{
+		{Field: "items.0.cid", Code: CodeInvalidCID},
+		{Field: "items.0.date", Code: CodeInvalidTimestamp},
+		{Field: "items.0.prefixed", Code: CodeInvalidHash},
+	}
+	for i, fieldError := range validationErr.Errors {
+		if fieldError.Field != want[i].Field || fieldError.Code != want[i].Code {
+			t.Errorf("error %d: expected %s %s, got %+v", i, want[i].Field, want[i].Code, fieldError)
+		}
+	}
+
+	// The message is JSON that clients can decode
+	var decoded ValidationError
+	if err := json.Unmarshal([]byte(validationErr.Error()), &decoded); err != nil {
+		t.Fatalf("expected a JSON message, got %v", err)
+	}
+	if decoded.Code != CodeValidationFailed || len(decoded.Errors) != 3 {
+		t.Errorf("unexpected decoded error %+v", decoded)
+	}
+
+	if (FieldErrors{}).Err() != nil {
+		t.Errorf("expected no error without field errors")
+	}
+}
+
+func TestSchemaValidate(t *testing.T) {
+	schema := MustCompileSchema([]byte(`{
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["serialNumber", "roi"],
+		"properties": {
+			"serialNumber": {"type": "string", "minLength": 1},
+			"confidence": {"type": "number", "minimum": 0, "maximum": 1},
+			"roi": {
+				"type": "object",
+				"properties": {"x1": {"type": "integer", "minimum": 0}}
+			}
+		}
+	}`))
+
+	if errs := schema.Validate([]byte(`{"serialNumber":"SN-1","confidence":0.5,"roi":{"x1":3}}`)); len(errs) != 0 {
+		t.Errorf("expected a valid document, got %+v", errs)
+	}
+
+	errs := schema.Validate([]byte(`{"confidence":1.5,"roi":{"x1":-1},"extra":true}`))
+	codes := map[string]string{}
+	for _, fieldError := range errs {
+		codes[fieldError.Field] = fieldError.Code
+	}
+	want := map[string]string{
+		"serialNumber": "required",
+		"confidence":   "number_lte",
+		"roi.x1":       "number_gte",
+		"extra":        "additional_property_not_allowed",
+	}
+	for field, code := range want {
+		if codes[field] != code {
+			t.Errorf("%s: expected %s, got %q (%+v)", field, code, codes[field], errs)
+		}
+	}
+
+	errs = schema.Validate([]byte(`not json`))
+	if len(errs) != 1 || errs[0].Field != "" || errs[0].Code != "invalid_json" {
+		t.Errorf("expected a single document error, got %+v", errs)
+	}
+}
@@ -0,0 +1,37 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+)
+
+// PrivateDataHasher reads the hash of private data, which every peer of the channel holds even for
+// collections its org is not a member of (satisfied by shim.ChaincodeStubInterface)
+type PrivateDataHasher interface {
+	GetPrivateDataHash(collection, key string) ([]byte, error)
+}
+
+// PrivateDataVerification is the result of checking a candidate private record against the on-chain hash
+type PrivateDataVerification struct {
+	Collection string `json:"collection"` // collection of the org holding the private data
+	Match      bool   `json:"match"`
+}
+
+// VerifyPrivateData compares the SHA-256 hash of a candidate private record with the hash of the
+// private data stored under a key, without reading the private data itself
+func VerifyPrivateData(stub PrivateDataHasher, collection, key string, candidate []byte) (*PrivateDataVerification, error) {
+	hash, err := stub.GetPrivateDataHash(collection, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read private data hash: %v", err)
+	}
+	if hash == nil {
+		return nil, fmt.Errorf("no private data in %s for the key", collection)
+	}
+
+	candidateHash := sha256.Sum256(candidate)
+	return &PrivateDataVerification{
+		Collection: collection,
+		Match:      bytes.Equal(hash, candidateHash[:]),
+	}, nil
+}
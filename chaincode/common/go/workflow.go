@@ -0,0 +1,259 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// Lifecycle states of an inspection record. A record is created as a draft or submitted, a Level 3
+// supervisor reviews it, and a supervisor releases or rejects it. Released and rejected records are final.
+const (
+	StatusDraft     = "draft"
+	StatusSubmitted = "submitted"
+	StatusReviewed  = "reviewed"
+	StatusReleased  = "released"
+	StatusRejected  = "rejected"
+)
+
+// nextStatuses lists the statuses a record may move to from each status
+var nextStatuses = map[string][]string{
+	StatusDraft:     {StatusSubmitted},
+	StatusSubmitted: {StatusReviewed, StatusRejected},
+	StatusReviewed:  {StatusReleased, StatusRejected},
+}
+
+// statusActorObjectType prefixes the private keys of transition actors: statusActor~RecordAttributes...~Status
+const statusActorObjectType = "statusActor"
+
+// StatusChange is a lifecycle transition in the public status history of a record
+type StatusChange struct {
+	Status       string `json:"status"`
+	Organization string `json:"organization"` // MSP ID of the caller
+	Role         string `json:"role"`         // role attribute of the caller's certificate
+	Comment      string `json:"comment,omitempty"`
+	TxID         string `json:"txId"`
+	Timestamp    string `json:"timestamp"` // transaction timestamp, RFC3339
+}
+
+// StatusActor identifies who moved a record to a status. It is kept in the owning organization's
+// private collection under its own key, apart from the record's private data, whose hash must keep
+// matching claims about the inspector.
+type StatusActor struct {
+	Status  string `json:"status"`
+	ID      string `json:"id"`      // client identity ID
+	Subject string `json:"subject"` // certificate subject DN
+	TxID    string `json:"txId"`
+}
+
+// StatusActorKey returns the private key of the actor who moved a record to status. The record is
+// identified by the attributes of its own key; it reaches each status at most once.
+func StatusActorKey(stub CompositeKeyCreator, status string, recordAttributes ...string) (string, error) {
+	attributes := append(append([]string{}, recordAttributes...), status)
+	return CompositeKey(stub, statusActorObjectType, attributes...)
+}
+
+// StatusRecord is a record that goes through the approval workflow
+type StatusRecord interface {
+	// WorkflowStatus returns the record's status and the MSP ID of the organization that owns it
+	WorkflowStatus() (status string, owner string)
+	// AppendStatusChange moves the record to the status of change and appends change to its history
+	AppendStatusChange(change StatusChange)
+	// StatusActorAttributes returns the attributes that identify the record in the keys of its actors
+	StatusActorAttributes() []string
+}
+
+// NewStatusChange describes the caller moving a record to status in the current transaction. The
+// change goes into the public status history, the returned actor into the org's private collection.
+func NewStatusChange(ctx contractapi.TransactionContextInterface, status, comment string) (StatusChange, *StatusActor, error) {
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return StatusChange{}, nil, fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	clientID, err := ctx.GetClientIdentity().GetID()
+	if err != nil {
+		return StatusChange{}, nil, fmt.Errorf("failed to get client ID: %v", err)
+	}
+	cert, err := ctx.GetClientIdentity().GetX509Certificate()
+	if err != nil || cert == nil {
+		return StatusChange{}, nil, fmt.Errorf("failed to get client certificate: %v", err)
+	}
+	role, _, err := ctx.GetClientIdentity().GetAttributeValue(AttrRole)
+	if err != nil {
+		return StatusChange{}, nil, fmt.Errorf("failed to read %s attribute: %v", AttrRole, err)
+	}
+	txTimestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return StatusChange{}, nil, fmt.Errorf("failed to get transaction timestamp: %v", err)
+	}
+
+	txID := ctx.GetStub().GetTxID()
+	change := StatusChange{
+		Status:       status,
+		Organization: clientMSPID,
+		Role:         role,
+		Comment:      comment,
+		TxID:         txID,
+		Timestamp:    time.Unix(txTimestamp.Seconds, int64(txTimestamp.Nanos)).UTC().Format(time.RFC3339),
+	}
+	actor := &StatusActor{Status: status, ID: clientID, Subject: cert.Subject.String(), TxID: txID}
+	return change, actor, nil
+}
+
+// PutStatusActor writes the actor of a record's transition to the owning org's private collection
+func PutStatusActor(ctx contractapi.TransactionContextInterface, collection string, record StatusRecord, actor *StatusActor) error {
+	key, err := StatusActorKey(ctx.GetStub(), actor.Status, record.StatusActorAttributes()...)
+	if err != nil {
+		return err
+	}
+	actorJSON, err := json.Marshal(actor)
+	if err != nil {
+		return fmt.Errorf("failed to marshal status actor: %v", err)
+	}
+	err = ctx.GetStub().PutPrivateData(collection, key, actorJSON)
+	if err != nil {
+		return fmt.Errorf("failed to write status actor: %v", err)
+	}
+	return nil
+}
+
+// ReadStatusActor reads who moved a record to status from the owning org's private collection (nil if nobody did)
+func ReadStatusActor(ctx contractapi.TransactionContextInterface, collection string, record StatusRecord,
+	status string) (*StatusActor, error) {
+
+	key, err := StatusActorKey(ctx.GetStub(), status, record.StatusActorAttributes()...)
+	if err != nil {
+		return nil, err
+	}
+	actorJSON, err := ctx.GetStub().GetPrivateData(collection, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read status actor: %v", err)
+	}
+	if actorJSON == nil {
+		return nil, nil
+	}
+	var actor StatusActor
+	err = json.Unmarshal(actorJSON, &actor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal status actor: %v", err)
+	}
+	return &actor, nil
+}
+
+// CheckStatusChange returns an error unless the caller may move records to status: its role must allow
+// the transition for records of method, and a rejection needs a reason
+func CheckStatusChange(ctx contractapi.TransactionContextInterface, status, comment, method string) error {
+	err := CheckTransitionRole(ctx.GetClientIdentity(), status, method)
+	if err != nil {
+		return err
+	}
+	if status == StatusRejected && comment == "" {
+		return fmt.Errorf("a reason is required to reject an inspection")
+	}
+	return nil
+}
+
+// ChangeStatus moves a record to status on behalf of the caller, once CheckStatusChange passed. The
+// caller's organization must own the record, the transition must be allowed, and a review must be
+// independent: the actor who recorded or submitted the record cannot review it. The change is appended
+// to the record's history and the caller written as its actor to collection, the owner's private
+// collection; writing the record is left to the chaincode. name identifies the record in errors.
+func ChangeStatus(ctx contractapi.TransactionContextInterface, record StatusRecord, name, collection,
+	status, comment string) error {
+
+	from, owner := record.WorkflowStatus()
+	clientMSPID, err := ctx.GetClientIdentity().GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to get client MSP ID: %v", err)
+	}
+	if owner != clientMSPID {
+		return fmt.Errorf("only the owning organization %s can change the status of inspection %s", owner, name)
+	}
+
+	err = CheckTransition(from, status)
+	if err != nil {
+		return fmt.Errorf("inspection %s: %v", name, err)
+	}
+	if status == StatusReviewed {
+		clientID, err := ctx.GetClientIdentity().GetID()
+		if err != nil {
+			return fmt.Errorf("failed to get client ID: %v", err)
+		}
+		var actors []*StatusActor
+		for _, recorded := range []string{StatusDraft, StatusSubmitted} {
+			actor, err := ReadStatusActor(ctx, collection, record, recorded)
+			if err != nil {
+				return err
+			}
+			actors = append(actors, actor)
+		}
+		err = CheckIndependentReview(clientID, actors...)
+		if err != nil {
+			return err
+		}
+	}
+
+	change, actor, err := NewStatusChange(ctx, status, comment)
+	if err != nil {
+		return err
+	}
+	record.AppendStatusChange(change)
+	return PutStatusActor(ctx, collection, record, actor)
+}
+
+// InitialStatus returns the status of a new record: a requested draft stays a draft, anything else
+// is submitted for review
+func InitialStatus(requested string) (string, error) {
+	switch requested {
+	case "", StatusSubmitted:
+		return StatusSubmitted, nil
+	case StatusDraft:
+		return StatusDraft, nil
+	}
+	return "", fmt.Errorf("new records must be %s or %s, got %q", StatusDraft, StatusSubmitted, requested)
+}
+
+// CheckTransition returns an error unless a record may move from status from to status to. Records
+// committed before the approval workflow have no status; they were never reviewed, so they cannot
+// enter the workflow and stay as recorded.
+func CheckTransition(from, to string) error {
+	if from == "" {
+		return fmt.Errorf("record was committed before the approval workflow and has no status to change")
+	}
+	if from == StatusReleased {
+		return fmt.Errorf("record is released and can no longer be changed")
+	}
+	for _, next := range nextStatuses[from] {
+		if next == to {
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot change status from %s to %s", from, to)
+}
+
+// CheckTransitionRole returns an error unless the caller may move a record to status: certified
+// inspectors submit drafts, Level 3 supervisors review, and supervisors release and reject
+func CheckTransitionRole(id AttributeReader, status, method string) error {
+	switch status {
+	case StatusSubmitted:
+		return CheckInspector(id, method)
+	case StatusReviewed:
+		return CheckReviewer(id, method)
+	case StatusReleased, StatusRejected:
+		return CheckSupervisor(id)
+	}
+	return fmt.Errorf("unknown status %q", status)
+}
+
+// CheckIndependentReview returns an error if the reviewer is the actor who created or submitted the
+// record (nil actors are skipped)
+func CheckIndependentReview(reviewerID string, actors ...*StatusActor) error {
+	for _, actor := range actors {
+		if actor != nil && actor.ID == reviewerID {
+			return fmt.Errorf("results must be reviewed by someone other than the inspector who recorded them")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInitialStatus(t *testing.T) {
+	for requested, expected := range map[string]string{"": StatusSubmitted, StatusSubmitted: StatusSubmitted, StatusDraft: StatusDraft} {
+		if status, err := InitialStatus(requested); err != nil || status != expected {
+			t.Errorf("%q: expected %s, got %q (%v)", requested, expected, status, err)
+		}
+	}
+	if _, err := InitialStatus(StatusReleased); err == nil {
+		t.Error("expected a new record to be rejected as released")
+	}
+}
+
+func TestCheckTransition(t *testing.T) {
+	allowed := [][2]string{
+		{StatusDraft, StatusSubmitted},
+		{StatusSubmitted, StatusReviewed},
+		{StatusSubmitted, StatusRejected},
+		{StatusReviewed, StatusReleased},
+		{StatusReviewed, StatusRejected},
+	}
+	for _, transition := range allowed {
+		if err := CheckTransition(transition[0], transition[1]); err != nil {
+			t.Errorf("%s -> %s: %v", transition[0], transition[1], err)
+		}
+	}
+
+	tests := []struct {
+		from, to, message string
+	}{
+		{StatusDraft, StatusReviewed, "cannot change status from draft to reviewed"},
+		{StatusSubmitted, StatusReleased, "cannot change status from submitted to released"},
+		{StatusRejected, StatusSubmitted, "cannot change status from rejected to submitted"},
+		{StatusReleased, StatusRejected, "released"},
+		{"", StatusReviewed, "committed before the approval workflow"},
+	}
+	for _, test := range tests {
+		err := CheckTransition(test.from, test.to)
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("%q -> %s: expected %q, got %v", test.from, test.to, test.message, err)
+		}
+	}
+}
+
+func TestCheckTransitionRole(t *testing.T) {
+	inspector := attributes{AttrRole: "inspector", AttrNDTLevel: "2", AttrNDTMethod: "thermography"}
+	supervisor := attributes{AttrRole: "supervisor", AttrNDTLevel: "3", AttrNDTMethod: "thermography"}
+
+	if err := CheckTransitionRole(inspector, StatusSubmitted, "thermography"); err != nil {
+		t.Errorf("expected an inspector to submit, got %v", err)
+	}
+	for _, status := range []string{StatusReviewed, StatusReleased, StatusRejected} {
+		if err := CheckTransitionRole(inspector, status, "thermography"); err == nil {
+			t.Errorf("expected an inspector not to move records to %s", status)
+		}
+		if err := CheckTransitionRole(supervisor, status, "thermography"); err != nil {
+			t.Errorf("expected a supervisor to move records to %s, got %v", status, err)
+		}
+	}
+	if err := CheckTransitionRole(supervisor, StatusDraft, "thermography"); err == nil {
+		t.Error("expected an error for a status without a transition")
+	}
+}
+
+func TestCheckIndependentReview(t *testing.T) {
+	submitter := &StatusActor{Status: StatusSubmitted, ID: "x509::CN=inspector1"}
+	if err := CheckIndependentReview("x509::CN=supervisor1", nil, submitter); err != nil {
+		t.Errorf("expected an independent review, got %v", err)
+	}
+	if err := CheckIndependentReview("x509::CN=inspector1", nil, submitter); err == nil {
+		t.Error("expected the submitter to be rejected as reviewer")
+	}
+}
+
+func TestStatusActorKey(t *testing.T) {
+	attributes := []string{"SN-1", "tx1"}
+	key, err := StatusActorKey(keyCreator{}, StatusReviewed, attributes...)
+	if err != nil || key != "\x00statusActor\x00SN-1\x00tx1\x00reviewed\x00" || len(attributes) != 2 {
+		t.Errorf("unexpected key %q (%v)", key, err)
+	}
+}
@@ -15,6 +15,8 @@ CC_SRC_PATH="../../chaincode/blade-inspection/go"
 
 echo ""
 echo "Step 1: Packaging chaincode..."
+# The shared chaincode/common module lives outside the chaincode directory, so vendor it
+(cd ${CC_SRC_PATH} && go mod vendor)
 peer lifecycle chaincode package ${CC_NAME}.tar.gz \
   --path ${CC_SRC_PATH} \
   --lang golang \
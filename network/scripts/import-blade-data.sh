@@ -14,152 +14,104 @@ export CORE_PEER_LOCALMSPID="MROLabMSP"
 export CORE_PEER_TLS_ROOTCERT_FILE=$PWD/../../organizations/peerOrganizations/mrolab.thermotrace.com/peers/peer0.mrolab.thermotrace.com/tls/ca.crt
 export CORE_PEER_MSPCONFIGPATH=$PWD/../../organizations/peerOrganizations/mrolab.thermotrace.com/users/Admin@mrolab.thermotrace.com/msp
 export CORE_PEER_ADDRESS=peer0.mrolab.thermotrace.com:7051
-export ORDERER_CA=$PWD/../../organizations/ordererOrganizations/thermotrace.com/orderers/orderer1.thermotrace.com/msp/tlscacerts/tlsca.thermotrace.com-cert.pem
-
-# Function to parse measurement (convert inches to mm, handle N/A)
-parse_measurement() {
-    local value="$1"
-    
-    # Remove spaces and quotes
-    value=$(echo "$value" | tr -d ' "')
-    
-    # Handle empty or N/A
-    if [ -z "$value" ] || [ "$value" = "N/A" ]; then
-        echo "0.0"
-        return
-    fi
-    
-    # Check if it has "mm" suffix
-    if [[ "$value" == *"mm"* ]]; then
-        # Remove "mm" and return
-        echo "$value" | sed 's/mm//'
-        return
-    fi
-    
-    # If numeric and < 20, assume inches and convert to mm
-    if [[ "$value" =~ ^[0-9.]+$ ]]; then
-        if (( $(echo "$value < 20" | bc -l) )); then
-            # Convert inches to mm (multiply by 25.4)
-            echo "$value * 25.4" | bc -l
-            return
-        fi
-    fi
-    
-    # Otherwise return as-is
-    echo "$value" | sed 's/[^0-9.]//g'
-}
+
+# Inspections are submitted by a certified inspector (role=inspector, ndt.method=dimensional)
+INSPECTOR_MSP=$PWD/../../organizations/peerOrganizations/mrolab.thermotrace.com/users/Inspector1@mrolab.thermotrace.com/msp
+
+# Build the Go importer (submits through the Fabric Gateway)
+IMPORTER_SRC=$PWD/../../applications/blade-importer
+IMPORTER_BIN=$PWD/blade-importer
+(cd "$IMPORTER_SRC" && go build -o "$IMPORTER_BIN" .)
 
 # Function to import CSV file
 import_csv() {
     local csv_file=$1
     local occasion=$2
-    
+    local unit=$3
+    local inspection_date=$4
+
     echo ""
     echo "Processing: $csv_file ($occasion)"
     echo "----------------------------------------"
-    
+
     if [ ! -f "$csv_file" ]; then
         echo "Error: File not found: $csv_file"
         return
     fi
-    
-    local count=0
-    local success=0
-    local errors=0
-    
-    # Skip header line and process data
-    tail -n +2 "$csv_file" | while IFS=',' read -r pn sn ar ap an am al ak aj ah ag af ae ad ac ab rest; do
-        # Skip empty lines
-        [ -z "$pn" ] && continue
-        
-        count=$((count + 1))
-        
-        # Clean part number and serial number
-        pn=$(echo "$pn" | tr -d ' "')
-        sn=$(echo "$sn" | tr -d ' "')
-        
-        # Parse all measurements
-        ar_val=$(parse_measurement "$ar")
-        ap_val=$(parse_measurement "$ap")
-        an_val=$(parse_measurement "$an")
-        am_val=$(parse_measurement "$am")
-        al_val=$(parse_measurement "$al")
-        ak_val=$(parse_measurement "$ak")
-        aj_val=$(parse_measurement "$aj")
-        ah_val=$(parse_measurement "$ah")
-        ag_val=$(parse_measurement "$ag")
-        af_val=$(parse_measurement "$af")
-        ae_val=$(parse_measurement "$ae")
-        ad_val=$(parse_measurement "$ad")
-        ac_val=$(parse_measurement "$ac")
-        ab_val=$(parse_measurement "$ab")
-        
-        # Create JSON for inspection
-        inspection_json=$(cat <<INSPJSON
-{
-  "partNumber": "$pn",
-  "serialNumber": "$sn",
-  "occasion": "$occasion",
-  "inspectionDate": "$(date -u +%Y-%m-%dT%H:%M:%SZ)",
-  "inspector": "DataImport",
-  "organization": "MROLabMSP",
-  "measurements": {
-    "ar": $ar_val,
-    "ap": $ap_val,
-    "an": $an_val,
-    "am": $am_val,
-    "al": $al_val,
-    "ak": $ak_val,
-    "aj": $aj_val,
-    "ah": $ah_val,
-    "ag": $ag_val,
-    "af": $af_val,
-    "ae": $ae_val,
-    "ad": $ad_val,
-    "ac": $ac_val,
-    "ab": $ab_val
-  },
-  "csvHash": "",
-  "recordedAt": "$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+
+    # Keep going if some rows fail - the importer reports them individually
+    "$IMPORTER_BIN" \
+        -csv "$csv_file" \
+        -occasion "$occasion" \
+        -unit "$unit" \
+        -date "$inspection_date" \
+        -inspector "DataImport" \
+        -msp-id "$CORE_PEER_LOCALMSPID" \
+        -cert "$INSPECTOR_MSP/signcerts/cert.pem" \
+        -key "$INSPECTOR_MSP/keystore" \
+        -tls-cert "$CORE_PEER_TLS_ROOTCERT_FILE" \
+        -peer "$CORE_PEER_ADDRESS" \
+        -peer-host-alias peer0.mrolab.thermotrace.com \
+        -channel inspection-channel \
+        -chaincode bladeinspection \
+        "${IMPORTER_ARGS[@]}" || true
 }
-INSPJSON
-)
-        
-        # Submit to blockchain
-        echo -n "  Importing ${pn}_${sn}_${occasion}... "
-        
-        if peer chaincode invoke \
-            -o orderer1.thermotrace.com:7050 \
-            --ordererTLSHostnameOverride orderer1.thermotrace.com \
-            --tls --cafile ${ORDERER_CA} \
-            -C inspection-channel \
-            -n bladeinspection \
-            --peerAddresses peer0.mrolab.thermotrace.com:7051 \
-            --tlsRootCertFiles $PWD/../../organizations/peerOrganizations/mrolab.thermotrace.com/peers/peer0.mrolab.thermotrace.com/tls/ca.crt \
-            --peerAddresses peer0.manufacturer.thermotrace.com:9051 \
-            --tlsRootCertFiles $PWD/../../organizations/peerOrganizations/manufacturer.thermotrace.com/peers/peer0.manufacturer.thermotrace.com/tls/ca.crt \
-            -c "{\"function\":\"AddInspection\",\"Args\":[\"$inspection_json\"]}" \
-            2>&1 | grep -q "Chaincode invoke successful"; then
-            echo "✓"
-            success=$((success + 1))
-        else
-            echo "✗"
-            errors=$((errors + 1))
-        fi
-        
-        # Small delay to avoid overwhelming the network
-        sleep 1
-    done
-    
-    echo "  Processed: $(tail -n +2 "$csv_file" | wc -l) records"
+
+# Extra importer flags, e.g. ./import-blade-data.sh -dry-run
+IMPORTER_ARGS=("$@")
+
+# Register the blades of a CSV file in the part registry, which inspections require. Parts are
+# registered by the manufacturer; already registered parts are reported and skipped, and any other
+# failure stops the script.
+register_parts() {
+    local csv_file=$1
+    local unit=$2
+    local manufacturer_org=$PWD/../../organizations/peerOrganizations/manufacturer.thermotrace.com
+    local manufacturer_msp=$manufacturer_org/users/Admin@manufacturer.thermotrace.com/msp
+
+    echo ""
+    echo "Registering parts: $csv_file"
+    echo "----------------------------------------"
+
+    "$IMPORTER_BIN" \
+        -register-parts \
+        -csv "$csv_file" \
+        -unit "$unit" \
+        -part-manufacturer "$PART_MANUFACTURER" \
+        -part-material "$PART_MATERIAL" \
+        -part-manufacture-date "$PART_MANUFACTURE_DATE" \
+        -msp-id ManufacturerMSP \
+        -cert "$manufacturer_msp/signcerts/cert.pem" \
+        -key "$manufacturer_msp/keystore" \
+        -tls-cert "$manufacturer_org/peers/peer0.manufacturer.thermotrace.com/tls/ca.crt" \
+        -peer peer0.manufacturer.thermotrace.com:9051 \
+        -peer-host-alias peer0.manufacturer.thermotrace.com \
+        -channel inspection-channel \
+        -chaincode bladeinspection \
+        "${IMPORTER_ARGS[@]}"
 }
 
-# Import all three CSV files
+# Details recorded for the sample blades, whose manufacturing records are not part of the CSV files
+PART_MANUFACTURER=${PART_MANUFACTURER:-"Sample OEM"}
+PART_MATERIAL=${PART_MATERIAL:-"Nickel superalloy"}
+PART_MANUFACTURE_DATE=${PART_MANUFACTURE_DATE:-"2020-01-01"}
+
+# Inspection date of each file. The date is part of every inspection's identity, so rerunning the import
+# with the same dates reports the recorded rows as duplicates instead of adding new inspections.
+BEFORE_SURFACING_DATE=${BEFORE_SURFACING_DATE:-"2025-10-20T08:00:00Z"}
+MANUAL_DATE=${MANUAL_DATE:-"2025-10-21T08:00:00Z"}
+AFTER_SURFACING_DATE=${AFTER_SURFACING_DATE:-"2025-10-22T08:00:00Z"}
+
+# Import all three CSV files (manual.csv is recorded in inches without unit suffixes)
 DATA_DIR="$PWD/../../sample-data"
 
-import_csv "$DATA_DIR/before_surfacing.csv" "before_surfacing"
-import_csv "$DATA_DIR/manual.csv" "manual"
-import_csv "$DATA_DIR/after_surfacing.csv" "after_surfacing"
+register_parts "$DATA_DIR/before_surfacing.csv" "mm"
+register_parts "$DATA_DIR/manual.csv" "in"
+register_parts "$DATA_DIR/after_surfacing.csv" "mm"
+
+import_csv "$DATA_DIR/before_surfacing.csv" "before_surfacing" "mm" "$BEFORE_SURFACING_DATE"
+import_csv "$DATA_DIR/manual.csv" "manual" "in" "$MANUAL_DATE"
+import_csv "$DATA_DIR/after_surfacing.csv" "after_surfacing" "mm" "$AFTER_SURFACING_DATE"
 
 echo ""
 echo "=========================================="
@@ -11,6 +11,8 @@ CC_VERSION="1.2"
 CC_SEQUENCE=3
 CC_SRC_PATH="../../chaincode/blade-inspection/go"
 
+# The shared chaincode/common module lives outside the chaincode directory, so vendor it
+(cd ${CC_SRC_PATH} && go mod vendor)
 peer lifecycle chaincode package ${CC_NAME}_v1.2.tar.gz \
   --path ${CC_SRC_PATH} --lang golang --label ${CC_NAME}_${CC_VERSION}
 